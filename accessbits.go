@@ -0,0 +1,139 @@
+package acr122u
+
+import "fmt"
+
+// Access is the set of keys allowing an operation on a MIFARE Classic block
+type Access byte
+
+// Accesses
+const (
+	AccessNever Access = 0x0
+	AccessKeyA  Access = 0x1
+	AccessKeyB  Access = 0x2
+	AccessKeyAB        = AccessKeyA | AccessKeyB
+)
+
+func (a Access) String() string {
+	switch a {
+	case AccessNever:
+		return "never"
+	case AccessKeyA:
+		return "key A"
+	case AccessKeyB:
+		return "key B"
+	case AccessKeyAB:
+		return "key A|B"
+	default:
+		return fmt.Sprintf("Access(%d)", byte(a))
+	}
+}
+
+// BlockAccess describes the access conditions of a data block
+type BlockAccess struct {
+	Read      Access
+	Write     Access
+	Increment Access
+	// Decrement also covers transfer and restore
+	Decrement Access
+}
+
+// TrailerAccess describes the access conditions of the sector trailer.
+// Key A can never be read.
+type TrailerAccess struct {
+	WriteKeyA       Access
+	ReadAccessBits  Access
+	WriteAccessBits Access
+	ReadKeyB        Access
+	WriteKeyB       Access
+}
+
+// AccessConditions are the decoded access bits of a MIFARE Classic sector.
+// In the 16 block sectors of a 4K card each data block access condition
+// applies to a group of 5 blocks.
+type AccessConditions struct {
+	// Bits are the access bits C1 C2 C3 (bits 2-0) of blocks 0-2 and the trailer
+	Bits    [4]byte
+	Blocks  [3]BlockAccess
+	Trailer TrailerAccess
+}
+
+// blockAccess are the data block access conditions indexed by C1 C2 C3
+var blockAccess = [8]BlockAccess{
+	0b000: {AccessKeyAB, AccessKeyAB, AccessKeyAB, AccessKeyAB},
+	0b010: {AccessKeyAB, AccessNever, AccessNever, AccessNever},
+	0b100: {AccessKeyAB, AccessKeyB, AccessNever, AccessNever},
+	0b110: {AccessKeyAB, AccessKeyB, AccessKeyB, AccessKeyAB},
+	0b001: {AccessKeyAB, AccessNever, AccessNever, AccessKeyAB},
+	0b011: {AccessKeyB, AccessKeyB, AccessNever, AccessNever},
+	0b101: {AccessKeyB, AccessNever, AccessNever, AccessNever},
+	0b111: {AccessNever, AccessNever, AccessNever, AccessNever},
+}
+
+// trailerAccess are the sector trailer access conditions indexed by C1 C2 C3
+var trailerAccess = [8]TrailerAccess{
+	0b000: {AccessKeyA, AccessKeyA, AccessNever, AccessKeyA, AccessKeyA},
+	0b010: {AccessNever, AccessKeyA, AccessNever, AccessKeyA, AccessNever},
+	0b100: {AccessKeyB, AccessKeyAB, AccessNever, AccessNever, AccessKeyB},
+	0b110: {AccessNever, AccessKeyAB, AccessNever, AccessNever, AccessNever},
+	0b001: {AccessKeyA, AccessKeyA, AccessKeyA, AccessKeyA, AccessKeyA},
+	0b011: {AccessKeyB, AccessKeyAB, AccessKeyB, AccessNever, AccessKeyB},
+	0b101: {AccessNever, AccessKeyAB, AccessKeyB, AccessNever, AccessNever},
+	0b111: {AccessNever, AccessKeyAB, AccessNever, AccessNever, AccessNever},
+}
+
+// ReadAccessBits authenticates the sector, reads its trailer and decodes
+// the access bits. Returns ErrInvalidAccessBits if the inverted copies of
+// the access bits do not match, which indicates a corrupted trailer, or
+// ErrInvalidParameter if the sector does not exist on the card. MIFARE Plus
+// cards are supported in SL1.
+func (c *card) ReadAccessBits(sector byte, key [6]byte, keyType KeyType) (*AccessConditions, error) {
+	if err := c.validateSector(sector); err != nil {
+		return nil, err
+	}
+
+	trailer := TrailerBlock(sector)
+
+	if err := c.Authenticate(trailer, key, keyType); err != nil {
+		return nil, err
+	}
+
+	data, err := c.ReadBlock(trailer)
+	if err != nil {
+		return nil, err
+	}
+
+	ac, err := decodeAccessBits(data[6:9])
+	if err != nil {
+		return nil, wrapError(fmt.Sprintf("sector %d", sector), err)
+	}
+
+	return ac, nil
+}
+
+// decodeAccessBits decodes the access bytes 6-8 of a sector trailer:
+//
+//	byte 6: ^C2 (bits 7-4), ^C1 (bits 3-0)
+//	byte 7:  C1 (bits 7-4), ^C3 (bits 3-0)
+//	byte 8:  C3 (bits 7-4),  C2 (bits 3-0)
+//
+// where bit n of each nibble belongs to block n of the sector.
+func decodeAccessBits(b []byte) (*AccessConditions, error) {
+	c1, c2, c3 := b[1]>>4, b[2]&0x0F, b[2]>>4
+
+	if ^b[0]&0x0F != c1 || ^b[0]>>4 != c2 || ^b[1]&0x0F != c3 {
+		return nil, wrapError(fmt.Sprintf("access bits %X", b), ErrInvalidAccessBits)
+	}
+
+	ac := &AccessConditions{}
+
+	for i := range ac.Bits {
+		ac.Bits[i] = (c1>>i&1)<<2 | (c2>>i&1)<<1 | c3>>i&1
+	}
+
+	for i := range ac.Blocks {
+		ac.Blocks[i] = blockAccess[ac.Bits[i]]
+	}
+	ac.Trailer = trailerAccess[ac.Bits[3]]
+
+	return ac, nil
+}
@@ -0,0 +1,103 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeAccessBits(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		bytes   []byte
+		bits    [4]byte
+		blocks  [3]BlockAccess
+		trailer TrailerAccess
+	}{
+		{
+			"Transport",
+			[]byte{0xFF, 0x07, 0x80},
+			[4]byte{0b000, 0b000, 0b000, 0b001},
+			[3]BlockAccess{blockAccess[0], blockAccess[0], blockAccess[0]},
+			TrailerAccess{AccessKeyA, AccessKeyA, AccessKeyA, AccessKeyA, AccessKeyA},
+		},
+		{
+			"Key B trailer",
+			[]byte{0x7F, 0x07, 0x88},
+			[4]byte{0b000, 0b000, 0b000, 0b011},
+			[3]BlockAccess{blockAccess[0], blockAccess[0], blockAccess[0]},
+			TrailerAccess{AccessKeyB, AccessKeyAB, AccessKeyB, AccessNever, AccessKeyB},
+		},
+		{
+			"Value blocks",
+			[]byte{0x08, 0x77, 0x8F},
+			[4]byte{0b110, 0b110, 0b110, 0b011},
+			[3]BlockAccess{
+				{AccessKeyAB, AccessKeyB, AccessKeyB, AccessKeyAB},
+				{AccessKeyAB, AccessKeyB, AccessKeyB, AccessKeyAB},
+				{AccessKeyAB, AccessKeyB, AccessKeyB, AccessKeyAB},
+			},
+			TrailerAccess{AccessKeyB, AccessKeyAB, AccessKeyB, AccessNever, AccessKeyB},
+		},
+		{
+			"Mixed",
+			[]byte{0xFC, 0x37, 0x80},
+			[4]byte{0b100, 0b100, 0b000, 0b001},
+			[3]BlockAccess{
+				{AccessKeyAB, AccessKeyB, AccessNever, AccessNever},
+				{AccessKeyAB, AccessKeyB, AccessNever, AccessNever},
+				blockAccess[0],
+			},
+			TrailerAccess{AccessKeyA, AccessKeyA, AccessKeyA, AccessKeyA, AccessKeyA},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ac, err := decodeAccessBits(tc.bytes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ac.Bits != tc.bits {
+				t.Fatalf("ac.Bits = %03b, want %03b", ac.Bits, tc.bits)
+			}
+
+			if ac.Blocks != tc.blocks {
+				t.Fatalf("ac.Blocks = %v, want %v", ac.Blocks, tc.blocks)
+			}
+
+			if ac.Trailer != tc.trailer {
+				t.Fatalf("ac.Trailer = %v, want %v", ac.Trailer, tc.trailer)
+			}
+		})
+	}
+
+	if _, err := decodeAccessBits([]byte{0xFF, 0x07, 0x81}); !errors.Is(err, ErrInvalidAccessBits) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCardReadAccessBits(t *testing.T) {
+	m := newMockMifare(atrMifareClassic4K)
+	copy(m.blocks[TrailerBlock(35)][6:9], []byte{0x7F, 0x07, 0x88})
+
+	ac, err := m.card().ReadAccessBits(35, m.key, KeyA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := ac.Bits[3], byte(0b011); got != want {
+		t.Fatalf("ac.Bits[3] = %03b, want %03b", got, want)
+	}
+
+	if _, err := m.card().ReadAccessBits(35, [6]byte{}, KeyA); !errors.Is(err, ErrOperationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Sector 40 would wrap to the trailer of sector 3
+	if _, err := m.card().ReadAccessBits(40, m.key, KeyA); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := newMockMifare(atrMifareClassic1K).card().ReadAccessBits(16, m.key, KeyA); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -55,6 +55,7 @@ type ShareMode uint32
 var (
 	ShareExclusive ShareMode = 0x1
 	ShareShared    ShareMode = 0x2
+	ShareDirect    ShareMode = 0x3
 )
 
 // Protocol is the protocol type
@@ -68,31 +69,92 @@ var (
 	ProtocolAny                = ProtocolT0 | ProtocolT1
 )
 
+// Scope is the scope of the PC/SC context
+type Scope uint32
+
+// Scopes
+var (
+	ScopeUser     Scope = 0x0
+	ScopeTerminal Scope = 0x1
+	ScopeSystem   Scope = 0x2
+	ScopeGlobal   Scope = 0x3
+)
+
+// Disposition is the action taken on the card when disconnecting
+type Disposition uint32
+
+// Dispositions
+var (
+	LeaveCard   Disposition = 0x0
+	ResetCard   Disposition = 0x1
+	UnpowerCard Disposition = 0x2
+	EjectCard   Disposition = 0x3
+)
+
 // Commands that can be transmitted to a *scard.Card
 var (
-	cmdGetUID = []byte{0xFF, 0xCA, 0x00, 0x00, 0x04}
+	cmdGetUID = []byte{0xFF, 0xCA, 0x00, 0x00, 0x00}
 )
 
+// uidLengths are the explicit UID lengths (Le) tried when the reader
+// returns no UID for cmdGetUID with Le=0
+var uidLengths = []byte{0x04, 0x07, 0x0A}
+
+// dispatchBuffer is the default number of read cards queued for the dispatcher
+const dispatchBuffer = 16
+
+// pollInterval is how long a read loop waits in GetStatusChange before
+// checking whether it should stop
+var pollInterval = time.Second
+
+// uidRetryDelay is the delay before retrying a UID read answered with 0x63 0x00
+var uidRetryDelay = 5 * time.Millisecond
+
 // Response codes
 var (
 	rcOperationSuccess = []byte{0x90, 0x00}
 	rcOperationFailed  = []byte{0x63, 0x00}
 )
 
-// scardContext is the interface used to communicate
+// Status words
+const (
+	swSuccess         uint16 = 0x9000
+	swOperationFailed uint16 = 0x6300
+)
+
+// PCSCContext is the interface used to communicate
 // with one or more ACR122U USB NFC Readers.
-type scardContext interface {
-	Connect(string, scard.ShareMode, scard.Protocol) (*scard.Card, error)
+// It is implemented by the PC/SC resource manager and can be replaced
+// using NewContext, for example by the simulator in package acr122utest.
+type PCSCContext interface {
+	Connect(string, scard.ShareMode, scard.Protocol) (PCSCCard, error)
 	ListReaders() ([]string, error)
 	Release() error
 	IsValid() (bool, error)
 	GetStatusChange(readerStates []scard.ReaderState, timeout time.Duration) error
 }
 
-// scardCard is the interface used by a *card to
+// scardContextAdapter adapts a *scard.Context to the PCSCContext interface
+type scardContextAdapter struct {
+	*scard.Context
+}
+
+// Connect returns the connected *scard.Card as a PCSCCard
+func (a scardContextAdapter) Connect(reader string, mode scard.ShareMode, proto scard.Protocol) (PCSCCard, error) {
+	sc, err := a.Context.Connect(reader, mode, proto)
+	if err != nil {
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+// PCSCCard is the interface used by a Card to
 // communicate with the underlying *scard.Card
-type scardCard interface {
+type PCSCCard interface {
 	Transmit([]byte) ([]byte, error)
 	Status() (*scard.CardStatus, error)
 	Disconnect(d scard.Disposition) error
+	Control(ioctl uint32, in []byte) ([]byte, error)
+	GetAttrib(id scard.Attrib) ([]byte, error)
 }
@@ -0,0 +1,55 @@
+package acr122utest_test
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/kurrik/acr122u"
+	"github.com/kurrik/acr122u/acr122utest"
+)
+
+// debounce drops cards with the UID of the previous card seen within window
+func debounce(clock acr122u.Clock, window time.Duration) acr122u.Middleware {
+	var (
+		last []byte
+		at   time.Time
+	)
+
+	return func(next acr122u.Handler) acr122u.Handler {
+		return acr122u.HandlerFunc(func(c acr122u.Card) {
+			now := clock.Now()
+			if bytes.Equal(c.UID(), last) && now.Sub(at) < window {
+				return
+			}
+			last, at = c.UID(), now
+
+			next.ServeCard(c)
+		})
+	}
+}
+
+func ExampleSimulator() {
+	uid := []byte{0x04, 0xA2, 0x2B, 0x31}
+
+	// A double tap followed by a deliberate second tap
+	s := acr122utest.NewSimulator().
+		Tap(0, 100*time.Millisecond, acr122utest.DefaultReader, uid).
+		Tap(300*time.Millisecond, 100*time.Millisecond, acr122utest.DefaultReader, uid).
+		Tap(2*time.Second, 100*time.Millisecond, acr122utest.DefaultReader, uid)
+
+	err := s.Serve(3*time.Second, acr122u.HandlerFunc(func(acr122u.Card) {}),
+		acr122u.WithMiddleware(debounce(s, time.Second)),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, c := range s.Calls() {
+		fmt.Printf("%v %X\n", c.At, c.UID)
+	}
+	// Output:
+	// 0s 04A22B31
+	// 2s 04A22B31
+}
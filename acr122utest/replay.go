@@ -0,0 +1,340 @@
+package acr122utest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ebfe/scard"
+	"github.com/kurrik/acr122u"
+)
+
+// ErrSessionMismatch is returned when the replayed context is used
+// differently than the recorded one, for example sending another APDU
+var ErrSessionMismatch = errors.New("session mismatch")
+
+// Replay replays a session recorded by acr122u.WithSessionRecorder.
+//
+// The recorded reader states are returned in order, advancing the replayed
+// clock to the time they were recorded. Connecting returns the recorded cards
+// in order, which answer the recorded APDUs. As with the Simulator, the
+// reader states are only returned once the connected cards have been
+// disconnected, so handlers see the time the card was presented. APDUs differing from the
+// recording fail with ErrSessionMismatch. Control and GetAttrib are not
+// recorded and return scard.ErrUnsupportedFeature.
+type Replay struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	readers   []string
+	start     time.Time
+	now       time.Duration
+	stop      func()
+	states    map[string][]acr122u.SessionEvent
+	connects  map[string][]acr122u.SessionEvent
+	cards     map[int][]acr122u.SessionEvent
+	connected int
+	calls     []Call
+}
+
+// ReplaySession reads a session recorded by acr122u.WithSessionRecorder
+func ReplaySession(r io.Reader) (*Replay, error) {
+	p := &Replay{
+		start:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		states:   map[string][]acr122u.SessionEvent{},
+		connects: map[string][]acr122u.SessionEvent{},
+		cards:    map[int][]acr122u.SessionEvent{},
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var e acr122u.SessionEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("session line %d: %w", line, err)
+		}
+
+		switch e.Op {
+		case acr122u.SessionReaders:
+			p.readers = e.Readers
+		case acr122u.SessionStatusChange:
+			key := stateKey(e.States)
+			p.states[key] = append(p.states[key], e)
+		case acr122u.SessionConnect:
+			p.connects[e.Reader] = append(p.connects[e.Reader], e)
+		case acr122u.SessionTransmit, acr122u.SessionCardStatus:
+			p.cards[e.Card] = append(p.cards[e.Card], e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p.readers) == 0 {
+		return nil, fmt.Errorf("no readers in session: %w", ErrSessionMismatch)
+	}
+
+	return p, nil
+}
+
+// stateKey identifies the read loop of reader states by their readers
+func stateKey(states []acr122u.SessionReaderState) string {
+	readers := make([]string, len(states))
+	for i, s := range states {
+		readers[i] = s.Reader
+	}
+
+	return strings.Join(readers, "\n")
+}
+
+// Now returns the replayed time, making Replay an acr122u.Clock
+func (p *Replay) Now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.start.Add(p.now)
+}
+
+// Context creates an acr122u.Context for the replayed readers using the
+// replayed clock
+func (p *Replay) Context(options ...acr122u.Option) (*acr122u.Context, error) {
+	return acr122u.NewContext(p, append([]acr122u.Option{acr122u.WithClock(p)}, options...)...)
+}
+
+// Serve serves the replayed cards using h until all recorded reader states
+// have been replayed, recording the calls reaching h
+func (p *Replay) Serve(h acr122u.Handler, options ...acr122u.Option) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p.mu.Lock()
+	p.stop = cancel
+	p.mu.Unlock()
+
+	options = append([]acr122u.Option{acr122u.WithLogLevel(acr122u.LogError)}, options...)
+
+	actx, err := p.Context(append(options, acr122u.WithMiddleware(p.record))...)
+	if err != nil {
+		return err
+	}
+
+	return actx.Serve(ctx, h)
+}
+
+// Calls returns the recorded handler calls
+func (p *Replay) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Call{}, p.calls...)
+}
+
+// record is the innermost middleware, recording the calls reaching the handler
+func (p *Replay) record(next acr122u.Handler) acr122u.Handler {
+	return acr122u.HandlerFunc(func(c acr122u.Card) {
+		p.mu.Lock()
+		p.calls = append(p.calls, Call{At: p.now, Reader: c.Reader(), UID: c.UID()})
+		p.mu.Unlock()
+
+		next.ServeCard(c)
+	})
+}
+
+// ListReaders returns the recorded readers
+func (p *Replay) ListReaders() ([]string, error) {
+	return append([]string{}, p.readers...), nil
+}
+
+// Release releases the replay
+func (p *Replay) Release() error {
+	return nil
+}
+
+// IsValid reports the replay as valid
+func (p *Replay) IsValid() (bool, error) {
+	return true, nil
+}
+
+// GetStatusChange returns the next recorded reader states of the readers.
+// Once all reader states have been replayed Serve is stopped.
+func (p *Replay) GetStatusChange(rs []scard.ReaderState, timeout time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.connected > 0 {
+		p.cond.Wait()
+	}
+
+	states := make([]acr122u.SessionReaderState, len(rs))
+	for i := range rs {
+		states[i].Reader = rs[i].Reader
+	}
+	key := stateKey(states)
+
+	if len(p.states[key]) == 0 {
+		return p.done(timeout)
+	}
+
+	e := p.states[key][0]
+	p.states[key] = p.states[key][1:]
+	if len(p.states[key]) == 0 {
+		p.cond.Broadcast()
+	}
+
+	if e.At > p.now {
+		p.now = e.At
+	}
+
+	for i := range rs {
+		s := e.States[i]
+		atr, err := hex.DecodeString(s.ATR)
+		if err != nil {
+			return err
+		}
+		rs[i].EventState = scard.StateFlag(s.EventState)
+		rs[i].Atr = atr
+	}
+
+	return e.Err()
+}
+
+// done waits until the reader states of all read loops have been replayed,
+// then stops Serve
+func (p *Replay) done(timeout time.Duration) error {
+	if p.stop == nil {
+		p.mu.Unlock()
+		time.Sleep(timeout)
+		p.mu.Lock()
+		return scard.ErrTimeout
+	}
+
+	for !p.replayed() {
+		p.cond.Wait()
+	}
+	p.stop()
+
+	return scard.ErrTimeout
+}
+
+// replayed reports whether all recorded reader states have been replayed
+func (p *Replay) replayed() bool {
+	for _, states := range p.states {
+		if len(states) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Connect returns the next card recorded as connected to the reader
+func (p *Replay) Connect(reader string, mode scard.ShareMode, proto scard.Protocol) (acr122u.PCSCCard, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.connects[reader]) == 0 {
+		return nil, fmt.Errorf("connect %s: %w", reader, ErrSessionMismatch)
+	}
+
+	e := p.connects[reader][0]
+	p.connects[reader] = p.connects[reader][1:]
+	if err := e.Err(); err != nil {
+		return nil, err
+	}
+
+	p.connected++
+
+	return &replayedCard{p: p, reader: reader, card: e.Card}, nil
+}
+
+// next returns the next recorded event of the card, which must be op
+func (p *Replay) next(card int, op string) (acr122u.SessionEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := p.cards[card]
+	if len(events) == 0 || events[0].Op != op {
+		return acr122u.SessionEvent{}, fmt.Errorf("card %d %s: %w", card, op, ErrSessionMismatch)
+	}
+	p.cards[card] = events[1:]
+
+	return events[0], nil
+}
+
+// replayedCard is a card connected through the replay, answering the
+// recorded APDUs
+type replayedCard struct {
+	p            *Replay
+	reader       string
+	card         int
+	disconnected bool
+}
+
+func (c *replayedCard) Transmit(cmd []byte) ([]byte, error) {
+	e, err := c.p.next(c.card, acr122u.SessionTransmit)
+	if err != nil {
+		return nil, err
+	}
+
+	if recorded, _ := hex.DecodeString(e.Command); !bytes.Equal(cmd, recorded) {
+		return nil, fmt.Errorf("sent %X, recorded %X: %w", cmd, recorded, ErrSessionMismatch)
+	}
+
+	if err := e.Err(); err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(e.Response)
+}
+
+func (c *replayedCard) Status() (*scard.CardStatus, error) {
+	e, err := c.p.next(c.card, acr122u.SessionCardStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.Err(); err != nil {
+		return nil, err
+	}
+
+	atr, err := hex.DecodeString(e.ATR)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scard.CardStatus{
+		Reader:         c.reader,
+		State:          scard.State(e.State),
+		ActiveProtocol: scard.Protocol(e.Protocol),
+		Atr:            atr,
+	}, nil
+}
+
+func (c *replayedCard) Disconnect(scard.Disposition) error {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	if !c.disconnected {
+		c.disconnected = true
+		c.p.connected--
+		c.p.cond.Broadcast()
+	}
+
+	return nil
+}
+
+func (c *replayedCard) Control(uint32, []byte) ([]byte, error) {
+	return nil, scard.ErrUnsupportedFeature
+}
+
+func (c *replayedCard) GetAttrib(scard.Attrib) ([]byte, error) {
+	return nil, scard.ErrUnsupportedFeature
+}
@@ -0,0 +1,76 @@
+package acr122utest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kurrik/acr122u"
+)
+
+func TestReplaySession(t *testing.T) {
+	var (
+		uid     = []byte{0x04, 0xA2, 0x2B, 0x31}
+		session bytes.Buffer
+	)
+
+	s := NewSimulator("entry", "exit").
+		Tap(0, 500*time.Millisecond, "entry", uid).
+		Tap(2*time.Second, 200*time.Millisecond, "exit", uid)
+
+	err := s.Serve(5*time.Second, acr122u.HandlerFunc(func(acr122u.Card) {}),
+		acr122u.WithSessionRecorder(&session),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := ReplaySession(&session)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var atrErrs []error
+	err = p.Serve(acr122u.HandlerFunc(func(c acr122u.Card) {
+		_, err := c.ATR()
+		atrErrs = append(atrErrs, err)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, calls := s.Calls(), p.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	for i := range want {
+		if calls[i].At != want[i].At || calls[i].Reader != want[i].Reader || !bytes.Equal(calls[i].UID, want[i].UID) {
+			t.Fatalf("calls[%d] = %v, want %v", i, calls[i], want[i])
+		}
+	}
+
+	// The handler did not read the status while recording
+	for i, err := range atrErrs {
+		if !errors.Is(err, ErrSessionMismatch) {
+			t.Fatalf("ATR() %d error = %v, want %v", i, err, ErrSessionMismatch)
+		}
+	}
+}
+
+func TestReplaySessionInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		session string
+	}{
+		{"Empty", ""},
+		{"Not JSON", "readers\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReplaySession(bytes.NewBufferString(tc.session)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
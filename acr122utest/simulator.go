@@ -0,0 +1,316 @@
+// Package acr122utest simulates ACR122U readers for testing handlers.
+//
+// A Simulator plays back a script of cards being presented to and removed
+// from readers on a simulated clock, so time based behavior can be tested
+// without real delays.
+package acr122utest
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ebfe/scard"
+	"github.com/kurrik/acr122u"
+)
+
+// DefaultReader is the reader simulated when NewSimulator is called without readers
+const DefaultReader = "Simulated Reader"
+
+// Call is a recorded handler call
+type Call struct {
+	// At is the simulated time since the start of the simulation
+	At time.Duration
+
+	// Reader is the reader the card was presented to
+	Reader string
+
+	// UID is the UID of the card
+	UID []byte
+}
+
+type event struct {
+	at     time.Duration
+	reader string
+	uid    []byte
+}
+
+// Simulator simulates readers and cards on a simulated clock.
+//
+// The simulated clock only advances while the serve loop waits for a status
+// change, after the previously read card has been handled, so handlers see
+// the time the card was presented. Timing is deterministic with the default
+// acr122u.DispatchSingleLoop dispatch model.
+//
+// A card counts as handled once it is disconnected, so connection reuse is
+// disabled: a connection kept open by acr122u.WithConnectionReuse would stop
+// the clock for good.
+type Simulator struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	readers   []string
+	start     time.Time
+	now       time.Duration
+	until     time.Duration
+	stop      func()
+	events    []event
+	present   map[string][]byte
+	connected int
+	calls     []Call
+}
+
+// NewSimulator creates a simulator for the readers
+func NewSimulator(readers ...string) *Simulator {
+	if len(readers) == 0 {
+		readers = []string{DefaultReader}
+	}
+
+	s := &Simulator{
+		readers: readers,
+		start:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		present: map[string][]byte{},
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	return s
+}
+
+// Present presents the card to the reader at the simulated time
+func (s *Simulator) Present(at time.Duration, reader string, uid []byte) *Simulator {
+	return s.add(event{at: at, reader: reader, uid: append([]byte{}, uid...)})
+}
+
+// Remove removes the card from the reader at the simulated time
+func (s *Simulator) Remove(at time.Duration, reader string) *Simulator {
+	return s.add(event{at: at, reader: reader})
+}
+
+// Tap presents the card to the reader at the simulated time and removes it after hold
+func (s *Simulator) Tap(at, hold time.Duration, reader string, uid []byte) *Simulator {
+	return s.Present(at, reader, uid).Remove(at+hold, reader)
+}
+
+func (s *Simulator) add(e event) *Simulator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, e)
+	sort.SliceStable(s.events, func(i, j int) bool {
+		return s.events[i].at < s.events[j].at
+	})
+
+	return s
+}
+
+// Now returns the simulated time, making Simulator an acr122u.Clock
+func (s *Simulator) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.start.Add(s.now)
+}
+
+// Elapsed returns the simulated time since the start of the simulation
+func (s *Simulator) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now
+}
+
+// Context creates an acr122u.Context for the simulated readers using the
+// simulated clock. acr122u.WithConnectionReuse is overridden to disable reuse.
+func (s *Simulator) Context(options ...acr122u.Option) (*acr122u.Context, error) {
+	options = append([]acr122u.Option{acr122u.WithClock(s)}, options...)
+	return acr122u.NewContext(s, append(options, acr122u.WithConnectionReuse(0))...)
+}
+
+// Serve serves the simulated cards using h until the simulated time reaches
+// until, recording the calls reaching h
+func (s *Simulator) Serve(until time.Duration, h acr122u.Handler, options ...acr122u.Option) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.mu.Lock()
+	s.until, s.stop = until, cancel
+	s.mu.Unlock()
+
+	options = append([]acr122u.Option{acr122u.WithLogLevel(acr122u.LogError)}, options...)
+
+	actx, err := s.Context(append(options, acr122u.WithMiddleware(s.record))...)
+	if err != nil {
+		return err
+	}
+
+	return actx.Serve(ctx, h)
+}
+
+// Calls returns the recorded handler calls
+func (s *Simulator) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call{}, s.calls...)
+}
+
+// record is the innermost middleware, recording the calls reaching the handler
+func (s *Simulator) record(next acr122u.Handler) acr122u.Handler {
+	return acr122u.HandlerFunc(func(c acr122u.Card) {
+		s.mu.Lock()
+		s.calls = append(s.calls, Call{At: s.now, Reader: c.Reader(), UID: c.UID()})
+		s.mu.Unlock()
+
+		next.ServeCard(c)
+	})
+}
+
+// ListReaders returns the simulated readers
+func (s *Simulator) ListReaders() ([]string, error) {
+	return append([]string{}, s.readers...), nil
+}
+
+// Release releases the simulator
+func (s *Simulator) Release() error {
+	return nil
+}
+
+// IsValid reports the simulator as valid
+func (s *Simulator) IsValid() (bool, error) {
+	return true, nil
+}
+
+// Connect connects to the card presented to the reader
+func (s *Simulator) Connect(reader string, mode scard.ShareMode, proto scard.Protocol) (acr122u.PCSCCard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uid, ok := s.present[reader]
+	if !ok && mode != scard.ShareDirect {
+		return nil, scard.ErrNoSmartcard
+	}
+
+	s.connected++
+
+	return &simulatedCard{s: s, reader: reader, uid: uid}, nil
+}
+
+// GetStatusChange advances the simulated clock to the next scripted event,
+// or by timeout, once all connected cards have been disconnected
+func (s *Simulator) GetStatusChange(rs []scard.ReaderState, timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.connected > 0 {
+		s.cond.Wait()
+	}
+
+	if s.changed(rs) {
+		return nil
+	}
+
+	next := s.now + timeout
+	if len(s.events) > 0 && s.events[0].at <= next {
+		next = s.events[0].at
+	}
+
+	if s.stop != nil && next >= s.until {
+		s.now = s.until
+		s.stop()
+		return scard.ErrTimeout
+	}
+
+	if next > s.now {
+		s.now = next
+	}
+
+	for len(s.events) > 0 && s.events[0].at <= s.now {
+		if e := s.events[0]; e.uid != nil {
+			s.present[e.reader] = e.uid
+		} else {
+			delete(s.present, e.reader)
+		}
+		s.events = s.events[1:]
+	}
+
+	if s.changed(rs) {
+		return nil
+	}
+
+	return scard.ErrTimeout
+}
+
+// changed updates the event state of the reader states, reporting any change
+func (s *Simulator) changed(rs []scard.ReaderState) bool {
+	var changed bool
+
+	for i := range rs {
+		_, present := s.present[rs[i].Reader]
+
+		switch {
+		case present && rs[i].CurrentState&scard.StatePresent == 0:
+			rs[i].EventState = scard.StatePresent | scard.StateChanged
+			changed = true
+		case !present && rs[i].CurrentState&scard.StatePresent != 0:
+			rs[i].EventState = scard.StateEmpty | scard.StateChanged
+			changed = true
+		default:
+			rs[i].EventState = rs[i].CurrentState
+		}
+	}
+
+	return changed
+}
+
+// simulatedCard is a card connected through the simulator, answering GET DATA (UID)
+type simulatedCard struct {
+	s            *Simulator
+	reader       string
+	uid          []byte
+	disconnected bool
+}
+
+var (
+	cmdGetUID = []byte{0xFF, 0xCA, 0x00, 0x00}
+
+	rcOperationSuccess = []byte{0x90, 0x00}
+	rcOperationFailed  = []byte{0x63, 0x00}
+)
+
+func (c *simulatedCard) Transmit(cmd []byte) ([]byte, error) {
+	if len(cmd) == 5 && bytes.Equal(cmd[:4], cmdGetUID) && c.uid != nil {
+		return append(append([]byte{}, c.uid...), rcOperationSuccess...), nil
+	}
+
+	return append([]byte{}, rcOperationFailed...), nil
+}
+
+func (c *simulatedCard) Status() (*scard.CardStatus, error) {
+	return &scard.CardStatus{
+		Reader:         c.reader,
+		State:          scard.Present,
+		ActiveProtocol: scard.ProtocolT1,
+	}, nil
+}
+
+func (c *simulatedCard) Disconnect(scard.Disposition) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if !c.disconnected {
+		c.disconnected = true
+		c.s.connected--
+		c.s.cond.Broadcast()
+	}
+
+	return nil
+}
+
+func (c *simulatedCard) Control(uint32, []byte) ([]byte, error) {
+	return nil, scard.ErrUnsupportedFeature
+}
+
+func (c *simulatedCard) GetAttrib(scard.Attrib) ([]byte, error) {
+	return nil, scard.ErrUnsupportedFeature
+}
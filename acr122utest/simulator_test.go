@@ -0,0 +1,102 @@
+package acr122utest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kurrik/acr122u"
+)
+
+func TestSimulatorServe(t *testing.T) {
+	var (
+		uidA = []byte{0x0A, 0x00, 0x00, 0x00}
+		uidB = []byte{0x0B, 0x00, 0x00, 0x00}
+		seen []time.Time
+	)
+
+	s := NewSimulator("entry", "exit").
+		Tap(0, 500*time.Millisecond, "entry", uidA).
+		Tap(600*time.Millisecond, 200*time.Millisecond, "exit", uidB).
+		Tap(5*time.Second, time.Second, "entry", uidB)
+
+	err := s.Serve(10*time.Second, acr122u.HandlerFunc(func(c acr122u.Card) {
+		seen = append(seen, s.Now())
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Call{
+		{0, "entry", uidA},
+		{600 * time.Millisecond, "exit", uidB},
+		{5 * time.Second, "entry", uidB},
+	}
+
+	calls := s.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	for i := range want {
+		if calls[i].At != want[i].At || calls[i].Reader != want[i].Reader || !bytes.Equal(calls[i].UID, want[i].UID) {
+			t.Fatalf("calls[%d] = %v, want %v", i, calls[i], want[i])
+		}
+
+		if got := seen[i].Sub(s.start); got != want[i].At {
+			t.Fatalf("handler %d saw %v, want %v", i, got, want[i].At)
+		}
+	}
+
+	if got := s.Elapsed(); got != 10*time.Second {
+		t.Fatalf("s.Elapsed() = %v, want %v", got, 10*time.Second)
+	}
+}
+
+func TestSimulatorConnectionReuse(t *testing.T) {
+	uid := []byte{0x0A, 0x00, 0x00, 0x00}
+
+	s := NewSimulator().
+		Tap(0, time.Second, DefaultReader, uid).
+		Tap(2*time.Second, time.Second, DefaultReader, uid)
+
+	// A pooled connection would never be disconnected, stopping the clock
+	err := s.Serve(5*time.Second, acr122u.HandlerFunc(func(acr122u.Card) {}), acr122u.WithConnectionReuse(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls := s.Calls(); len(calls) != 2 || calls[1].At != 2*time.Second {
+		t.Fatalf("calls = %v, want taps at 0s and 2s", calls)
+	}
+
+	if got := s.Elapsed(); got != 5*time.Second {
+		t.Fatalf("s.Elapsed() = %v, want %v", got, 5*time.Second)
+	}
+}
+
+func TestSimulatorIdleCallback(t *testing.T) {
+	var idles []time.Duration
+
+	s := NewSimulator().Tap(4*time.Second, time.Second, DefaultReader, []byte{0x0A, 0x00, 0x00, 0x00})
+
+	err := s.Serve(10*time.Second, acr122u.HandlerFunc(func(acr122u.Card) {}),
+		acr122u.WithIdleCallback(3*time.Second, func(idle time.Duration) {
+			idles = append(idles, idle)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []time.Duration{3 * time.Second, 3 * time.Second, 6 * time.Second}
+	if len(idles) != len(want) {
+		t.Fatalf("idles = %v, want %v", idles, want)
+	}
+
+	for i := range want {
+		if idles[i] != want[i] {
+			t.Fatalf("idles = %v, want %v", idles, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package acr122u
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// runtimeOptionFields are the Context fields Apply may change. The log
+// options only take effect through the log output, the other fields are
+// read while holding optionsMu.
+var runtimeOptionFields = map[string]bool{
+	"logLevel":           true,
+	"logWriter":          true,
+	"logTimeFormat":      true,
+	"logNoColor":         true,
+	"throttle":           true,
+	"handlerTimeout":     true,
+	"readerErrorHandler": true,
+	"cardErrorHandler":   true,
+}
+
+// runtimeSafe marks the option as one Apply may apply while serving. Apply
+// rejects any option not marked, including options which would only set a
+// field to its zero value.
+func runtimeSafe(option Option) Option {
+	return func(actx *Context) {
+		option(actx)
+		actx.runtimeSafe = true
+	}
+}
+
+// Apply changes options of the context while it may be serving, for example
+// WithLogLevel(LogTrace) to investigate a problem. Only the logging options,
+// WithMinReadInterval, WithHandlerTimeout, WithReaderErrorHandler and
+// WithCardErrorHandler can be applied. Other options, such as WithShareMode
+// or WithDisposition, are rejected with ErrInvalidParameter and no option is
+// applied.
+//
+// WithMinReadInterval starts a new interval for all readers.
+func (actx *Context) Apply(options ...Option) error {
+	actx.optionsMu.Lock()
+	defer actx.optionsMu.Unlock()
+
+	probe := &Context{
+		logLevel:           actx.logLevel,
+		logWriter:          actx.logWriter,
+		logTimeFormat:      actx.logTimeFormat,
+		logNoColor:         actx.logNoColor,
+		throttle:           actx.throttle,
+		handlerTimeout:     actx.handlerTimeout,
+		readerErrorHandler: actx.readerErrorHandler,
+		cardErrorHandler:   actx.cardErrorHandler,
+	}
+	for i, option := range options {
+		probe.runtimeSafe = false
+		if option(probe); !probe.runtimeSafe {
+			return wrapError(fmt.Sprintf("option %d cannot be applied at runtime", i), ErrInvalidParameter)
+		}
+	}
+	probe.runtimeSafe = false
+
+	// Options combining runtime and other options set other fields too
+	if field := structuralOptionField(probe); field != "" {
+		return wrapError(fmt.Sprintf("option setting %s cannot be applied at runtime", field), ErrInvalidParameter)
+	}
+
+	actx.logLevel = probe.logLevel
+	actx.logWriter = probe.logWriter
+	actx.logTimeFormat = probe.logTimeFormat
+	actx.logNoColor = probe.logNoColor
+	actx.throttle = probe.throttle
+	actx.handlerTimeout = probe.handlerTimeout
+	actx.readerErrorHandler = probe.readerErrorHandler
+	actx.cardErrorHandler = probe.cardErrorHandler
+	actx.configureLogOutput()
+
+	return nil
+}
+
+// structuralOptionField returns the name of a field set by options on the
+// probe which Apply cannot change, or ""
+func structuralOptionField(probe *Context) string {
+	v := reflect.ValueOf(probe).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if !runtimeOptionFields[name] && !v.Field(i).IsZero() {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,129 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestContextApplyLogLevel(t *testing.T) {
+	var (
+		buf   syncBuffer
+		polls = make(chan struct{}, 1)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		getStatusChange: func([]scard.ReaderState, time.Duration) error {
+			time.Sleep(time.Millisecond)
+			select {
+			case polls <- struct{}{}:
+			default:
+			}
+			if strings.Contains(buf.String(), "Handled ErrTimeout") {
+				cancel()
+			}
+			return scard.ErrTimeout
+		},
+	}, WithLogWriter(&buf), WithLogLevel(LogInfo))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- actx.ServeFunc(ctx, func(Card) {})
+	}()
+
+	<-polls
+	<-polls
+	if got := buf.String(); strings.Contains(got, "Handled ErrTimeout") {
+		t.Fatalf("trace logged before Apply: %q", got)
+	}
+
+	if err := actx.Apply(WithLogLevel(LogTrace)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("trace not logged after Apply: %q", buf.String())
+	}
+}
+
+func TestContextApply(t *testing.T) {
+	var reported error
+
+	actx, err := newContext(&mockContext{}, WithLogLevel(LogError))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.Apply(WithHandlerTimeout(time.Second), WithMinReadInterval(time.Second), WithReaderErrorHandler(func(reader string, err error) {
+		reported = err
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actx.readerError("Test", ErrOperationFailed)
+	if reported != ErrOperationFailed || actx.handlerTimeout != time.Second || actx.throttle == nil {
+		t.Fatalf("options not applied: %v, %v, %v", reported, actx.handlerTimeout, actx.throttle)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		option Option
+	}{
+		{"Share mode", WithShareMode(ShareExclusive)},
+		{"Middleware", WithMiddleware(func(h Handler) Handler { return h })},
+		{"Zero disposition", WithDisposition(LeaveCard)},
+		{"Zero error limit", WithAutoReestablish(0)},
+		{"Combined", func(actx *Context) {
+			WithHandlerTimeout(time.Minute)(actx)
+			WithShareMode(ShareExclusive)(actx)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := actx.Apply(WithHandlerTimeout(time.Minute), tc.option)
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if actx.handlerTimeout != time.Second {
+				t.Fatalf("actx.handlerTimeout = %v, want unchanged", actx.handlerTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package acr122u
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ebfe/scard"
+)
+
+// Standard reader attribute IDs for ReaderAttribute
+var (
+	AttrVendorName        = uint32(scard.AttrVendorName)
+	AttrVendorIFDType     = uint32(scard.AttrVendorIfdType)
+	AttrVendorIFDVersion  = uint32(scard.AttrVendorIfdVersion)
+	AttrVendorIFDSerialNo = uint32(scard.AttrVendorIfdSerialNo)
+	AttrICCPresence       = uint32(scard.AttrIccPresence)
+	AttrMaxInput          = uint32(scard.AttrMaxinput)
+)
+
+// Default maximum APDU sizes of the ACR122U, used when the reader does not
+// report its buffer size
+const (
+	defaultMaxSend = 261
+	defaultMaxRecv = 261
+)
+
+// ReaderAttribute returns the raw value of the reader attribute over a
+// direct connection, which does not require a card to be present
+func (actx *Context) ReaderAttribute(reader string, attrID uint32) ([]byte, error) {
+	var attr []byte
+
+	err := actx.direct(reader, func(sc PCSCCard) (err error) {
+		attr, err = sc.GetAttrib(scard.Attrib(attrID))
+		return err
+	})
+	if err != nil {
+		return nil, wrapError(fmt.Sprintf("reader attribute %X", attrID), err)
+	}
+
+	return attr, nil
+}
+
+// VendorName returns the vendor name reported by the reader
+func (actx *Context) VendorName(reader string) (string, error) {
+	attr, err := actx.ReaderAttribute(reader, AttrVendorName)
+	if err != nil {
+		return "", err
+	}
+
+	return attributeString(attr), nil
+}
+
+// IFDVersion returns the vendor supplied interface device version of the
+// reader as major.minor.build
+func (actx *Context) IFDVersion(reader string) (string, error) {
+	attr, err := actx.ReaderAttribute(reader, AttrVendorIFDVersion)
+	if err != nil {
+		return "", err
+	}
+
+	if len(attr) != 4 {
+		return "", wrapError(fmt.Sprintf("IFD version %X", attr), ErrShortResponse)
+	}
+
+	v := binary.LittleEndian.Uint32(attr)
+
+	return fmt.Sprintf("%d.%d.%d", v>>24, v>>16&0xFF, v&0xFFFF), nil
+}
+
+// MaxAPDUSizes returns the maximum command and response APDU sizes of the
+// reader, from the maximum input size reported by the reader driver, or the
+// ACR122U defaults of 261 bytes if the driver does not support the attribute
+func (actx *Context) MaxAPDUSizes(reader string) (send, recv int, err error) {
+	attr, err := actx.ReaderAttribute(reader, AttrMaxInput)
+	if errors.Is(err, scard.ErrUnsupportedFeature) {
+		return defaultMaxSend, defaultMaxRecv, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(attr) < 4 {
+		return 0, 0, wrapError(fmt.Sprintf("max input %X", attr), ErrShortResponse)
+	}
+
+	size := int(binary.LittleEndian.Uint32(attr))
+
+	return size, size, nil
+}
+
+// attributeString returns the string attribute up to the first NUL byte
+func attributeString(attr []byte) string {
+	if i := bytes.IndexByte(attr, 0x00); i >= 0 {
+		attr = attr[:i]
+	}
+
+	return string(attr)
+}
@@ -0,0 +1,129 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextVendorName(t *testing.T) {
+	var mode scard.ShareMode
+
+	actx, err := newContext(&mockContext{
+		connect: func(reader string, m scard.ShareMode, p scard.Protocol) (PCSCCard, error) {
+			mode = m
+			return &mockCard{getAttrib: func(id scard.Attrib) ([]byte, error) {
+				if id != scard.AttrVendorName {
+					t.Fatalf("id = %X, want %X", id, scard.AttrVendorName)
+				}
+				return []byte("ACS\x00"), nil
+			}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := actx.VendorName("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "ACS"; got != want {
+		t.Fatalf("VendorName() = %q, want %q", got, want)
+	}
+
+	if mode != scard.ShareDirect {
+		t.Fatalf("mode = %v, want %v", mode, scard.ShareDirect)
+	}
+}
+
+func TestContextIFDVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		attr []byte
+		want string
+		err  error
+	}{
+		{"OK", []byte{0x14, 0x02, 0x07, 0x02}, "2.7.532", nil},
+		{"Short", []byte{0x02}, "", ErrShortResponse},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actx, err := newContext(&mockContext{
+				connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+					return &mockCard{getAttrib: func(scard.Attrib) ([]byte, error) {
+						return tc.attr, nil
+					}}, nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := actx.IFDVersion("Test")
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("IFDVersion() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestContextMaxAPDUSizes(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		attr       []byte
+		attrErr    error
+		send, recv int
+		err        error
+	}{
+		{"Reported", []byte{0x06, 0x01, 0x00, 0x00}, nil, 262, 262, nil},
+		{"Unsupported", nil, scard.ErrUnsupportedFeature, 261, 261, nil},
+		{"Short", []byte{0x06}, nil, 0, 0, ErrShortResponse},
+		{"Failed", nil, scard.ErrReaderUnavailable, 0, 0, scard.ErrReaderUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actx, err := newContext(&mockContext{
+				connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+					return &mockCard{getAttrib: func(id scard.Attrib) ([]byte, error) {
+						if id != scard.AttrMaxinput {
+							t.Fatalf("id = %X, want %X", id, scard.AttrMaxinput)
+						}
+						return tc.attr, tc.attrErr
+					}}, nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			send, recv, err := actx.MaxAPDUSizes("Test")
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if send != tc.send || recv != tc.recv {
+				t.Fatalf("MaxAPDUSizes() = %d, %d, want %d, %d", send, recv, tc.send, tc.recv)
+			}
+		})
+	}
+}
+
+func TestContextReaderAttributeUnsupported(t *testing.T) {
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return &mockCard{}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := actx.ReaderAttribute("Test", AttrICCPresence); !errors.Is(err, scard.ErrUnsupportedFeature) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package acr122u
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ebfe/scard"
+)
+
+// Available reports whether an ACR122U reader is connected, so applications
+// can fall back to a "no reader" mode instead of failing to establish a
+// context. If not, the reason says why. The temporary PC/SC context used to
+// list the readers is released before returning.
+func Available() (bool, string) {
+	pc, err := establishPCSCContext(ScopeSystem)
+	if err != nil {
+		return false, pcscError(err).Error()
+	}
+	defer func() {
+		_ = pc.Release()
+	}()
+
+	readers, err := pc.ListReaders()
+	if errors.Is(err, scard.ErrNoReadersAvailable) || (err == nil && len(readers) == 0) {
+		return false, "no readers connected"
+	}
+	if err != nil {
+		return false, fmt.Sprintf("listing readers: %v", err)
+	}
+
+	for _, r := range readers {
+		if isACR122UReader(r) {
+			return true, ""
+		}
+	}
+
+	return false, fmt.Sprintf("no ACR122U reader among %q", readers)
+}
+
+// isACR122UReader reports if the reader name is an ACR122U
+func isACR122UReader(name string) bool {
+	return strings.Contains(strings.ToUpper(name), "ACR122")
+}
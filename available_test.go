@@ -0,0 +1,58 @@
+package acr122u
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestAvailable(t *testing.T) {
+	defer func(f func(Scope) (PCSCContext, error)) {
+		establishPCSCContext = f
+	}(establishPCSCContext)
+
+	for _, tc := range []struct {
+		name      string
+		establish error
+		readers   []string
+		listErr   error
+		want      bool
+		reason    string
+	}{
+		{"Available", nil, []string{"Generic Reader 00 00", "ACS ACR122U PICC Interface 00 00"}, nil, true, ""},
+		{"No PC/SC service", scard.ErrNoService, nil, nil, false, ErrPCSCUnavailable.Error()},
+		{"No readers", nil, nil, scard.ErrNoReadersAvailable, false, "no readers connected"},
+		{"Empty reader list", nil, []string{}, nil, false, "no readers connected"},
+		{"List error", nil, nil, scard.ErrUnknownError, false, "listing readers"},
+		{"Other readers", nil, []string{"Generic Reader 00 00"}, nil, false, "no ACR122U reader"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var released bool
+
+			establishPCSCContext = func(Scope) (PCSCContext, error) {
+				if tc.establish != nil {
+					return nil, tc.establish
+				}
+				return &mockContext{
+					listReaders: func() ([]string, error) {
+						return tc.readers, tc.listErr
+					},
+					release: func() error {
+						released = true
+						return nil
+					},
+				}, nil
+			}
+
+			got, reason := Available()
+			if got != tc.want || !strings.Contains(reason, tc.reason) || (tc.reason == "") != (reason == "") {
+				t.Fatalf("Available() = %v, %q, want %v, %q", got, reason, tc.want, tc.reason)
+			}
+
+			if tc.establish == nil && !released {
+				t.Fatalf("context not released")
+			}
+		})
+	}
+}
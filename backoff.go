@@ -0,0 +1,126 @@
+package acr122u
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the delay between retries. Implementations used with
+// WithBackoff are shared by the read loops and must be safe for concurrent use.
+type Backoff interface {
+	// Next returns the delay before the retry following the attempt (0 based)
+	Next(attempt int) time.Duration
+
+	// Reset is called once retrying succeeded
+	Reset()
+}
+
+// ConstantBackoff waits the same delay between all retries
+type ConstantBackoff time.Duration
+
+// Next returns the constant delay
+func (b ConstantBackoff) Next(attempt int) time.Duration {
+	return time.Duration(b)
+}
+
+// Reset does nothing, ConstantBackoff is stateless
+func (b ConstantBackoff) Reset() {}
+
+// ExponentialBackoff configures an exponential backoff between retries
+type ExponentialBackoff struct {
+	// Initial is the delay before the first retry, defaults to 500ms
+	Initial time.Duration
+
+	// Max caps the delay between retries, defaults to 30s
+	Max time.Duration
+
+	// Multiplier grows the delay after each retry, defaults to 2
+	Multiplier float64
+}
+
+// BackoffConfig is the former name of ExponentialBackoff.
+//
+// Deprecated: Use ExponentialBackoff.
+type BackoffConfig = ExponentialBackoff
+
+// Backoff defaults
+const (
+	defaultBackoffInitial    = 500 * time.Millisecond
+	defaultBackoffMax        = 30 * time.Second
+	defaultBackoffMultiplier = 2
+	defaultBackoffJitter     = 0.5
+)
+
+// Next returns the delay before the retry following the attempt (0 based)
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	var (
+		d          = b.Initial
+		max        = b.Max
+		multiplier = b.Multiplier
+	)
+
+	if d <= 0 {
+		d = defaultBackoffInitial
+	}
+
+	if max <= 0 {
+		max = defaultBackoffMax
+	}
+
+	if multiplier < 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+
+	for i := 0; i < attempt && d < max; i++ {
+		d = time.Duration(float64(d) * multiplier)
+	}
+
+	if d > max {
+		d = max
+	}
+
+	return d
+}
+
+// Reset does nothing, ExponentialBackoff is stateless
+func (b ExponentialBackoff) Reset() {}
+
+// JitteredBackoff randomizes the delays of a backoff, so clients retrying
+// at the same time spread out
+type JitteredBackoff struct {
+	// Backoff computes the delays to randomize, defaults to ExponentialBackoff{}
+	Backoff Backoff
+
+	// Jitter is the fraction of the delay it is randomly shortened or
+	// lengthened by, from 0 to 1, defaults to 0.5
+	Jitter float64
+}
+
+// Next returns the delay of the backoff randomly shortened or lengthened by up to Jitter
+func (b JitteredBackoff) Next(attempt int) time.Duration {
+	jitter := b.Jitter
+	if jitter <= 0 {
+		jitter = defaultBackoffJitter
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	d := float64(backoffOrDefault(b.Backoff).Next(attempt))
+
+	return time.Duration(d * (1 - jitter + 2*jitter*rand.Float64()))
+}
+
+// Reset resets the randomized backoff
+func (b JitteredBackoff) Reset() {
+	backoffOrDefault(b.Backoff).Reset()
+}
+
+// backoffOrDefault returns b, or the default ExponentialBackoff if b is nil
+func backoffOrDefault(b Backoff) Backoff {
+	if b == nil {
+		return ExponentialBackoff{}
+	}
+
+	return b
+}
@@ -0,0 +1,88 @@
+package acr122u
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffNext(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{
+			"Defaults",
+			ExponentialBackoff{},
+			[]time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			"Capped",
+			ExponentialBackoff{Initial: time.Second, Max: 3 * time.Second, Multiplier: 2},
+			[]time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			"Multiplier 1",
+			ExponentialBackoff{Initial: time.Second, Multiplier: 1},
+			[]time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			"Constant",
+			ConstantBackoff(250 * time.Millisecond),
+			[]time.Duration{250 * time.Millisecond, 250 * time.Millisecond, 250 * time.Millisecond},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for attempt, want := range tc.want {
+				if got := tc.backoff.Next(attempt); got != want {
+					t.Fatalf("Next(%d) = %v, want %v", attempt, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJitteredBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		backoff  JitteredBackoff
+		attempt  int
+		min, max time.Duration
+	}{
+		{"Default jitter", JitteredBackoff{Backoff: ConstantBackoff(time.Second)}, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+		{"Small jitter", JitteredBackoff{Backoff: ConstantBackoff(time.Second), Jitter: 0.1}, 3, 900 * time.Millisecond, 1100 * time.Millisecond},
+		{"Capped jitter", JitteredBackoff{Backoff: ConstantBackoff(time.Second), Jitter: 2}, 0, 0, 2 * time.Second},
+		{"Default backoff", JitteredBackoff{Jitter: 0.5}, 1, 500 * time.Millisecond, 1500 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var distinct = map[time.Duration]bool{}
+
+			for i := 0; i < 100; i++ {
+				got := tc.backoff.Next(tc.attempt)
+				if got < tc.min || got > tc.max {
+					t.Fatalf("Next(%d) = %v, want between %v and %v", tc.attempt, got, tc.min, tc.max)
+				}
+				distinct[got] = true
+			}
+
+			if len(distinct) < 2 {
+				t.Fatalf("Next(%d) returned %d distinct delays, want randomized delays", tc.attempt, len(distinct))
+			}
+		})
+	}
+}
+
+// recordingBackoff records the attempts and resets, waiting a millisecond
+type recordingBackoff struct {
+	attempts []int
+	resets   int
+}
+
+func (b *recordingBackoff) Next(attempt int) time.Duration {
+	b.attempts = append(b.attempts, attempt)
+	return time.Millisecond
+}
+
+func (b *recordingBackoff) Reset() {
+	b.resets++
+}
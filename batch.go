@@ -0,0 +1,37 @@
+package acr122u
+
+import "fmt"
+
+// BatchError is the error of the failing step of TransmitBatch
+type BatchError struct {
+	Step int
+	Err  error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("step %d: %v", e.Step, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// TransmitBatch sends the APDUs in order, returning the response data of
+// each. The batch stops at the first APDU failing or answered with a status
+// other than 0x90 0x00, returning the responses so far and a *BatchError
+// with the index of the failing APDU. On a card connected using
+// ConnectExclusive no other process can interleave commands.
+func (c *card) TransmitBatch(apdus [][]byte) ([][]byte, error) {
+	resps := make([][]byte, 0, len(apdus))
+
+	for i, apdu := range apdus {
+		resp, err := c.transmit(apdu)
+		if err != nil {
+			return resps, &BatchError{Step: i, Err: err}
+		}
+
+		resps = append(resps, resp)
+	}
+
+	return resps, nil
+}
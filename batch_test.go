@@ -0,0 +1,52 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCardTransmitBatch(t *testing.T) {
+	var sent int
+
+	c := transmitCard(func(cmd []byte) ([]byte, error) {
+		sent++
+
+		switch cmd[1] {
+		case 0xCA:
+			return uidTransmit(cmd)
+		case 0xB0:
+			return []byte{0x6A, 0x82}, nil
+		default:
+			return rcOperationSuccess, nil
+		}
+	})
+
+	resps, err := c.TransmitBatch([][]byte{
+		cmdGetUID,
+		{0xFF, 0xB0, 0x00, 0x04, 0x10},
+		{0xFF, 0xD6, 0x00, 0x04, 0x04, 0x01, 0x02, 0x03, 0x04},
+	})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Step != 1 || !errors.Is(err, ErrOperationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resps) != 1 || !bytes.Equal(resps[0], testUID) {
+		t.Fatalf("resps = %X, want [%X]", resps, testUID)
+	}
+
+	if sent != 2 {
+		t.Fatalf("sent = %d, want 2", sent)
+	}
+
+	resps, err = c.TransmitBatch([][]byte{cmdGetUID, {0xFF, 0x00, 0x00, 0x00, 0x00}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resps) != 2 {
+		t.Fatalf("len(resps) = %d, want 2", len(resps))
+	}
+}
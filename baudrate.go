@@ -0,0 +1,58 @@
+package acr122u
+
+import "fmt"
+
+// BaudRate is a contactless bit rate between the reader and an ISO14443-4 card
+type BaudRate int
+
+// Baud rates
+const (
+	BaudRate106 BaudRate = iota
+	BaudRate212
+	BaudRate424
+	BaudRate848
+)
+
+func (b BaudRate) String() string {
+	switch b {
+	case BaudRate106:
+		return "106 kbps"
+	case BaudRate212:
+		return "212 kbps"
+	case BaudRate424:
+		return "424 kbps"
+	case BaudRate848:
+		return "848 kbps"
+	default:
+		return fmt.Sprintf("BaudRate(%d)", int(b))
+	}
+}
+
+// negotiateBaudRate requests the bit rate from an ISO14443-4 card using the
+// PN532 InPSL command, which sends the PPS request. Other cards and cards
+// rejecting the request stay at 106 kbps.
+func (c *card) negotiateBaudRate(rate BaudRate) error {
+	if rate == BaudRate106 {
+		return nil
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return err
+	}
+
+	if t != CardTypeISODEP {
+		return nil
+	}
+
+	resp, err := c.pn532(0x4E, 0x01, byte(rate), byte(rate))
+	if err != nil {
+		return err
+	}
+
+	if len(resp) != 1 || resp[0]&0x3F != 0x00 {
+		return wrapError(fmt.Sprintf("PPS %v status %X", rate, resp), ErrOperationFailed)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestCardNegotiateBaudRate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		atr  []byte
+		rate BaudRate
+		resp []byte
+		sent []byte
+		err  error
+	}{
+		{
+			name: "424 kbps",
+			atr:  atrISODEP,
+			rate: BaudRate424,
+			resp: []byte{0xD5, 0x4F, 0x00, 0x90, 0x00},
+			sent: []byte{0xFF, 0x00, 0x00, 0x00, 0x05, 0xD4, 0x4E, 0x01, 0x02, 0x02},
+		},
+		{
+			name: "Rejected",
+			atr:  atrISODEP,
+			rate: BaudRate848,
+			resp: []byte{0xD5, 0x4F, 0x01, 0x90, 0x00},
+			sent: []byte{0xFF, 0x00, 0x00, 0x00, 0x05, 0xD4, 0x4E, 0x01, 0x03, 0x03},
+			err:  ErrOperationFailed,
+		},
+		{
+			name: "Default rate",
+			atr:  atrISODEP,
+			rate: BaudRate106,
+		},
+		{
+			name: "Not ISO-DEP",
+			atr:  atrMifareClassic1K,
+			rate: BaudRate424,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var sent []byte
+
+			c := newCard("Test", &mockCard{
+				transmit: func(cmd []byte) ([]byte, error) {
+					sent = cmd
+					return tc.resp, nil
+				},
+				status: atrStatus(tc.atr),
+			})
+
+			if err := c.negotiateBaudRate(tc.rate); !errors.Is(err, tc.err) {
+				t.Fatalf("got = %v, want %v", err, tc.err)
+			}
+
+			if !bytes.Equal(sent, tc.sent) {
+				t.Fatalf("sent = %X, want %X", sent, tc.sent)
+			}
+		})
+	}
+}
+
+func TestContextMaxBaudRate(t *testing.T) {
+	t.Run("Fallback", func(t *testing.T) {
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				return &mockCard{
+					transmit: func(cmd []byte) ([]byte, error) {
+						if cmd[1] == 0x00 {
+							return rcOperationFailed, nil
+						}
+						return uidTransmit(cmd)
+					},
+					status: atrStatus(atrISODEP),
+				}, nil
+			},
+		}, WithMaxBaudRate(BaudRate424))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(c.UID(), testUID) {
+			t.Fatalf("c.UID() = %X, want %X", c.UID(), testUID)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := newContext(&mockContext{}, WithMaxBaudRate(BaudRate(4))); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
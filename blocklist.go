@@ -0,0 +1,20 @@
+package acr122u
+
+// uidSet is a set of UIDs with constant time lookup, for large lists.
+// A nil uidSet is valid and contains nothing.
+type uidSet map[string]struct{}
+
+func newUIDSet(uids [][]byte) uidSet {
+	s := make(uidSet, len(uids))
+	for _, uid := range uids {
+		s[string(uid)] = struct{}{}
+	}
+
+	return s
+}
+
+// contains reports whether the UID is in the set
+func (s uidSet) contains(uid []byte) bool {
+	_, ok := s[string(uid)]
+	return ok
+}
@@ -0,0 +1,107 @@
+package acr122u
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// readCache caches the identity computed for the most recent card per reader.
+//
+// Entries are keyed by the UID read over the live connection, so a card
+// swapped for another of the same type is never mistaken for the cached one.
+// Since there is at most one entry per reader the cache is bounded by the
+// reader count.
+type readCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]readCacheEntry
+}
+
+type readCacheEntry struct {
+	uid      []byte
+	identity string
+	expires  time.Time
+}
+
+func newReadCache(ttl time.Duration) *readCache {
+	return &readCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]readCacheEntry{},
+	}
+}
+
+// get returns the cached identity for the card on the reader if it is still valid
+func (rc *readCache) get(reader string, uid []byte) (string, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	e, ok := rc.entries[reader]
+	if !ok {
+		return "", false
+	}
+
+	if !bytes.Equal(e.uid, uid) || !rc.now().Before(e.expires) {
+		delete(rc.entries, reader)
+		return "", false
+	}
+
+	return e.identity, true
+}
+
+// put caches the identity of the card read from the reader
+func (rc *readCache) put(reader string, uid []byte, identity string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries[reader] = readCacheEntry{
+		uid:      uid,
+		identity: identity,
+		expires:  rc.now().Add(rc.ttl),
+	}
+}
+
+// clear removes the cached card for the reader
+func (rc *readCache) clear(reader string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	delete(rc.entries, reader)
+}
+
+// uidLengthCache remembers the Le that returned a UID per reader.
+// A nil *uidLengthCache is valid and remembers nothing.
+type uidLengthCache struct {
+	mu      sync.Mutex
+	lengths map[string]byte
+}
+
+func newUIDLengthCache() *uidLengthCache {
+	return &uidLengthCache{lengths: map[string]byte{}}
+}
+
+func (uc *uidLengthCache) get(reader string) (byte, bool) {
+	if uc == nil {
+		return 0, false
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	le, ok := uc.lengths[reader]
+
+	return le, ok
+}
+
+func (uc *uidLengthCache) put(reader string, le byte) {
+	if uc == nil {
+		return
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.lengths[reader] = le
+}
@@ -0,0 +1,53 @@
+package acr122u
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadCache(t *testing.T) {
+	now := time.Now()
+
+	rc := newReadCache(time.Second)
+	rc.now = func() time.Time { return now }
+
+	rc.put("Test", testUID, "identity")
+
+	t.Run("Hit", func(t *testing.T) {
+		if got, ok := rc.get("Test", testUID); !ok || got != "identity" {
+			t.Fatalf("rc.get() = %q, %v, want %q, true", got, ok, "identity")
+		}
+	})
+
+	t.Run("Other reader", func(t *testing.T) {
+		if _, ok := rc.get("Other", testUID); ok {
+			t.Fatalf("unexpected cache hit")
+		}
+	})
+
+	t.Run("Changed UID", func(t *testing.T) {
+		rc.put("Test", testUID, "identity")
+
+		if _, ok := rc.get("Test", []byte{0x01, 0x02, 0x03, 0x04}); ok {
+			t.Fatalf("unexpected cache hit")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		rc.put("Test", testUID, "identity")
+		now = now.Add(time.Second)
+
+		if _, ok := rc.get("Test", testUID); ok {
+			t.Fatalf("unexpected cache hit")
+		}
+	})
+
+	t.Run("Cleared", func(t *testing.T) {
+		rc.put("Test", testUID, "identity")
+		rc.clear("Test")
+
+		if _, ok := rc.get("Test", testUID); ok {
+			t.Fatalf("unexpected cache hit")
+		}
+	})
+}
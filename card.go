@@ -1,6 +1,14 @@
 package acr122u
 
-import "bytes"
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ebfe/scard"
+)
 
 // Card represents a ACR122U card
 type Card interface {
@@ -10,18 +18,197 @@ type Card interface {
 	// Status returns the card status
 	Status() (Status, error)
 
+	// ATR returns the answer to reset of the card
+	ATR() ([]byte, error)
+
+	// ActiveProtocol returns the protocol negotiated with the card
+	ActiveProtocol() (Protocol, error)
+
 	// UID returns the UID for the card
 	UID() []byte
+
+	// UIDIsRandom reports whether the UID is a random ID (RID) that changes
+	// on every tap, which must not be used to identify the card
+	UIDIsRandom() bool
+
+	// Refresh reads the UID again from the live connection, returning
+	// ErrCardChanged if the card was swapped since it was read
+	Refresh() error
+
+	// ReadDuration returns the time it took to connect and read the UID
+	ReadDuration() time.Duration
+
+	// ReadTime returns when the card was read
+	ReadTime() time.Time
+
+	// WithValue attaches the value to the card for the current read, so
+	// middleware can pass data to the handlers it wraps, and returns the card
+	WithValue(key, val any) Card
+
+	// Value returns the value attached to the card for key, or nil
+	Value(key any) any
+
+	// Removed reports whether a transmit failed because the card was
+	// removed or reset, after which transmits return ErrCardRemoved
+	Removed() bool
+
+	// LastExchange returns the last APDU sent to the card and the response
+	// received, which is nil if the transmit failed
+	LastExchange() (sent, received []byte)
+
+	// HaltAndReactivate halts the card and activates it again, starting a
+	// new session without removing the card
+	HaltAndReactivate() error
+
+	// Type returns the card type reported by the reader
+	Type() (CardType, error)
+
+	// Authenticate authenticates the MIFARE Classic sector containing the block
+	Authenticate(block byte, key [6]byte, keyType KeyType) error
+
+	// ReadBlock reads a 16 byte MIFARE Classic block
+	ReadBlock(block byte) ([]byte, error)
+
+	// WriteBlock writes a 16 byte MIFARE Classic block
+	WriteBlock(block byte, data []byte) error
+
+	// MIFAREPlusSecurityLevel returns the security level of a MIFARE Plus card
+	MIFAREPlusSecurityLevel() (int, error)
+
+	// ReadAccessBits reads and decodes the access conditions of a MIFARE Classic sector
+	ReadAccessBits(sector byte, key [6]byte, keyType KeyType) (*AccessConditions, error)
+
+	// StoreData stores data across the data blocks of a MIFARE Classic card
+	StoreData(data []byte, key [6]byte, keyType KeyType) error
+
+	// LoadData loads data stored using StoreData
+	LoadData(key [6]byte, keyType KeyType) ([]byte, error)
+
+	// LoadDataWithProgress loads data like LoadData, reporting the blocks read
+	LoadDataWithProgress(fn func(done, total int), key [6]byte, keyType KeyType) ([]byte, error)
+
+	// ReadPage reads a 4 byte MIFARE Ultralight/NTAG page
+	ReadPage(page byte) ([]byte, error)
+
+	// WritePage writes a 4 byte MIFARE Ultralight/NTAG page
+	WritePage(page byte, data []byte) error
+
+	// LockPages irreversibly locks MIFARE Ultralight/NTAG pages 4-15 using the static lock bits
+	LockPages(from, to byte, confirm bool) error
+
+	// SetCCLocked irreversibly locks the MIFARE Ultralight/NTAG capability container
+	SetCCLocked(confirm bool) error
+
+	// ReadConfig reads the configuration of an NTAG21x tag
+	ReadConfig() (*NTAGConfig, error)
+
+	// SetMirror configures the NTAG21x ASCII mirror of the UID and NFC counter
+	SetMirror(cfg MirrorConfig) error
+
+	// ReadSignature reads the 32 byte NTAG21x originality signature
+	ReadSignature() ([]byte, error)
+
+	// VerifyOriginality verifies the NTAG21x originality signature against
+	// the NXP public key, returning false for cloned tags
+	VerifyOriginality() (bool, error)
+
+	// Fingerprint returns a hash of the UID, ATR, SAK and, for NTAG21x, the
+	// originality signature. It is not stable for cards with a random UID.
+	Fingerprint() (string, error)
+
+	// WriteCredential writes the credential to a MIFARE Classic block or
+	// four MIFARE Ultralight/NTAG pages
+	WriteCredential(cred Credential, at byte, key [6]byte, keyType KeyType) error
+
+	// ReadCredential reads a credential written by WriteCredential
+	ReadCredential(at byte, key [6]byte, keyType KeyType) (Credential, error)
+
+	// ReadNDEF reads the NDEF message of an NFC Forum Type 2 or Type 4 tag
+	ReadNDEF() ([]*NDEFRecord, error)
+
+	// ReadNDEFWithProgress reads the NDEF message like ReadNDEF, reporting the bytes read
+	ReadNDEFWithProgress(fn func(done, total int)) ([]*NDEFRecord, error)
+
+	// WriteNDEF writes the NDEF message to an NFC Forum Type 2 or Type 4 tag
+	WriteNDEF(records []*NDEFRecord) error
+
+	// UpdateNDEFRecord replaces a record of the NDEF message, writing only
+	// the changed pages or bytes if the message keeps its length
+	UpdateNDEFRecord(index int, rec *NDEFRecord) error
+
+	// ReadFile reads the ISO7816 elementary file selected by file ID
+	ReadFile(fid [2]byte) ([]byte, error)
+
+	// TransmitBatch sends the APDUs in order, stopping at the first failure
+	TransmitBatch(apdus [][]byte) ([][]byte, error)
+
+	// TransmitTemplate assembles an APDU from a hex template with {name}
+	// placeholders and transmits it
+	TransmitTemplate(template string, vars map[string]byte) ([]byte, error)
+
+	// TransmitDESFire sends a native DESFire command, following additional frames
+	TransmitDESFire(cmd byte, data []byte) ([]byte, error)
+
+	// DESFireListApplications returns the DESFire application IDs
+	DESFireListApplications() ([][3]byte, error)
+
+	// DESFireListFiles returns the file IDs of the selected DESFire application
+	DESFireListFiles() ([]byte, error)
+
+	// Erase irreversibly wipes the user data of a MIFARE Classic or
+	// Ultralight/NTAG card, leaving keys and configuration untouched
+	Erase(key [6]byte, keyType KeyType, confirm bool) error
+
+	// Dump reads everything that can safely be read from the card for diagnostics
+	Dump() (*CardReport, error)
+
+	// DumpRaw reads the entire memory of a MIFARE Classic or NTAG21x card in
+	// the usual dump file layout, authenticating MIFARE sectors with the keys
+	DumpRaw(keys ...[6]byte) ([]byte, error)
+
+	// DumpRawWithProgress dumps the card like DumpRaw, reporting the blocks
+	// or pages read so far to fn
+	DumpRawWithProgress(fn func(done, total int), keys ...[6]byte) ([]byte, error)
+
+	// RestoreRaw writes the data of a DumpRaw dump back to the card
+	RestoreRaw(data []byte, keys ...[6]byte) error
+
+	// Disconnect disconnects from the card. Cards passed to a Handler are
+	// disconnected after the handler returns.
+	Disconnect() error
+
+	// Verify submits a PIN using the ISO7816 VERIFY command.
+	// The remaining tries are returned along with ErrWrongPIN,
+	// or -1 if the card did not report them.
+	Verify(p2 byte, pin []byte) (remainingTries int, err error)
 }
 
 type card struct {
-	uid    []byte
-	reader string
-	scard  scardCard
+	uid           []byte
+	reader        string
+	scard         PCSCCard
+	disconnected  bool
+	removed       bool
+	authenticated bool
+	authSector    int
+	readDuration  time.Duration
+	readTime      time.Time
+	uidLengths    *uidLengthCache
+	disposition   Disposition
+	uidCommand    []byte
+	values        map[any]any
+	readOnly      bool
+	verifyWrites  bool
+	pool          *connPool
+	uidRetries    int
+	lastSent      []byte
+	lastReceived  []byte
+	identity      string
+	removals      int
 }
 
-func newCard(reader string, sc scardCard) *card {
-	return &card{reader: reader, scard: sc}
+func newCard(reader string, sc PCSCCard) *card {
+	return &card{reader: reader, scard: sc, disposition: ResetCard}
 }
 
 func (c *card) Reader() string {
@@ -37,29 +224,244 @@ func (c *card) Status() (Status, error) {
 	return newStatus(scs)
 }
 
+func (c *card) ATR() ([]byte, error) {
+	s, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Atr, nil
+}
+
+func (c *card) ActiveProtocol() (Protocol, error) {
+	s, err := c.Status()
+	if err != nil {
+		return ProtocolUndefined, err
+	}
+
+	return Protocol(s.ActiveProtocol), nil
+}
+
 func (c *card) UID() []byte {
 	return c.uid
 }
 
-// transmit raw command to underlying scardCard
+// UIDIsRandom reports whether the UID is a random ID, a single size UID
+// starting with 0x08 (ISO14443-3)
+func (c *card) UIDIsRandom() bool {
+	return len(c.uid) == 4 && c.uid[0] == 0x08
+}
+
+// Refresh reads the UID again, updating the UID and returning ErrCardChanged
+// if it differs. The ATR and type are always read from the live connection.
+func (c *card) Refresh() error {
+	uid, err := c.getUID()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(uid, c.uid) {
+		prev := c.uid
+		c.uid = uid
+		return wrapError(fmt.Sprintf("UID %X, read as %X", uid, prev), ErrCardChanged)
+	}
+
+	return nil
+}
+
+func (c *card) ReadDuration() time.Duration {
+	return c.readDuration
+}
+
+func (c *card) ReadTime() time.Time {
+	return c.readTime
+}
+
+func (c *card) WithValue(key, val any) Card {
+	if c.values == nil {
+		c.values = map[any]any{}
+	}
+	c.values[key] = val
+
+	return c
+}
+
+func (c *card) resetValues() {
+	c.values = nil
+}
+
+func (c *card) Value(key any) any {
+	return c.values[key]
+}
+
+func (c *card) Removed() bool {
+	return c.removed
+}
+
+func (c *card) LastExchange() ([]byte, []byte) {
+	return c.lastSent, c.lastReceived
+}
+
+func (c *card) Type() (CardType, error) {
+	s, err := c.Status()
+	if err != nil {
+		return CardTypeUnknown, err
+	}
+
+	return cardTypeFromATR(s.Atr), nil
+}
+
+func (c *card) Verify(p2 byte, pin []byte) (int, error) {
+	if len(pin) == 0 || len(pin) > 0xFF {
+		return -1, wrapError("pin length", ErrInvalidParameter)
+	}
+
+	cmd := append([]byte{0x00, 0x20, 0x00, p2, byte(len(pin))}, pin...)
+
+	_, sw, err := c.transmitSW(cmd)
+	if err != nil {
+		return -1, err
+	}
+
+	switch {
+	case sw == swSuccess:
+		return -1, nil
+	case sw == swOperationFailed:
+		return -1, ErrWrongPIN
+	case sw&0xFFF0 == 0x63C0:
+		return int(sw & 0x0F), ErrWrongPIN
+	case sw == 0x6983:
+		return 0, ErrPINBlocked
+	default:
+		return -1, wrapError("verify "+statusText(sw), ErrOperationFailed)
+	}
+}
+
+func (c *card) Disconnect() error {
+	return c.disconnect()
+}
+
+// disconnect from the card, subsequent calls are no-ops. With connection
+// reuse the connection is kept open for the next read of the reader.
+func (c *card) disconnect() error {
+	if c.disconnected {
+		return nil
+	}
+
+	c.disconnected = true
+
+	if c.pool != nil && !c.removed {
+		c.pool.put(c.reader, c.scard, c.disposition)
+		return nil
+	}
+
+	return c.scard.Disconnect(scard.Disposition(c.disposition))
+}
+
+// transmit raw command to underlying PCSCCard, returning the response data.
+// Status words other than 0x90 0x00 are returned as errors wrapping ErrOperationFailed.
 func (c *card) transmit(cmd []byte) ([]byte, error) {
-	resp, err := c.scard.Transmit(cmd)
+	data, sw, err := c.transmitSW(cmd)
 	if err != nil {
 		return nil, err
 	}
 
-	if bytes.Equal(resp, rcOperationFailed) {
+	switch sw {
+	case swSuccess:
+		return data, nil
+	case swOperationFailed:
 		return nil, ErrOperationFailed
+	default:
+		return nil, wrapError(statusText(sw), ErrOperationFailed)
+	}
+}
+
+// transmitSW transmits raw command to underlying PCSCCard, returning the
+// response data and status word
+func (c *card) transmitSW(cmd []byte) ([]byte, uint16, error) {
+	if c.removed {
+		return nil, 0, ErrCardRemoved
 	}
 
-	if bytes.HasSuffix(resp, rcOperationSuccess) {
-		return bytes.TrimSuffix(resp, rcOperationSuccess), nil
+	resp, err := c.scard.Transmit(cmd)
+	c.lastSent, c.lastReceived = append([]byte{}, cmd...), append([]byte(nil), resp...)
+	if err != nil {
+		if errors.Is(err, scard.ErrRemovedCard) || errors.Is(err, scard.ErrResetCard) || errors.Is(err, scard.ErrNoSmartcard) {
+			c.removed = true
+		}
+		return nil, 0, err
 	}
 
-	return resp, nil
+	return splitResponse(resp)
 }
 
-// getUID returns the UID for the card
+// splitResponse splits a response APDU into its data and status word (SW1 SW2)
+func splitResponse(resp []byte) ([]byte, uint16, error) {
+	if len(resp) < 2 {
+		return nil, 0, wrapError(fmt.Sprintf("response %X", resp), ErrShortResponse)
+	}
+
+	n := len(resp) - 2
+
+	return resp[:n], binary.BigEndian.Uint16(resp[n:]), nil
+}
+
+// getUID returns the UID for the card using the custom UID command if set,
+// otherwise cmdGetUID (FF CA 00 00 00).
+//
+// Some reader firmwares return no UID for Le=0, in which case the explicit
+// uidLengths are tried, honoring a 0x6C <length> response. The Le that
+// returned the UID is remembered per reader and tried first on later reads.
 func (c *card) getUID() ([]byte, error) {
-	return c.transmit(cmdGetUID)
+	if c.uidCommand != nil {
+		data, sw, err := c.transmitUID(c.uidCommand)
+		if err != nil {
+			return nil, err
+		}
+		if sw != swSuccess {
+			return nil, wrapError(statusText(sw), ErrOperationFailed)
+		}
+		return data, nil
+	}
+
+	var hinted bool
+
+	les := append([]byte{cmdGetUID[4]}, uidLengths...)
+	if le, ok := c.uidLengths.get(c.reader); ok {
+		les = append([]byte{le}, les...)
+	}
+
+	for i := 0; i < len(les); i++ {
+		cmd := append(append([]byte{}, cmdGetUID[:4]...), les[i])
+
+		data, sw, err := c.transmitUID(cmd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case sw == swSuccess && len(data) >= 4:
+			c.uidLengths.put(c.reader, les[i])
+			return data, nil
+		case sw&0xFF00 == 0x6C00 && !hinted:
+			hinted = true
+			les = append(les[:i+1], append([]byte{byte(sw)}, les[i+1:]...)...)
+		}
+	}
+
+	return nil, wrapError("no UID in response", ErrOperationFailed)
+}
+
+// transmitUID transmits the UID command, retrying up to uidRetries times
+// when the card answers 0x63 0x00, as some NTAG216 tags do on the first
+// read after entering the field
+func (c *card) transmitUID(cmd []byte) ([]byte, uint16, error) {
+	for retry := 0; ; retry++ {
+		data, sw, err := c.transmitSW(cmd)
+		if err != nil || sw != swOperationFailed || retry >= c.uidRetries {
+			return data, sw, err
+		}
+
+		time.Sleep(uidRetryDelay)
+	}
 }
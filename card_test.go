@@ -2,6 +2,7 @@ package acr122u
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/ebfe/scard"
@@ -52,6 +53,46 @@ func TestCardStatus(t *testing.T) {
 	})
 }
 
+func TestCardATRActiveProtocol(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := statusCard(func() (*scard.CardStatus, error) {
+			return &scard.CardStatus{Atr: atrMifareClassic1K, ActiveProtocol: scard.ProtocolT1}, nil
+		})
+
+		atr, err := c.ATR()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(atr, atrMifareClassic1K) {
+			t.Fatalf("c.ATR() = %X, want %X", atr, atrMifareClassic1K)
+		}
+
+		p, err := c.ActiveProtocol()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if p != ProtocolT1 {
+			t.Fatalf("c.ActiveProtocol() = %v, want %v", p, ProtocolT1)
+		}
+	})
+
+	t.Run("Error from Status", func(t *testing.T) {
+		c := statusCard(func() (*scard.CardStatus, error) {
+			return nil, scard.ErrRemovedCard
+		})
+
+		if _, err := c.ATR(); err != scard.ErrRemovedCard {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := c.ActiveProtocol(); err != scard.ErrRemovedCard {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
 func TestCardUID(t *testing.T) {
 	c := &card{uid: testUID}
 
@@ -60,30 +101,400 @@ func TestCardUID(t *testing.T) {
 	}
 }
 
+func TestCardRefresh(t *testing.T) {
+	swapped := []byte{0x04, 0x11, 0x22, 0x33}
+
+	t.Run("Same card", func(t *testing.T) {
+		c := transmitCard(uidTransmit)
+		c.uid = testUID
+
+		if err := c.Refresh(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Swapped card", func(t *testing.T) {
+		c := transmitCard(func([]byte) ([]byte, error) {
+			return append(append([]byte{}, swapped...), rcOperationSuccess...), nil
+		})
+		c.uid = testUID
+
+		if err := c.Refresh(); !errors.Is(err, ErrCardChanged) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := c.UID(); !bytes.Equal(got, swapped) {
+			t.Fatalf("c.UID() = %X, want %X", got, swapped)
+		}
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		c := transmitCard(func([]byte) ([]byte, error) {
+			return nil, scard.ErrRemovedCard
+		})
+		c.uid = testUID
+
+		if err := c.Refresh(); !errors.Is(err, scard.ErrRemovedCard) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCardUIDIsRandom(t *testing.T) {
+	for _, tc := range []struct {
+		uid  []byte
+		want bool
+	}{
+		{[]byte{0x08, 0x12, 0x34, 0x56}, true},
+		{[]byte{0x04, 0x12, 0x34, 0x56}, false},
+		{[]byte{0x88, 0x12, 0x34, 0x56}, false},
+		{[]byte{0x08, 0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC}, false},
+		{nil, false},
+	} {
+		if got := (&card{uid: tc.uid}).UIDIsRandom(); got != tc.want {
+			t.Fatalf("UIDIsRandom(%X) = %v, want %v", tc.uid, got, tc.want)
+		}
+	}
+}
+
 func TestCardGetUID(t *testing.T) {
-	c := transmitCard(func(cmd []byte) ([]byte, error) {
-		if !bytes.Equal(cmd, cmdGetUID) {
-			t.Fatalf("cmd = %v, want %v", cmd, cmdGetUID)
+	t.Run("Le=0", func(t *testing.T) {
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			if !bytes.Equal(cmd, cmdGetUID) {
+				t.Fatalf("cmd = %v, want %v", cmd, cmdGetUID)
+			}
+
+			return uidTransmit(cmd)
+		})
+
+		got, err := c.getUID()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(got, testUID) {
+			t.Fatalf("%#v != %#v", got, testUID)
+		}
+	})
+
+	t.Run("Le=0 empty then retry", func(t *testing.T) {
+		var (
+			les  []byte
+			uid7 = []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+		)
+
+		lengths := newUIDLengthCache()
+
+		newUIDCard := func() *card {
+			c := transmitCard(func(cmd []byte) ([]byte, error) {
+				les = append(les, cmd[4])
+
+				switch cmd[4] {
+				case 0x00:
+					return rcOperationSuccess, nil
+				case 0x07:
+					return append(append([]byte{}, uid7...), rcOperationSuccess...), nil
+				default:
+					return []byte{0x6C, 0x07}, nil
+				}
+			})
+			c.uidLengths = lengths
+
+			return c
+		}
+
+		got, err := newUIDCard().getUID()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(got, uid7) {
+			t.Fatalf("%#v != %#v", got, uid7)
+		}
+
+		if want := []byte{0x00, 0x04, 0x07}; !bytes.Equal(les, want) {
+			t.Fatalf("les = %X, want %X", les, want)
+		}
+
+		les = nil
+
+		if _, err := newUIDCard().getUID(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := []byte{0x07}; !bytes.Equal(les, want) {
+			t.Fatalf("les = %X, want %X", les, want)
+		}
+	})
+
+	t.Run("Operation failed then retry", func(t *testing.T) {
+		var calls int
+
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			if calls++; calls == 1 {
+				return rcOperationFailed, nil
+			}
+			return uidTransmit(cmd)
+		})
+		c.uidRetries = 1
+
+		got, err := c.getUID()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(got, testUID) {
+			t.Fatalf("%#v != %#v", got, testUID)
+		}
+
+		if calls != 2 {
+			t.Fatalf("calls = %d, want 2", calls)
+		}
+	})
+
+	t.Run("Operation failed without retries", func(t *testing.T) {
+		var cmds [][]byte
+
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			if cmds = append(cmds, cmd); len(cmds) == 1 {
+				return rcOperationFailed, nil
+			}
+			return uidTransmit(cmd)
+		})
+
+		if _, err := c.getUID(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
-		return testUID, nil
+		if got, want := cmds[1][4], uidLengths[0]; got != want {
+			t.Fatalf("Le = %X, want %X", got, want)
+		}
+	})
+
+	t.Run("No UID", func(t *testing.T) {
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			return rcOperationFailed, nil
+		})
+
+		if _, err := c.getUID(); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Transmit error", func(t *testing.T) {
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			return nil, scard.ErrRemovedCard
+		})
+
+		if _, err := c.getUID(); !errors.Is(err, scard.ErrRemovedCard) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Short response", func(t *testing.T) {
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			return []byte{0x90}, nil
+		})
+
+		if _, err := c.getUID(); !errors.Is(err, ErrShortResponse) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSplitResponse(t *testing.T) {
+	for _, tc := range []struct {
+		resp []byte
+		data []byte
+		sw   uint16
+		err  error
+	}{
+		{nil, nil, 0, ErrShortResponse},
+		{[]byte{0x90}, nil, 0, ErrShortResponse},
+		{[]byte{0x90, 0x00}, []byte{}, 0x9000, nil},
+		{[]byte{0x01, 0x02, 0x63, 0x00}, []byte{0x01, 0x02}, 0x6300, nil},
+	} {
+		data, sw, err := splitResponse(tc.resp)
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Fatalf("splitResponse(%X) unexpected error: %v", tc.resp, err)
+		}
+
+		if !bytes.Equal(data, tc.data) || sw != tc.sw {
+			t.Fatalf("splitResponse(%X) = %X, %04X, want %X, %04X", tc.resp, data, sw, tc.data, tc.sw)
+		}
+	}
+}
+
+func TestCardTransmit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		resp []byte
+		data []byte
+		err  error
+	}{
+		{"Empty", []byte{}, nil, ErrShortResponse},
+		{"One byte", []byte{0x90}, nil, ErrShortResponse},
+		{"Success", []byte{0x01, 0x90, 0x00}, []byte{0x01}, nil},
+		{"Failed", []byte{0x63, 0x00}, nil, ErrOperationFailed},
+		{"Other status", []byte{0x6A, 0x82}, nil, ErrOperationFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := transmitCard(func([]byte) ([]byte, error) {
+				return tc.resp, nil
+			})
+
+			data, err := c.transmit([]byte{0x00})
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(data, tc.data) {
+				t.Fatalf("data = %X, want %X", data, tc.data)
+			}
+		})
+	}
+}
+
+func TestCardRemoved(t *testing.T) {
+	var calls int
+
+	c := transmitCard(func([]byte) ([]byte, error) {
+		if calls++; calls > 1 {
+			return nil, scard.ErrRemovedCard
+		}
+		return rcOperationSuccess, nil
+	})
+
+	if _, err := c.transmit([]byte{0x00}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.Removed() {
+		t.Fatalf("c.Removed() = true, want false")
+	}
+
+	if _, err := c.transmit([]byte{0x00}); !errors.Is(err, scard.ErrRemovedCard) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !c.Removed() {
+		t.Fatalf("c.Removed() = false, want true")
+	}
+
+	if _, err := c.ReadBlock(4); !errors.Is(err, ErrCardRemoved) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestCardLastExchange(t *testing.T) {
+	c := transmitCard(func(cmd []byte) ([]byte, error) {
+		if cmd[1] == 0xB0 {
+			return nil, scard.ErrRemovedCard
+		}
+		return uidTransmit(cmd)
 	})
 
-	got, err := c.getUID()
-	if err != nil {
+	if sent, received := c.LastExchange(); sent != nil || received != nil {
+		t.Fatalf("c.LastExchange() = %X, %X, want nil, nil", sent, received)
+	}
+
+	if _, err := c.transmit(cmdGetUID); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if !bytes.Equal(got, testUID) {
-		t.Fatalf("%#v != %#v", got, testUID)
+	sent, received := c.LastExchange()
+	if want := append(append([]byte{}, testUID...), rcOperationSuccess...); !bytes.Equal(sent, cmdGetUID) || !bytes.Equal(received, want) {
+		t.Fatalf("c.LastExchange() = %X, %X, want %X, %X", sent, received, cmdGetUID, want)
+	}
+
+	cmd := []byte{0xFF, 0xB0, 0x00, 0x04, 0x10}
+	if _, err := c.transmit(cmd); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if sent, received := c.LastExchange(); !bytes.Equal(sent, cmd) || received != nil {
+		t.Fatalf("c.LastExchange() = %X, %X, want %X, nil", sent, received, cmd)
+	}
+}
+
+func TestCardVerify(t *testing.T) {
+	pin := []byte{0x31, 0x32, 0x33, 0x34}
+
+	for _, tc := range []struct {
+		name  string
+		resp  []byte
+		tries int
+		err   error
+	}{
+		{"Success", []byte{0x90, 0x00}, -1, nil},
+		{"Wrong PIN with tries", []byte{0x63, 0xC2}, 2, ErrWrongPIN},
+		{"Wrong PIN without tries", []byte{0x63, 0x00}, -1, ErrWrongPIN},
+		{"Blocked", []byte{0x69, 0x83}, 0, ErrPINBlocked},
+		{"Other status", []byte{0x6A, 0x86}, -1, ErrOperationFailed},
+		{"Short response", []byte{0x63}, -1, ErrShortResponse},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := transmitCard(func(cmd []byte) ([]byte, error) {
+				if want := []byte{0x00, 0x20, 0x00, 0x81, 0x04, 0x31, 0x32, 0x33, 0x34}; !bytes.Equal(cmd, want) {
+					t.Fatalf("cmd = %X, want %X", cmd, want)
+				}
+
+				return tc.resp, nil
+			})
+
+			tries, err := c.Verify(0x81, pin)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tries != tc.tries {
+				t.Fatalf("tries = %d, want %d", tries, tc.tries)
+			}
+		})
+	}
+
+	t.Run("Empty PIN", func(t *testing.T) {
+		if _, err := newCard("", nil).Verify(0x81, nil); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCardDisconnect(t *testing.T) {
+	var disconnects int
+
+	c := newCard("", &mockCard{disconnect: func(d scard.Disposition) error {
+		if d != scard.ResetCard {
+			t.Fatalf("d = %v, want %v", d, scard.ResetCard)
+		}
+
+		disconnects++
+		return nil
+	}})
+
+	for i := 0; i < 2; i++ {
+		if err := c.disconnect(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := disconnects, 1; got != want {
+		t.Fatalf("disconnects = %d, want %d", got, want)
 	}
 }
 
 var testUID = []byte{0x83, 0xfb, 0x58, 0x24, 0x90}
 
 type mockCard struct {
-	transmit func([]byte) ([]byte, error)
-	status   func() (*scard.CardStatus, error)
+	transmit   func([]byte) ([]byte, error)
+	status     func() (*scard.CardStatus, error)
+	disconnect func(scard.Disposition) error
+	control    func(uint32, []byte) ([]byte, error)
+	getAttrib  func(scard.Attrib) ([]byte, error)
 }
 
 func (c *mockCard) Transmit(cmd []byte) ([]byte, error) {
@@ -91,17 +502,53 @@ func (c *mockCard) Transmit(cmd []byte) ([]byte, error) {
 }
 
 func (c *mockCard) Status() (*scard.CardStatus, error) {
-	return c.status()
+	if c.status != nil {
+		return c.status()
+	}
+
+	return nil, scard.ErrUnknownError
 }
 
 func (c *mockCard) Disconnect(d scard.Disposition) error {
+	if c.disconnect != nil {
+		return c.disconnect(d)
+	}
+
 	return nil
 }
 
+func (c *mockCard) Control(ioctl uint32, in []byte) ([]byte, error) {
+	if c.control != nil {
+		return c.control(ioctl, in)
+	}
+
+	return nil, scard.ErrUnknownError
+}
+
+func (c *mockCard) GetAttrib(id scard.Attrib) ([]byte, error) {
+	if c.getAttrib != nil {
+		return c.getAttrib(id)
+	}
+
+	return nil, scard.ErrUnsupportedFeature
+}
+
+// uidTransmit responds to any command with testUID and a success code
+func uidTransmit(cmd []byte) ([]byte, error) {
+	return append(append([]byte{}, testUID...), rcOperationSuccess...), nil
+}
+
 func transmitCard(t func(cmd []byte) ([]byte, error)) *card {
 	return newCard("", &mockCard{transmit: t})
 }
 
+// atrStatus returns a status func reporting the ATR
+func atrStatus(atr []byte) func() (*scard.CardStatus, error) {
+	return func() (*scard.CardStatus, error) {
+		return &scard.CardStatus{Reader: "Test", Atr: atr}, nil
+	}
+}
+
 func statusCard(s func() (*scard.CardStatus, error)) *card {
 	return newCard("", &mockCard{status: s})
 }
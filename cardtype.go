@@ -0,0 +1,97 @@
+package acr122u
+
+import "bytes"
+
+// CardType is the type of a card as reported by the reader
+type CardType int
+
+// Card types
+const (
+	CardTypeUnknown CardType = iota
+	CardTypeMifareClassic1K
+	CardTypeMifareClassic4K
+	CardTypeMifareMini
+	CardTypeMifareUltralight
+	CardTypeTopaz
+	CardTypeFeliCa
+	CardTypeISODEP
+	CardTypeMifarePlus
+)
+
+func (t CardType) String() string {
+	switch t {
+	case CardTypeMifareClassic1K:
+		return "MIFARE Classic 1K"
+	case CardTypeMifareClassic4K:
+		return "MIFARE Classic 4K"
+	case CardTypeMifareMini:
+		return "MIFARE Mini"
+	case CardTypeMifareUltralight:
+		return "MIFARE Ultralight"
+	case CardTypeTopaz:
+		return "Topaz"
+	case CardTypeFeliCa:
+		return "FeliCa"
+	case CardTypeISODEP:
+		return "ISO-DEP"
+	case CardTypeMifarePlus:
+		return "MIFARE Plus"
+	default:
+		return "Unknown"
+	}
+}
+
+// atrStorageCardRID is the PC/SC registered application provider identifier
+// found in the ATR the reader builds for storage cards (PC/SC part 3)
+var atrStorageCardRID = []byte{0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00, 0x03, 0x06}
+
+// cardTypeFromATR returns the card type encoded in the ATR built by the reader
+func cardTypeFromATR(atr []byte) CardType {
+	if len(atr) >= 15 && bytes.Equal(atr[4:12], atrStorageCardRID) {
+		switch uint16(atr[13])<<8 | uint16(atr[14]) {
+		case 0x0001:
+			return CardTypeMifareClassic1K
+		case 0x0002:
+			return CardTypeMifareClassic4K
+		case 0x0003:
+			return CardTypeMifareUltralight
+		case 0x0026:
+			return CardTypeMifareMini
+		case atrMifarePlusSL1_2K, atrMifarePlusSL1_4K, atrMifarePlusSL2_2K, atrMifarePlusSL2_4K:
+			return CardTypeMifarePlus
+		case 0xF004:
+			return CardTypeTopaz
+		case 0xF011, 0xF012:
+			return CardTypeFeliCa
+		default:
+			return CardTypeUnknown
+		}
+	}
+
+	if len(atr) >= 4 && atr[0] == 0x3B && atr[1]&0xF0 == 0x80 && atr[2] == 0x80 && atr[3] == 0x01 {
+		return CardTypeISODEP
+	}
+
+	return CardTypeUnknown
+}
+
+func containsCardType(types []CardType, t CardType) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasATRPrefix reports whether the ATR starts with one of the prefixes
+func hasATRPrefix(prefixes [][]byte, atr []byte) bool {
+	for _, p := range prefixes {
+		if bytes.HasPrefix(atr, p) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package acr122u
+
+import "testing"
+
+func TestCardTypeFromATR(t *testing.T) {
+	for _, tc := range []struct {
+		atr  []byte
+		want CardType
+	}{
+		{atrMifareClassic1K, CardTypeMifareClassic1K},
+		{atrMifareClassic4K, CardTypeMifareClassic4K},
+		{atrMifareUltralight, CardTypeMifareUltralight},
+		{atrMifareMini, CardTypeMifareMini},
+		{[]byte{0x3B, 0x8F, 0x80, 0x01, 0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00, 0x03, 0x06, 0x11, 0xF0, 0x11, 0x00, 0x00, 0x00, 0x00, 0x8A}, CardTypeFeliCa},
+		{atrISODEP, CardTypeISODEP},
+		{atrMifarePlusSL1, CardTypeMifarePlus},
+		{atrMifarePlusSL3, CardTypeISODEP},
+		{[]byte{0x3B, 0x00}, CardTypeUnknown},
+		{nil, CardTypeUnknown},
+	} {
+		if got := cardTypeFromATR(tc.atr); got != tc.want {
+			t.Fatalf("cardTypeFromATR(%X) = %v, want %v", tc.atr, got, tc.want)
+		}
+	}
+}
+
+func TestCardType(t *testing.T) {
+	c := statusCard(atrStatus(atrMifareClassic4K))
+
+	got, err := c.Type()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := CardTypeMifareClassic4K; got != want {
+		t.Fatalf("c.Type() = %v, want %v", got, want)
+	}
+}
+
+var (
+	atrMifareMini       = []byte{0x3B, 0x8F, 0x80, 0x01, 0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00, 0x03, 0x06, 0x03, 0x00, 0x26, 0x00, 0x00, 0x00, 0x00, 0x4D}
+	atrMifareClassic1K  = []byte{0x3B, 0x8F, 0x80, 0x01, 0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00, 0x03, 0x06, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x6A}
+	atrMifareClassic4K  = []byte{0x3B, 0x8F, 0x80, 0x01, 0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00, 0x03, 0x06, 0x03, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x69}
+	atrMifareUltralight = []byte{0x3B, 0x8F, 0x80, 0x01, 0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00, 0x03, 0x06, 0x03, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x68}
+	atrISODEP           = []byte{0x3B, 0x81, 0x80, 0x01, 0x80, 0x80}
+)
@@ -0,0 +1,14 @@
+package acr122u
+
+import "time"
+
+// Clock provides the current time used for time based features
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
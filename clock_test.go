@@ -0,0 +1,30 @@
+package acr122u
+
+import (
+	"sync"
+	"time"
+)
+
+// mockClock is a clock that only moves when advanced
+type mockClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newMockClock() *mockClock {
+	return &mockClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *mockClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *mockClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,77 @@
+package acr122u
+
+import (
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// crossReaderWindow is how long a read UID counts as present on a reader
+// for WithCrossReaderConflict, unless the card is removed before
+const crossReaderWindow = time.Second
+
+// conflictTracker calls fn when the same UID is present on several readers.
+// A nil *conflictTracker is valid and tracks nothing.
+type conflictTracker struct {
+	mu     sync.Mutex
+	window time.Duration
+	fn     func(uid []byte, readers []string)
+	seen   map[string]map[string]time.Time
+}
+
+func newConflictTracker(window time.Duration, fn func(uid []byte, readers []string)) *conflictTracker {
+	return &conflictTracker{window: window, fn: fn, seen: map[string]map[string]time.Time{}}
+}
+
+// check records the UID read on the reader at now, calling fn if it is
+// present on other readers within the window
+func (ct *conflictTracker) check(reader string, uid []byte, now time.Time) {
+	if ct == nil {
+		return
+	}
+
+	ct.mu.Lock()
+	key := hex.EncodeToString(uid)
+	readers := ct.seen[key]
+	if readers == nil {
+		readers = map[string]time.Time{}
+		ct.seen[key] = readers
+	}
+	for r, t := range readers {
+		if now.Sub(t) > ct.window {
+			delete(readers, r)
+		}
+	}
+	readers[reader] = now
+
+	var conflicting []string
+	if len(readers) > 1 {
+		for r := range readers {
+			conflicting = append(conflicting, r)
+		}
+		sort.Strings(conflicting)
+	}
+	ct.mu.Unlock()
+
+	if conflicting != nil {
+		ct.fn(append([]byte{}, uid...), conflicting)
+	}
+}
+
+// remove forgets the UIDs read on the reader once its card is removed
+func (ct *conflictTracker) remove(reader string) {
+	if ct == nil {
+		return
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	for key, readers := range ct.seen {
+		delete(readers, reader)
+		if len(readers) == 0 {
+			delete(ct.seen, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestConflictTracker(t *testing.T) {
+	var conflicts [][]string
+
+	clk := newMockClock()
+	ct := newConflictTracker(time.Second, func(uid []byte, readers []string) {
+		conflicts = append(conflicts, readers)
+	})
+
+	ct.check("B", testUID, clk.Now())
+	ct.check("A", []byte{0x01, 0x02, 0x03, 0x04}, clk.Now())
+	ct.check("A", testUID, clk.Now())
+
+	if len(conflicts) != 1 || !stringsEqual(conflicts[0], []string{"A", "B"}) {
+		t.Fatalf("conflicts = %q, want [[A B]]", conflicts)
+	}
+
+	conflicts = nil
+
+	ct.remove("B")
+	ct.check("A", testUID, clk.Now())
+
+	clk.Advance(2 * time.Second)
+	ct.check("B", testUID, clk.Now())
+
+	if len(conflicts) != 0 {
+		t.Fatalf("conflicts = %q, want none", conflicts)
+	}
+}
+
+func TestContextServeCrossReaderConflict(t *testing.T) {
+	var (
+		uids    [][]byte
+		readers [][]string
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		listReaders: func() ([]string, error) {
+			return []string{"Reader B", "Reader A"}, nil
+		},
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent),
+	}, WithCrossReaderConflict(func(uid []byte, r []string) {
+		uids = append(uids, uid)
+		readers = append(readers, r)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var served int
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		if served++; served == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(readers) != 1 || !stringsEqual(readers[0], []string{"Reader A", "Reader B"}) {
+		t.Fatalf("readers = %q, want [[Reader A Reader B]]", readers)
+	}
+
+	if !bytes.Equal(uids[0], testUID) {
+		t.Fatalf("uid = %X, want %X", uids[0], testUID)
+	}
+}
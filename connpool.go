@@ -0,0 +1,131 @@
+package acr122u
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+// connPool keeps the connection of a handled card open so the next read of
+// the reader within the idle window reuses it instead of connecting again.
+// Connections are closed once idle for longer, or when the card is removed.
+// A nil *connPool is valid and keeps nothing.
+type connPool struct {
+	mu    sync.Mutex
+	idle  time.Duration
+	clock Clock
+	conns map[string]pooledConn
+}
+
+// pooledConn is an open connection waiting to be reused
+type pooledConn struct {
+	sc          PCSCCard
+	disposition Disposition
+	since       time.Time
+}
+
+func newConnPool(idle time.Duration) *connPool {
+	return &connPool{idle: idle, clock: realClock{}, conns: map[string]pooledConn{}}
+}
+
+// put keeps the connection to the reader open, closing the one kept before
+func (p *connPool) put(reader string, sc PCSCCard, d Disposition) {
+	p.mu.Lock()
+	prev, ok := p.conns[reader]
+	p.conns[reader] = pooledConn{sc: sc, disposition: d, since: p.clock.Now()}
+	p.mu.Unlock()
+
+	if ok && prev.sc != sc {
+		prev.close()
+	}
+}
+
+// take returns the open connection to the reader, or nil if there is none.
+// Connections which have been idle for too long or whose card was removed or
+// reset, for example because another card was presented, are closed.
+func (p *connPool) take(reader string) PCSCCard {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	pc, ok := p.conns[reader]
+	delete(p.conns, reader)
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	if p.clock.Now().Sub(pc.since) > p.idle {
+		pc.close()
+		return nil
+	}
+
+	if _, err := pc.sc.Status(); err != nil {
+		pc.close()
+		return nil
+	}
+
+	return pc.sc
+}
+
+// drop closes the open connection to the reader, if any
+func (p *connPool) drop(reader string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	pc, ok := p.conns[reader]
+	delete(p.conns, reader)
+	p.mu.Unlock()
+
+	if ok {
+		pc.close()
+	}
+}
+
+// expire closes the connections idle for longer than the idle window
+func (p *connPool) expire(now time.Time) {
+	if p == nil {
+		return
+	}
+
+	var expired []pooledConn
+
+	p.mu.Lock()
+	for reader, pc := range p.conns {
+		if now.Sub(pc.since) > p.idle {
+			expired = append(expired, pc)
+			delete(p.conns, reader)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pc := range expired {
+		pc.close()
+	}
+}
+
+// close closes all open connections
+func (p *connPool) close() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = map[string]pooledConn{}
+	p.mu.Unlock()
+
+	for _, pc := range conns {
+		pc.close()
+	}
+}
+
+func (pc pooledConn) close() {
+	// The card may already be gone, there is nothing to do about errors
+	_ = pc.sc.Disconnect(scard.Disposition(pc.disposition))
+}
@@ -0,0 +1,121 @@
+package acr122u
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextConnectionReuse(t *testing.T) {
+	var (
+		connects    int
+		disconnects int
+		statusErr   error
+	)
+
+	clock := newMockClock()
+
+	newReuseContext := func(t *testing.T) *Context {
+		connects, disconnects, statusErr = 0, 0, nil
+
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				connects++
+				return &mockCard{
+					transmit: uidTransmit,
+					status: func() (*scard.CardStatus, error) {
+						if statusErr != nil {
+							return nil, statusErr
+						}
+						return &scard.CardStatus{Reader: "Test", Atr: atrMifareClassic1K}, nil
+					},
+					disconnect: func(scard.Disposition) error {
+						disconnects++
+						return nil
+					},
+				}, nil
+			},
+		}, WithClock(clock), WithConnectionReuse(time.Minute))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return actx
+	}
+
+	read := func(t *testing.T, actx *Context) {
+		c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := c.disconnect(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	t.Run("Reused within window", func(t *testing.T) {
+		actx := newReuseContext(t)
+
+		read(t, actx)
+		clock.Advance(30 * time.Second)
+		read(t, actx)
+
+		if connects != 1 || disconnects != 0 {
+			t.Fatalf("connects = %d, disconnects = %d, want 1, 0", connects, disconnects)
+		}
+	})
+
+	t.Run("Card changed", func(t *testing.T) {
+		actx := newReuseContext(t)
+
+		read(t, actx)
+		statusErr = scard.ErrRemovedCard
+		read(t, actx)
+
+		if connects != 2 || disconnects != 1 {
+			t.Fatalf("connects = %d, disconnects = %d, want 2, 1", connects, disconnects)
+		}
+	})
+
+	t.Run("Idle window passed", func(t *testing.T) {
+		actx := newReuseContext(t)
+
+		read(t, actx)
+		clock.Advance(2 * time.Minute)
+		read(t, actx)
+
+		if connects != 2 || disconnects != 1 {
+			t.Fatalf("connects = %d, disconnects = %d, want 2, 1", connects, disconnects)
+		}
+	})
+
+	t.Run("Expired and removed", func(t *testing.T) {
+		actx := newReuseContext(t)
+
+		read(t, actx)
+		actx.connections.expire(clock.Now())
+		if disconnects != 0 {
+			t.Fatalf("disconnects = %d, want 0", disconnects)
+		}
+
+		actx.connections.expire(clock.Now().Add(2 * time.Minute))
+		if disconnects != 1 {
+			t.Fatalf("disconnects = %d, want 1", disconnects)
+		}
+
+		read(t, actx)
+		actx.connections.drop("Test")
+		if disconnects != 2 {
+			t.Fatalf("disconnects = %d, want 2", disconnects)
+		}
+
+		read(t, actx)
+		if err := actx.Release(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if connects != 3 || disconnects != 3 {
+			t.Fatalf("connects = %d, disconnects = %d, want 3, 3", connects, disconnects)
+		}
+	})
+}
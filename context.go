@@ -2,36 +2,165 @@ package acr122u
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ebfe/scard"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
-var scardEstablishContext = scard.EstablishContext
+// Establishes the *scard.Context, replaced in tests. The scard package only
+// establishes contexts in the system scope.
+var scardEstablishContext = func(scope Scope) (*scard.Context, error) {
+	if scope != ScopeSystem {
+		return nil, wrapError(fmt.Sprintf("establish scope %d", scope), ErrNotSupported)
+	}
+
+	return scard.EstablishContext()
+}
 
 // Context for ACR122U readers
 type Context struct {
-	context   scardContext
-	readers   []string
-	shareMode ShareMode
-	protocol  Protocol
-	logLevel  LogLevel
-	logWriter io.Writer
+	context       PCSCContext
+	scope         Scope
+	readers       []string
+	shareMode     ShareMode
+	protocol      Protocol
+	logLevel      LogLevel
+	logWriter     io.Writer
+	logTimeFormat string
+	logNoColor    bool
+	logger        zerolog.Logger
+	logOut        *logOutput
+	readCache     *readCache
+	dedupeReaders bool
+	middleware    []Middleware
+	sinks         []cardSink
+	uidLengths    *uidLengthCache
+	disposition   Disposition
+	dispatchModel DispatchModel
+	uidCommand    []byte
+	clock         Clock
+	idle          *idleTracker
+	readerOrder   []string
+	maxBaudRate   BaudRate
+	cardTypes     []CardType
+	atrPrefixes   [][]byte
+	throttle      *readThrottle
+	backoff       Backoff
+	resilient     bool
+	pingFirmware  bool
+	escapeCode    uint32
+	maxErrors     int
+	readOnly      bool
+	verifyWrites  bool
+	uidRetries    int
+	bufferSize    int
+	buffered      bool
+	conflicts     *conflictTracker
+	beforeConnect func(reader string) error
+	recorder      *sessionRecorder
+	maxRuntime    time.Duration
+	connections   *connPool
+	watchdog      time.Duration
+	removals      *removalTracker
+	stateHandler  func(ReaderState)
+	sleep         sleepState
+	blocklist     uidSet
+	denied        func(Card)
+	identityFn    func(Card) (string, error)
+	runtime       *runtimeLimit
+	handler       Handler
+	handlerMu     sync.Mutex
+	optionsMu     sync.RWMutex
+	establish     func() (PCSCContext, error)
+	contextMu     sync.RWMutex
+	generation    int
+
+	handlerTimeout     time.Duration
+	readerErrorHandler func(reader string, err error)
+	cardErrorHandler   func(reader string, err error)
+	inlineDispatch     bool
+	// runtimeSafe is set by the options Apply may apply, see runtimeSafe
+	runtimeSafe bool
 }
 
 // EstablishContext creates a ACR122U context
 func EstablishContext(options ...Option) (*Context, error) {
-	sctx, err := scardEstablishContext()
+	return establishContext(options...)
+}
+
+// EstablishContextWithRetry creates a ACR122U context like EstablishContext,
+// retrying with backoff until it succeeds or ctx is done, for example while
+// the PC/SC service is still starting. Invalid options are not retried.
+// A nil backoff uses the ExponentialBackoff defaults.
+func EstablishContextWithRetry(ctx context.Context, backoff Backoff, options ...Option) (*Context, error) {
+	backoff = backoffOrDefault(backoff)
+	for attempt := 0; ; attempt++ {
+		actx, err := establishContext(options...)
+		if err == nil || errors.Is(err, ErrInvalidParameter) {
+			if attempt > 0 {
+				backoff.Reset()
+			}
+			return actx, err
+		}
+
+		timer := time.NewTimer(backoff.Next(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, wrapError(err.Error(), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Establishes the PC/SC context and creates a context, releasing the PC/SC
+// context if creating the context fails
+func establishContext(options ...Option) (*Context, error) {
+	scope := establishScope(options...)
+	establish := func() (PCSCContext, error) {
+		return establishPCSCContext(scope)
+	}
+
+	sctx, err := establish()
+	if err != nil {
+		return nil, pcscError(err)
+	}
+
+	actx, err := newContext(sctx, options...)
+	if err != nil {
+		_ = sctx.Release()
+		return nil, err
+	}
+	actx.establish = establish
+
+	return actx, nil
+}
+
+// Returns the scope selected by the options, which is needed before the
+// options are applied to the context
+func establishScope(options ...Option) Scope {
+	actx := &Context{scope: ScopeSystem}
+	for _, option := range options {
+		option(actx)
+	}
+	return actx.scope
+}
+
+// Establishes the PC/SC context, replaced in tests
+var establishPCSCContext = func(scope Scope) (PCSCContext, error) {
+	sctx, err := scardEstablishContext(scope)
 	if err != nil {
 		return nil, err
 	}
 
-	return newContext(sctx, options...)
+	return scardContextAdapter{sctx}, nil
 }
 
 // Option is the function type used to configure the context
@@ -44,6 +173,14 @@ func WithShareMode(sm ShareMode) Option {
 	}
 }
 
+// WithDisposition sets the action taken on the card when disconnecting,
+// defaults to ResetCard
+func WithDisposition(d Disposition) Option {
+	return func(actx *Context) {
+		actx.disposition = d
+	}
+}
+
 // WithProtocol accepts Undefined (0x0), T0 (0x1), T1 (0x2) or Any (T0|T1)
 func WithProtocol(p Protocol) Option {
 	return func(actx *Context) {
@@ -51,52 +188,514 @@ func WithProtocol(p Protocol) Option {
 	}
 }
 
+// WithEstablishScope sets the scope of the PC/SC context established by
+// EstablishContext, defaults to ScopeSystem. The scard package only supports
+// ScopeSystem, other scopes return ErrNotSupported.
+func WithEstablishScope(scope Scope) Option {
+	return func(actx *Context) {
+		actx.scope = scope
+	}
+}
+
 // Sets the logging level
 func WithLogLevel(l LogLevel) Option {
-	return func(actx *Context) {
+	return runtimeSafe(func(actx *Context) {
 		actx.logLevel = l
-	}
+	})
 }
 
 // Sets the log writer
 func WithLogWriter(w io.Writer) Option {
-	return func(actx *Context) {
+	return runtimeSafe(func(actx *Context) {
 		actx.logWriter = w
+	})
+}
+
+// Sets the time format used by the console log writer, e.g. time.RFC3339
+func WithLogTimeFormat(format string) Option {
+	return runtimeSafe(func(actx *Context) {
+		actx.logTimeFormat = format
+	})
+}
+
+// Disables colors in the console log writer, e.g. when logging to a file
+func WithLogNoColor() Option {
+	return runtimeSafe(func(actx *Context) {
+		actx.logNoColor = true
+	})
+}
+
+// WithReadCache reuses the identity computed for a card for the given TTL as
+// long as the card has not been removed. The card is still connected and its
+// UID read, but a matching reader+UID skips the identity function set with
+// WithIdentityFunc, which is typically the expensive part of a read.
+func WithReadCache(ttl time.Duration) Option {
+	return func(actx *Context) {
+		actx.readCache = newReadCache(ttl)
+	}
+}
+
+// WithDedupeReaders collapses readers that refer to the same device,
+// preferring the PICC interface. See normalizeReaderName for the matching rules.
+func WithDedupeReaders() Option {
+	return func(actx *Context) {
+		actx.dedupeReaders = true
+	}
+}
+
+// WithMiddleware wraps the handler passed to Serve in the middleware,
+// the first middleware being the outermost
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(actx *Context) {
+		actx.middleware = append(actx.middleware, middleware...)
+	}
+}
+
+// WithNetworkSink writes each card read as a line of JSON to the network address,
+// e.g. WithNetworkSink("unix", "/tmp/cards.sock") or WithNetworkSink("tcp", "localhost:9000").
+// The connection is re-established on failure, and card events are buffered
+// and eventually dropped while the consumer is unavailable.
+func WithNetworkSink(network, address string) Option {
+	return func(actx *Context) {
+		actx.sinks = append(actx.sinks, newNetworkSink(network, address))
+	}
+}
+
+// WithFileSink appends each card read as a line of JSON to the file at path,
+// creating it if needed. Before exceeding maxBytes the file is rotated to
+// path.1, shifting older files to path.2, path.3, etc. A maxBytes of 0
+// disables rotation. Card events are written in the background and dropped
+// if the disk cannot keep up. While Serve is called concurrently, e.g. by
+// ReadN, the events are written by the Serve call started first.
+func WithFileSink(path string, maxBytes int64) Option {
+	return func(actx *Context) {
+		actx.sinks = append(actx.sinks, newFileSink(path, maxBytes))
+	}
+}
+
+// DispatchModel selects how readers are polled and cards are read
+type DispatchModel int
+
+// Dispatch models
+const (
+	// DispatchSingleLoop polls all readers with a single GetStatusChange
+	// loop and reads cards inline, so a slow read delays other readers.
+	DispatchSingleLoop DispatchModel = iota
+
+	// DispatchSingleLoopAsync polls all readers with a single GetStatusChange
+	// loop and reads cards in separate goroutines. A present event may be
+	// delivered after a later event of the same reader.
+	DispatchSingleLoopAsync
+
+	// DispatchPerReader polls each reader with its own GetStatusChange loop.
+	DispatchPerReader
+)
+
+// WithDispatchModel selects the dispatch model, defaults to DispatchSingleLoop.
+// DispatchSingleLoopAsync keeps the number of wakeups low with many readers.
+func WithDispatchModel(m DispatchModel) Option {
+	return func(actx *Context) {
+		actx.dispatchModel = m
+	}
+}
+
+// WithOrderedBuffer queues up to size read cards for the handler, so bursts
+// of cards do not block the readers. Cards are handled one at a time in the
+// order they were read. A full buffer blocks reading until the handler
+// catches up, no card is dropped. Queued cards stay connected, but may have
+// been removed by the time they are handled.
+func WithOrderedBuffer(size int) Option {
+	return func(actx *Context) {
+		actx.bufferSize = size
+		actx.buffered = true
+	}
+}
+
+// WithInlineDispatch handles cards on the goroutine receiving the reader
+// states instead of a dispatcher goroutine, as done before the dispatcher
+// was introduced. Reading stops while a card is pending, so a slow handler
+// delays detecting the next card.
+func WithInlineDispatch() Option {
+	return func(actx *Context) {
+		actx.inlineDispatch = true
+	}
+}
+
+// WithUIDCommand overrides the APDU sent to read the UID of a card.
+// The default is the PC/SC GET DATA command FF CA 00 00 00, retried with
+// explicit lengths if needed. A custom command is sent as is and must be
+// at least 4 bytes long.
+func WithUIDCommand(apdu []byte) Option {
+	return func(actx *Context) {
+		actx.uidCommand = append([]byte{}, apdu...)
+	}
+}
+
+// WithUIDRetries sets how often a UID read answered with 0x63 0x00 is
+// retried before giving up, for tags failing the first read after entering
+// the field. The default is 1.
+func WithUIDRetries(n int) Option {
+	return func(actx *Context) {
+		actx.uidRetries = n
+	}
+}
+
+// WithIdleCallback calls fn with the idle duration when no card has been read
+// for after, and every after thereafter until a card is read. after must be
+// positive. fn is called from the read loop and should return quickly.
+func WithIdleCallback(after time.Duration, fn func(idle time.Duration)) Option {
+	return func(actx *Context) {
+		actx.idle = newIdleTracker(after, fn)
+	}
+}
+
+// WithCrossReaderConflict calls fn with the UID and the readers when a card
+// with the same UID is read on several readers within a second, as a single
+// physical card cannot be in two places. The readers are sorted by name.
+// fn is called from the read loop and should return quickly.
+func WithCrossReaderConflict(fn func(uid []byte, readers []string)) Option {
+	return func(actx *Context) {
+		actx.conflicts = newConflictTracker(crossReaderWindow, fn)
+	}
+}
+
+// WithBeforeConnect calls fn before connecting to a card presented to a
+// reader, for example to switch an external antenna. If fn returns an error
+// the card is not read and the error is passed to the card error handler.
+// fn is not called by Reserve, ConnectExclusive or reader commands.
+func WithBeforeConnect(fn func(reader string) error) Option {
+	return func(actx *Context) {
+		actx.beforeConnect = fn
 	}
 }
 
+// WithMinReadInterval waits until at least d has passed since the previous
+// card read on a reader before reading the next card, for readers whose RF
+// field needs time to cycle between reads. Unlike debouncing this applies
+// to every card, regardless of its UID. Zero does not wait.
+func WithMinReadInterval(d time.Duration) Option {
+	return runtimeSafe(func(actx *Context) {
+		actx.throttle = nil
+		if d > 0 {
+			actx.throttle = newReadThrottle(d)
+		}
+	})
+}
+
+// WithMaxRuntime stops Serve after d, returning ErrMaxRuntimeReached, for
+// example to close a timed enrollment window. The runtime is measured in
+// real time and on the clock set by WithClock, whichever passes d first.
+// If the ctx passed to Serve is done first Serve returns as usual.
+func WithMaxRuntime(d time.Duration) Option {
+	return func(actx *Context) {
+		actx.maxRuntime = d
+	}
+}
+
+// WithConnectionReuse keeps the connection of a handled card open for idle,
+// so the next read of the reader reuses it instead of connecting again.
+// The connection is closed once idle for longer or when the card is removed,
+// and not reused if the card was reset or replaced in the meantime.
+// Zero disables reuse.
+func WithConnectionReuse(idle time.Duration) Option {
+	return func(actx *Context) {
+		actx.connections = nil
+		if idle > 0 {
+			actx.connections = newConnPool(idle)
+		}
+	}
+}
+
+// WithWatchdog restarts a read loop of Serve when GetStatusChange has not
+// returned for timeout, for drivers that hang without returning an error.
+// The stalled loop is reported to the reader error handler as
+// ErrWatchdogTimeout and the PC/SC context is re-established, which
+// interrupts the hanging call. If the context cannot be re-established the
+// loop is not restarted and Serve returns the error. As the readers are
+// polled every second and the loop also waits while reading cards and backing
+// off after errors, timeout should be well above these delays. A timeout of a
+// second or less is rejected with ErrInvalidParameter.
+func WithWatchdog(timeout time.Duration) Option {
+	return func(actx *Context) {
+		actx.watchdog = timeout
+	}
+}
+
+// WithResilientLoop keeps Serve running after read and polling errors,
+// which are logged and passed to the error handlers, retrying with backoff
+// until ctx is done. By default Serve returns on the first error.
+// Combine with WithAutoReestablish to also re-establish the PC/SC context.
+func WithResilientLoop() Option {
+	return func(actx *Context) {
+		actx.resilient = true
+	}
+}
+
+// WithBackoff sets the backoff between read loop retries after errors,
+// see WithAutoReestablish and WithResilientLoop. Defaults to an ExponentialBackoff from 100ms to 5s.
+func WithBackoff(b Backoff) Option {
+	return func(actx *Context) {
+		actx.backoff = b
+	}
+}
+
+// WithReaderOrder sets the order readers are polled and their cards are
+// dispatched in, for example to favor a primary reader when cards are
+// presented to several readers at once. Unlisted readers follow the listed
+// ones. Each listed reader must exist. With DispatchPerReader readers are
+// polled independently, so simultaneous cards may be dispatched in any order.
+func WithReaderOrder(order []string) Option {
+	return func(actx *Context) {
+		actx.readerOrder = append([]string{}, order...)
+	}
+}
+
+// WithMaxBaudRate requests the bit rate from ISO14443-4 (ISO-DEP) cards after
+// connecting, speeding up large transfers. Cards rejecting the request stay
+// at the default of 106 kbps.
+func WithMaxBaudRate(rate BaudRate) Option {
+	return func(actx *Context) {
+		actx.maxBaudRate = rate
+	}
+}
+
+// WithCardTypeFilter only dispatches cards of the card types, other cards
+// are logged and skipped. No card types dispatches all cards.
+func WithCardTypeFilter(types ...CardType) Option {
+	return func(actx *Context) {
+		actx.cardTypes = append([]CardType{}, types...)
+	}
+}
+
+// WithUIDBlocklist keeps cards with the UIDs, for example revoked cards,
+// from the handler and ScanAll. They are passed to the WithDeniedCallback
+// callback instead, if any. Lookups take constant time, so the list can be large.
+func WithUIDBlocklist(uids [][]byte) Option {
+	return func(actx *Context) {
+		actx.blocklist = newUIDSet(uids)
+	}
+}
+
+// WithDeniedCallback calls fn instead of the handler for cards blocked by
+// WithUIDBlocklist, for example to flash a red LED or log the attempt.
+// The card is disconnected after fn returns.
+func WithDeniedCallback(fn func(Card)) Option {
+	return func(actx *Context) {
+		actx.denied = fn
+	}
+}
+
+// WithIdentityFunc deduplicates cards in ReadN by the identity fn returns
+// instead of the UID, e.g. a value read from an applet, so cards with random
+// UIDs can be deduplicated. fn is called while reading the card, before it
+// is handled. Cards for which fn fails or returns "" fall back to the UID.
+func WithIdentityFunc(fn func(Card) (string, error)) Option {
+	return func(actx *Context) {
+		actx.identityFn = fn
+	}
+}
+
+// WithATRPrefixFilter only reads cards whose ATR, as reported by the reader
+// state, starts with one of the prefixes. Other cards are skipped without
+// connecting, unlike WithCardTypeFilter. Cards whose reader state reports no
+// ATR are read. No prefixes reads all cards.
+func WithATRPrefixFilter(prefixes ...[]byte) Option {
+	return func(actx *Context) {
+		actx.atrPrefixes = nil
+		for _, p := range prefixes {
+			actx.atrPrefixes = append(actx.atrPrefixes, append([]byte{}, p...))
+		}
+	}
+}
+
+// WithEscapeIoctl sets the IOCTL used to send escape commands to the reader,
+// for drivers expecting another code than the default of the OS, e.g. the ACS
+// acsccid driver on Linux, which expects scard.CtlCode(3500).
+func WithEscapeIoctl(code uint32) Option {
+	return func(actx *Context) {
+		actx.escapeCode = code
+	}
+}
+
+// WithPingFirmware makes Ping also query the PN532 firmware of the first reader
+func WithPingFirmware() Option {
+	return func(actx *Context) {
+		actx.pingFirmware = true
+	}
+}
+
+// WithAutoReestablish keeps serving after read and polling errors, releasing
+// and re-establishing the PC/SC context after maxConsecutiveErrors errors
+// without a card being read successfully. Card read errors with
+// DispatchSingleLoopAsync are skipped without being counted. Only contexts
+// created by EstablishContext are re-established.
+func WithAutoReestablish(maxConsecutiveErrors int) Option {
+	return func(actx *Context) {
+		actx.maxErrors = maxConsecutiveErrors
+	}
+}
+
+// WithReadOnly makes the methods writing to cards return ErrReadOnlyMode
+// without sending anything to the card. Reading is unaffected.
+func WithReadOnly() Option {
+	return func(actx *Context) {
+		actx.readOnly = true
+	}
+}
+
+// WithVerifyWrites makes WriteBlock and WritePage read the data back after
+// writing, returning ErrWriteVerifyFailed if it differs. MIFARE Classic
+// sector trailers are not verified, as the keys read back as zeros.
+func WithVerifyWrites() Option {
+	return func(actx *Context) {
+		actx.verifyWrites = true
+	}
+}
+
+// WithClock replaces the clock used for time based features such as
+// WithIdleCallback, to drive them from simulated time.
+func WithClock(c Clock) Option {
+	return func(actx *Context) {
+		actx.clock = c
+	}
+}
+
+// WithHandlerTimeout cancels the context passed to a ContextHandler after d.
+// Plain Handlers are not interrupted.
+func WithHandlerTimeout(d time.Duration) Option {
+	return runtimeSafe(func(actx *Context) {
+		actx.handlerTimeout = d
+	})
+}
+
+// WithReaderErrorHandler calls fn when polling a reader fails, which stops
+// Serve. A loop polling several readers calls fn for each of its readers.
+func WithReaderErrorHandler(fn func(reader string, err error)) Option {
+	return runtimeSafe(func(actx *Context) {
+		actx.readerErrorHandler = fn
+	})
+}
+
+// WithCardErrorHandler calls fn when reading a card presented to a reader fails.
+func WithCardErrorHandler(fn func(reader string, err error)) Option {
+	return runtimeSafe(func(actx *Context) {
+		actx.cardErrorHandler = fn
+	})
+}
+
+// WithRemovalHandler calls fn when a card is removed from a reader, with a
+// snapshot of the last card read by the reader, to pair the card presented
+// with its removal. last is nil if no card was read, for example because
+// reading it failed or it was skipped. Unless WithInlineDispatch is used, fn
+// is called by the dispatcher after handling the cards read before.
+func WithRemovalHandler(fn func(reader string, last *CardSnapshot)) Option {
+	return func(actx *Context) {
+		actx.removals = newRemovalTracker(fn)
+	}
+}
+
+// WithStateHandler calls fn with each change of the state of a reader seen by
+// Serve, e.g. to show whether a card is present or muted. fn is called by the
+// goroutine receiving the states before the card read, if any, is dispatched,
+// and must not block.
+func WithStateHandler(fn func(ReaderState)) Option {
+	return func(actx *Context) {
+		actx.stateHandler = fn
+	}
+}
+
+// NewContext creates a context using the PC/SC context, which is released by Release.
+// Use EstablishContext to create a context for the system's readers.
+func NewContext(pc PCSCContext, options ...Option) (*Context, error) {
+	return newContext(pc, options...)
+}
+
 // Creates a context with the supplied options.  Processes options for logging.
-func newContext(sctx scardContext, options ...Option) (*Context, error) {
+func newContext(sctx PCSCContext, options ...Option) (*Context, error) {
 	if _, err := sctx.IsValid(); err != nil {
-		return nil, err
+		return nil, pcscError(err)
 	}
 	readers, err := sctx.ListReaders()
 	if err != nil {
-		return nil, err
+		return nil, pcscError(err)
 	}
 	if len(readers) == 0 {
 		return nil, scard.ErrNoReadersAvailable
 	}
 	actx := &Context{
-		context:   sctx,
-		readers:   readers,
-		shareMode: ShareShared,
-		protocol:  ProtocolAny,
-		logLevel:  LogDebug,
-		logWriter: ConsoleLogger,
+		context:     sctx,
+		scope:       ScopeSystem,
+		readers:     readers,
+		shareMode:   ShareShared,
+		protocol:    ProtocolAny,
+		logLevel:    LogDebug,
+		logWriter:   ConsoleLogger,
+		uidLengths:  newUIDLengthCache(),
+		disposition: ResetCard,
+		clock:       realClock{},
+		uidRetries:  1,
+		escapeCode:  ioctlEscape,
+		removals:    newRemovalTracker(nil),
 	}
 	for _, option := range options {
 		option(actx)
 	}
-	zerolog.SetGlobalLevel(zerolog.Level(actx.logLevel))
-	log.Logger = log.Output(actx.logWriter)
+	if actx.dedupeReaders {
+		actx.readers = dedupeReaders(actx.readers)
+	}
+	if actx.readerOrder != nil {
+		if actx.readers, err = orderReaders(actx.readers, actx.readerOrder); err != nil {
+			return nil, err
+		}
+	}
+	if actx.maxErrors < 0 {
+		return nil, wrapError("negative error limit", ErrInvalidParameter)
+	}
+	if actx.maxBaudRate < BaudRate106 || actx.maxBaudRate > BaudRate848 {
+		return nil, wrapError(actx.maxBaudRate.String(), ErrInvalidParameter)
+	}
+	if actx.buffered && actx.bufferSize < 1 {
+		return nil, wrapError("buffer size", ErrInvalidParameter)
+	}
+	if actx.watchdog < 0 {
+		return nil, wrapError("negative watchdog timeout", ErrInvalidParameter)
+	}
+	if actx.watchdog > 0 && actx.watchdog <= pollInterval {
+		return nil, wrapError(fmt.Sprintf("watchdog timeout %v within the %v poll interval", actx.watchdog, pollInterval), ErrInvalidParameter)
+	}
+	if actx.maxRuntime < 0 {
+		return nil, wrapError("negative maximum runtime", ErrInvalidParameter)
+	}
+	if actx.uidRetries < 0 {
+		return nil, wrapError("negative UID retries", ErrInvalidParameter)
+	}
+	if actx.idle != nil && actx.idle.after <= 0 {
+		return nil, wrapError(fmt.Sprintf("idle duration %v", actx.idle.after), ErrInvalidParameter)
+	}
+	for _, p := range actx.atrPrefixes {
+		if len(p) == 0 {
+			return nil, wrapError("empty ATR prefix", ErrInvalidParameter)
+		}
+	}
+	if actx.uidCommand != nil && len(actx.uidCommand) < 4 {
+		return nil, wrapError("UID command too short", ErrInvalidParameter)
+	}
+	if actx.connections != nil {
+		actx.connections.clock = actx.clock
+	}
+	if actx.recorder != nil {
+		actx.recorder.begin(readers, actx.clock)
+		actx.context = actx.recordContext(actx.context)
+	}
+	actx.logger = actx.newLogger()
 
 	return actx, nil
 }
 
 // Release should be called when the context is not needed anymore
 func (actx *Context) Release() error {
-	return actx.context.Release()
+	actx.connections.close()
+	return actx.pcsc().Release()
 }
 
 // Readers returns a list of readers
@@ -109,66 +708,478 @@ func (actx *Context) SetReaders(r []string) {
 	actx.readers = r
 }
 
+// Reserve connects to the reader in exclusive mode, preventing other processes
+// from using it until release is called.  Returns ErrReaderBusy if another
+// process holds the reader.  PC/SC requires a card to be present to connect.
+func (actx *Context) Reserve(reader string) (release func(), err error) {
+	c, err := actx.connectExclusive(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.disposition = LeaveCard
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if err := c.disconnect(); err != nil {
+				actx.logger.Error().Err(err).Str("Reader", reader).Msg("Problem releasing reader")
+			}
+		})
+	}, nil
+}
+
+// ConnectExclusive connects to the card on the reader in exclusive mode and
+// reads its UID, preventing other processes from interleaving commands, e.g.
+// during a multi-step write. Returns ErrReaderBusy if another process holds
+// the reader. The card must be disconnected using Disconnect.
+func (actx *Context) ConnectExclusive(reader string) (Card, error) {
+	c, err := actx.connectExclusive(reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.uid, err = c.getUID(); err != nil {
+		if err := c.disconnect(); err != nil {
+			actx.logger.Error().Err(err).Str("Reader", reader).Msg("Problem disconnecting")
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// Connects to the reader in exclusive mode, mapping sharing violations to ErrReaderBusy
+func (actx *Context) connectExclusive(reader string) (*card, error) {
+	c, err := actx.connectWith(reader, ShareExclusive, actx.protocol)
+	if err != nil {
+		if errors.Is(err, scard.ErrSharingViolation) {
+			return nil, wrapError(reader, ErrReaderBusy)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// ReadN serves until n cards with distinct UIDs have been read or ctx is done,
+// returning the cards read. Cards with random UIDs are never deduplicated,
+// unless WithIdentityFunc identifies them.  If fewer than n cards were read the
+// error that stopped reading is returned, or the error of ctx if it is done.
+func (actx *Context) ReadN(ctx context.Context, n int) ([]*CardSnapshot, error) {
+	var (
+		snapshots    []*CardSnapshot
+		seen         = map[string]bool{}
+		ctx2, cancel = context.WithCancel(ctx)
+	)
+	defer cancel()
+	err := actx.ServeFunc(ctx2, func(c Card) {
+		key, dedupe := dedupeKey(c)
+		if (seen[key] && dedupe) || len(snapshots) >= n {
+			return
+		}
+		seen[key] = true
+		s := newCardSnapshot(c)
+		snapshots = append(snapshots, &s)
+		if len(snapshots) == n {
+			cancel()
+		}
+	})
+	if err != nil {
+		return snapshots, err
+	}
+	if len(snapshots) < n {
+		if err := ctx.Err(); err != nil {
+			return snapshots, err
+		}
+		return snapshots, ErrShutdown
+	}
+	return snapshots, nil
+}
+
+// dedupeKey returns the key deduplicating the card, its WithIdentityFunc
+// identity or else its UID, and whether the card can be deduplicated, which
+// cards with a random UID and no identity cannot
+func dedupeKey(c Card) (string, bool) {
+	if cd, ok := c.(*card); ok && cd.identity != "" {
+		return "identity:" + cd.identity, true
+	}
+
+	return "uid:" + hex.EncodeToString(c.UID()), !c.UIDIsRandom()
+}
+
+// Ping checks that the PC/SC context is valid and at least one reader is
+// available, for health checks. With WithPingFirmware it also queries the
+// firmware of the first reader. Ping is safe to call while serving.
+func (actx *Context) Ping() error {
+	if _, err := actx.pcsc().IsValid(); err != nil {
+		return pcscError(err)
+	}
+	readers, err := actx.pcsc().ListReaders()
+	if err != nil {
+		return pcscError(err)
+	}
+	if len(readers) == 0 {
+		return ErrNoReadersAvailable
+	}
+	if actx.pingFirmware {
+		if _, err := actx.pn532(actx.readers[0], 0x02); err != nil {
+			return wrapError("firmware query", err)
+		}
+	}
+	return nil
+}
+
 // ServeFunc uses the provided HandlerFunc as a Handler
 func (actx *Context) ServeFunc(ctx context.Context, hf HandlerFunc) error {
 	return actx.Serve(ctx, hf)
 }
 
-// Serve cards being swiped using the provided Handler
+// Serve cards being swiped using the provided Handler.
+//
+// By default cards are handled one at a time, in the order they were read,
+// by a dispatcher goroutine, so a slow handler does not delay detecting the
+// next cards. Up to 16 read cards are queued for the dispatcher, see
+// WithOrderedBuffer. Serve returns once the queued cards have been handled.
+// With WithInlineDispatch cards are handled by the goroutine receiving the
+// reader states, which stops reading once a single card is pending.
+//
+// Serve returns nil once ctx is done. If reading stops first, e.g. on a card
+// read error without WithResilientLoop, the error that stopped it is returned.
 func (actx *Context) Serve(ctx context.Context, h Handler) error {
+	return actx.serve(ctx, h, actx.readerLists(), false)
+}
+
+// ServeMap serves the readers in handlers, each polled by its own loop, using
+// the handler of the reader for its cards. Other readers are not served. Each
+// reader has its own dispatcher goroutine, unless WithInlineDispatch is used,
+// so a slow handler only delays the cards of its reader. A reader that fails
+// stops without affecting the other readers. ServeMap returns once ctx is
+// done or all readers have stopped, returning the errors of the failed
+// readers as ReaderErrors. Returns ErrUnknownReader if a reader in handlers
+// does not exist.
+func (actx *Context) ServeMap(ctx context.Context, handlers map[string]Handler) error {
+	if len(handlers) == 0 {
+		return wrapError("no reader handlers", ErrInvalidParameter)
+	}
+	for r, h := range handlers {
+		if h == nil {
+			return wrapError(fmt.Sprintf("nil handler for %q", r), ErrInvalidParameter)
+		}
+		if !containsReader(actx.readers, r) {
+			return wrapError(r, ErrUnknownReader)
+		}
+	}
+	var readers []string
+	for _, r := range actx.readers {
+		if _, ok := handlers[r]; ok {
+			readers = append(readers, r)
+		}
+	}
+	return actx.serve(ctx, readerHandlers(handlers), perReaderLists(readers), true)
+}
+
+// Serves the cards read by a read loop per reader list using h, stopping
+// once the maximum runtime has passed. With isolate each reader list has its
+// own dispatcher and a failing read loop does not stop the others.
+func (actx *Context) serve(ctx context.Context, h Handler, readerLists [][]string, isolate bool) error {
+	ctx, limit := actx.limitRuntime(ctx)
+	err := actx.serveLoop(ctx, h, readerLists, isolate)
+	if rerr := limit.release(); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// Serves the cards read by a read loop per reader list using h until ctx is done
+func (actx *Context) serveLoop(ctx context.Context, h Handler, readerLists [][]string, isolate bool) error {
 	var (
-		logger = log.With().Str("Caller", "Serve").Logger()
+		logger = actx.logger.With().Str("Caller", "Serve").Logger()
 	)
+	actx.SetHandler(h)
+	// Sinks are stopped once the queued cards have been handled, and write
+	// the events left before Serve returns
+	var sinks sync.WaitGroup
+	sinkCtx, stopSinks := context.WithCancel(ctx)
+	defer func() {
+		stopSinks()
+		sinks.Wait()
+	}()
+	for _, s := range actx.sinks {
+		sinks.Add(1)
+		go func(s cardSink) {
+			defer sinks.Done()
+			s.run(sinkCtx, actx.logger)
+		}(s)
+	}
 	// Channel for state reads
-	stateChan := make(chan scard.ReaderState, 1)
-	go actx.read(ctx, stateChan)
+	stateChan := make(chan scard.ReaderState, actx.stateBuffer())
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- actx.read(ctx, readerLists, isolate, stateChan)
+	}()
+
+	dispatch, dispatchRemoval := actx.handle, actx.handleRemoval
+	if !actx.inlineDispatch {
+		// Removals are queued with the cards to be handled in order, using
+		// a queue per reader when isolated
+		var (
+			queues = map[string]chan func(){}
+			done   sync.WaitGroup
+		)
+		keys := []string{""}
+		if isolate {
+			keys = nil
+			for _, readers := range readerLists {
+				keys = append(keys, readers...)
+			}
+		}
+		for _, key := range keys {
+			queue := make(chan func(), actx.dispatchBuffer())
+			queues[key] = queue
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				for fn := range queue {
+					fn()
+				}
+			}()
+		}
+		defer func() {
+			for _, queue := range queues {
+				close(queue)
+			}
+			done.Wait()
+		}()
+		queueFor := func(reader string) chan<- func() {
+			if isolate {
+				return queues[reader]
+			}
+			return queues[""]
+		}
+		dispatch = func(ctx context.Context, c cardData) {
+			queueFor(c.Reader()) <- func() { actx.handle(ctx, c) }
+		}
+		dispatchRemoval = func(r cardRemoval) {
+			queueFor(r.reader) <- func() { actx.handleRemoval(r) }
+		}
+	}
+
+	// Include the raw state values when tracing
+	formatState := formatStateFlag
+	if actx.logOut.enabled(zerolog.TraceLevel) {
+		formatState = formatStateFlagHex
+	}
 
 	for stateReceived := range stateChan {
 		logger.Info().
-			Str("Cur state", formatStateFlag(stateReceived.CurrentState)).
-			Str("Evt state", formatStateFlag(stateReceived.EventState)).
+			Str("Cur state", formatState(stateReceived.CurrentState)).
+			Str("Evt state", formatState(stateReceived.EventState)).
 			Str("User data", fmt.Sprintf("%v", stateReceived.UserData)).
 			Msg("Signal received")
 
-		if stateReceived.EventState&scard.StatePresent != 0 {
-			switch v := stateReceived.UserData.(type) {
-			case *card:
-				logger.Debug().Str("UserData", fmt.Sprintf("%v", v)).Msg("Handling card")
-				if v != nil {
-					h.ServeCard(v)
-				}
-			default:
-				logger.Error().Str("UserData", fmt.Sprintf("%v", v)).Msg("Unahandled card data type")
-				return ErrUnhandledCardData
-			}
+		if actx.stateHandler != nil {
+			actx.stateHandler(newReaderState(stateReceived))
 		}
+
+		if r, ok := stateReceived.UserData.(cardRemoval); ok {
+			dispatchRemoval(r)
+			continue
+		}
+
+		if err := actx.dispatchState(ctx, stateReceived, dispatch); err != nil {
+			return err
+		}
+	}
+	return <-readErr
+}
+
+// cardData is the UserData of a reader state carrying a card to dispatch.
+// Any type implementing it can be passed from the read loop to the handler.
+type cardData interface {
+	Card
+	// resetValues clears the values attached by a previous dispatch
+	resetValues()
+	disconnect() error
+}
+
+// Dispatches the card data of a present reader state.  Present states without
+// card data, e.g. of skipped cards, are ignored.
+func (actx *Context) dispatchState(ctx context.Context, state scard.ReaderState, dispatch func(context.Context, cardData)) error {
+	var (
+		logger = actx.logger.With().Str("Caller", "Serve").Logger()
+	)
+	if state.EventState&scard.StatePresent == 0 {
+		return nil
+	}
+	switch v := state.UserData.(type) {
+	case nil:
+	case cardData:
+		logger.Debug().Str("UserData", fmt.Sprintf("%v", v)).Msg("Handling card")
+		dispatch(ctx, v)
+	default:
+		logger.Error().Str("UserData", fmt.Sprintf("%v", v)).Msg("Unahandled card data type")
+		return ErrUnhandledCardData
 	}
 	return nil
 }
 
+// Handles the card using the active handler, then disconnects it
+func (actx *Context) handle(ctx context.Context, c cardData) {
+	var (
+		logger = actx.logger.With().Str("Caller", "handle").Logger()
+	)
+	// Values are per read, a cached card is handled again
+	c.resetValues()
+	actx.publish(c)
+	if !actx.denyBlocked(c, logger) {
+		hctx, cancel := actx.handlerContext(ctx)
+		if err := serveCard(hctx, actx.activeHandler(), c); err != nil {
+			logger.Error().Err(err).Msg("Problem handling card")
+		}
+		cancel()
+	}
+	if err := c.disconnect(); err != nil {
+		logger.Error().Err(err).Msg("Problem disconnecting")
+	}
+}
+
+// Reports whether the UID of the card is blocked by WithUIDBlocklist, passing
+// the card to the WithDeniedCallback callback if so
+func (actx *Context) denyBlocked(c Card, logger zerolog.Logger) bool {
+	if !actx.blocklist.contains(c.UID()) {
+		return false
+	}
+	logger.Info().Hex("UID", c.UID()).Msg("Denied blocked UID")
+	if actx.denied != nil {
+		actx.denied(c)
+	}
+	return true
+}
+
+// Returns the capacity of the reader state channel
+func (actx *Context) stateBuffer() int {
+	if actx.inlineDispatch && actx.bufferSize > 0 {
+		return actx.bufferSize
+	}
+	return 1
+}
+
+// Returns the capacity of the dispatcher queue
+func (actx *Context) dispatchBuffer() int {
+	if actx.bufferSize > 0 {
+		return actx.bufferSize
+	}
+	return dispatchBuffer
+}
+
+// Returns the context passed to a ContextHandler for a card
+func (actx *Context) handlerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	actx.optionsMu.RLock()
+	timeout := actx.handlerTimeout
+	actx.optionsMu.RUnlock()
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Reports a reader error to the reader error handler, if any
+func (actx *Context) readerError(reader string, err error) {
+	actx.optionsMu.RLock()
+	fn := actx.readerErrorHandler
+	actx.optionsMu.RUnlock()
+	if fn != nil {
+		fn(reader, err)
+	}
+}
+
+// Reports a card error to the card error handler, if any
+func (actx *Context) cardError(reader string, err error) {
+	actx.optionsMu.RLock()
+	fn := actx.cardErrorHandler
+	actx.optionsMu.RUnlock()
+	if fn != nil {
+		fn(reader, err)
+	}
+}
+
+// SetHandler replaces the handler of a running Serve loop, the cards read
+// afterwards are handled by h. The middleware is applied to h anew.
+func (actx *Context) SetHandler(h Handler) {
+	h = chainMiddleware(h, actx.middleware...)
+	actx.handlerMu.Lock()
+	defer actx.handlerMu.Unlock()
+	actx.handler = h
+}
+
+// Returns the handler set by Serve or SetHandler
+func (actx *Context) activeHandler() Handler {
+	actx.handlerMu.Lock()
+	defer actx.handlerMu.Unlock()
+	return actx.handler
+}
+
+// Publishes the card to the sinks
+func (actx *Context) publish(c Card) {
+	e := newCardEvent(c)
+	for _, s := range actx.sinks {
+		if !s.publish(e) {
+			actx.logger.Warn().Str("UID", e.UID).Msg("Sink buffer full, dropping card event")
+		}
+	}
+}
+
 // Connects to the reader.  Needs to be called before waiting for state change.
+// Reuses the open connection to the reader with WithConnectionReuse.
 func (actx *Context) connect(reader string) (*card, error) {
-	sc, err := actx.context.Connect(reader,
-		scard.ShareMode(actx.shareMode),
-		scard.Protocol(actx.protocol),
+	if sc := actx.connections.take(reader); sc != nil {
+		c := actx.newCard(reader, sc)
+		c.pool = actx.connections
+		return c, nil
+	}
+	c, err := actx.connectWith(reader, actx.shareMode, actx.protocol)
+	if err != nil {
+		return nil, err
+	}
+	c.pool = actx.connections
+	return c, nil
+}
+
+// Connects to the reader using the share mode and protocol instead of the
+// configured ones
+func (actx *Context) connectWith(reader string, mode ShareMode, proto Protocol) (*card, error) {
+	sc, err := actx.pcsc().Connect(reader,
+		scard.ShareMode(mode),
+		scard.Protocol(proto),
 	)
 	if err != nil {
 		return nil, err
 	}
-	return newCard(reader, sc), nil
+	return actx.newCard(reader, sc), nil
+}
+
+// Creates a card for the connection using the configured card options
+func (actx *Context) newCard(reader string, sc PCSCCard) *card {
+	c := newCard(reader, sc)
+	c.uidLengths = actx.uidLengths
+	c.disposition = actx.disposition
+	c.uidCommand = actx.uidCommand
+	c.uidRetries = actx.uidRetries
+	c.readOnly = actx.readOnly
+	c.verifyWrites = actx.verifyWrites
+	return c
 }
 
-// Disconnects from the reader.  Needs to be called when exiting.
+// Disconnects from the reader.  Needs to be called once the card has been handled.
 func (actx *Context) disconnect(c *card) error {
-	err := c.scard.Disconnect(scard.ResetCard)
-	return err
+	return c.disconnect()
 }
 
 // Initializes a reader structure which will be populated by waitForStatusChange.
 func (actx *Context) initializeReaderState() []scard.ReaderState {
-	rs := make([]scard.ReaderState, len(actx.readers))
+	return initializeReaderState(actx.readers)
+}
+
+func initializeReaderState(readers []string) []scard.ReaderState {
+	rs := make([]scard.ReaderState, len(readers))
 	for i := range rs {
-		rs[i].Reader = actx.readers[i]
+		rs[i].Reader = readers[i]
 		rs[i].CurrentState = scard.StateUnaware
 	}
 	return rs
@@ -180,11 +1191,16 @@ func (actx *Context) initializeReaderState() []scard.ReaderState {
 // - `interruptDuration` configures how frequently the read will timeout and check for the channel close.
 func (actx *Context) waitForStatusChange(ctx context.Context, rs []scard.ReaderState, interruptDuration time.Duration) error {
 	var (
-		logger = log.With().Str("Caller", "waitForStatusChange").Logger()
+		logger = actx.logger.With().Str("Caller", "waitForStatusChange").Logger()
 	)
 	logger.Debug().Msg("Waiting for status to change")
 	for {
-		err := actx.context.GetStatusChange(rs, interruptDuration)
+		err := actx.pcsc().GetStatusChange(rs, interruptDuration)
+		beat(ctx)
+		now := actx.clock.Now()
+		actx.idle.check(now)
+		actx.runtime.check(now)
+		actx.connections.expire(now)
 		select {
 		case <-ctx.Done():
 			return ErrShutdown
@@ -209,10 +1225,22 @@ func (actx *Context) waitForStatusChange(ctx context.Context, rs []scard.ReaderS
 // Reads the data payload from the reader.  Meant to be called when the state changes to StatePresent.
 func (actx *Context) readCardData(state scard.ReaderState) (*card, error) {
 	var (
-		logger = log.With().Str("Caller", "readCardData").Logger()
+		logger = actx.logger.With().Str("Caller", "readCardData").Logger()
 	)
+	if len(actx.atrPrefixes) > 0 && len(state.Atr) > 0 && !hasATRPrefix(actx.atrPrefixes, state.Atr) {
+		logger.Info().Hex("ATR", state.Atr).Msg("Skipping card ATR")
+		return nil, nil
+	}
 	// Step 1: Connect
+	if actx.beforeConnect != nil {
+		if err := actx.beforeConnect(state.Reader); err != nil {
+			logger.Info().Err(err).Msg("Skipping card read")
+			actx.cardError(state.Reader, wrapError("readCardData before connect", err))
+			return nil, nil
+		}
+	}
 	logger.Debug().Msg("Connecting to reader")
+	start := time.Now()
 	c, err := actx.connect(state.Reader)
 	if err != nil {
 		err2 := wrapError("readCardData connect error", err)
@@ -227,47 +1255,261 @@ func (actx *Context) readCardData(state scard.ReaderState) (*card, error) {
 			return nil, err2
 		}
 	}
-	// Step 3 (defer): Disconnect when exiting
-	defer func() {
+	// Step 2: Read payload, the card stays connected until it has been handled
+	logger.Debug().Msg("Reading payload")
+	if c.uid, err = c.getUID(); err != nil {
+		logger.Error().Err(err).Msg("Problem reading UID")
 		logger.Debug().Msg("Disconnecting")
 		if err := actx.disconnect(c); err != nil {
 			logger.Error().Err(err).Msg("Problem disconnecting")
 		}
-	}()
-	// Step 2: Read payload
-	logger.Debug().Msg("Reading payload")
-	if c.uid, err = c.getUID(); err != nil {
-		fmt.Printf("Error: %v\n", err)
 		return nil, err
 	}
+	// Step 3: Skip cards of unwanted types
+	if len(actx.cardTypes) > 0 {
+		t, err := c.Type()
+		if err != nil || !containsCardType(actx.cardTypes, t) {
+			logger.Info().Err(err).Str("Type", t.String()).Msg("Skipping card type")
+			if err := actx.disconnect(c); err != nil {
+				logger.Error().Err(err).Msg("Problem disconnecting")
+			}
+			return nil, nil
+		}
+	}
+	// Step 4: Compute the identity of the card, falling back to the UID
+	cached := false
+	if actx.readCache != nil {
+		if c.identity, cached = actx.readCache.get(state.Reader, c.uid); cached {
+			logger.Debug().Msg("Using cached card identity")
+		}
+	}
+	if actx.identityFn != nil && !cached {
+		if c.identity, err = actx.identityFn(c); err != nil {
+			logger.Warn().Err(err).Msg("Problem computing card identity, using UID")
+			c.identity, err = "", nil
+		}
+	}
+	// Step 5: Negotiate the bit rate, staying at the default rate on failure
+	if err := c.negotiateBaudRate(actx.maxBaudRate); err != nil {
+		logger.Warn().Err(err).Str("BaudRate", actx.maxBaudRate.String()).Msg("Problem negotiating baud rate")
+	}
+	c.readDuration = time.Since(start)
+	c.readTime = actx.clock.Now()
+	logger.Debug().Dur("Duration", c.readDuration).Msg("Read payload")
+	if actx.readCache != nil && !cached && !c.UIDIsRandom() {
+		actx.readCache.put(state.Reader, c.uid, c.identity)
+	}
 	return c, err
 }
 
-func (actx *Context) read(ctx context.Context, results chan<- scard.ReaderState) {
+// Returns the readers polled by each read loop for the configured dispatch model
+func (actx *Context) readerLists() [][]string {
+	if actx.dispatchModel == DispatchPerReader {
+		return perReaderLists(actx.readers)
+	}
+	return [][]string{actx.readers}
+}
+
+// Returns a reader list per reader, polling each reader with its own loop
+func perReaderLists(readers []string) [][]string {
+	var readerLists [][]string
+	for _, r := range readers {
+		readerLists = append(readerLists, []string{r})
+	}
+	return readerLists
+}
+
+// Reads reader states and cards with a read loop per reader list until
+// ctx is done or reading fails, then closes results.
+// Returns the error that stopped the first read loop, nil if ctx is done.
+// With isolate a failing read loop only stops itself, and the errors of all
+// failed loops are returned as ReaderErrors.
+func (actx *Context) read(ctx context.Context, readerLists [][]string, isolate bool, results chan<- scard.ReaderState) error {
 	var (
-		logger = log.With().Str("Caller", "read").Logger()
-		rs     = actx.initializeReaderState()
-		err    error
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		errs         ReaderErrors
+		ctx2, cancel = context.WithCancel(ctx)
 	)
 	defer close(results)
+	defer cancel()
+	actx.idle.reset(actx.clock.Now())
+	for _, readers := range readerLists {
+		readers := readers
+		loopCtx, loopCancel := ctx2, cancel
+		if isolate {
+			loopCtx, loopCancel = context.WithCancel(ctx2)
+		}
+		// Stops the read loop, recording the error unless it already stopped
+		stop := func(err error) {
+			mu.Lock()
+			if err != nil && loopCtx.Err() == nil {
+				errs = append(errs, &ReaderError{Reader: strings.Join(readers, ", "), Err: err})
+			}
+			mu.Unlock()
+			loopCancel()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer stop(nil)
+			actx.watchedReadLoop(loopCtx, stop, readers, results)
+		}()
+	}
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	switch {
+	case len(errs) == 0:
+		return nil
+	case !isolate:
+		return errs[0].Err
+	default:
+		return errs
+	}
+}
+
+// Records a successful card read for the idle and cross reader conflict
+// tracking. removals is the number of removals from the reader before the
+// card was presented.
+func (actx *Context) cardRead(c *card, removals int) {
+	c.removals = removals
+	now := actx.clock.Now()
+	actx.idle.reset(now)
+	actx.removals.read(c)
+	if !c.UIDIsRandom() {
+		actx.conflicts.check(c.reader, c.uid, now)
+	}
+}
+
+// Waits until the minimum read interval of the reader has passed, returning
+// false if ctx is done first
+func (actx *Context) awaitReadInterval(ctx context.Context, reader string) bool {
+	actx.optionsMu.RLock()
+	throttle := actx.throttle
+	actx.optionsMu.RUnlock()
+	d := throttle.delay(reader, actx.clock.Now())
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Polls the readers with a single GetStatusChange loop, sending state changes
+// to results.  Cards are read inline, or in a goroutine for DispatchSingleLoopAsync.
+func (actx *Context) readLoop(ctx context.Context, stop func(error), readers []string, results chan<- scard.ReaderState) {
+	var (
+		logger = actx.logger.With().Str("Caller", "read").Logger()
+		rs     = initializeReaderState(readers)
+		reads  sync.WaitGroup
+		errs   = loopErrors{generation: actx.contextGeneration()}
+		err    error
+	)
+	defer reads.Wait()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
-		err = actx.waitForStatusChange(ctx, rs, time.Second)
+		err = actx.waitForStatusChange(ctx, rs, pollInterval)
 		if err != nil {
-			return
+			if err == ErrShutdown {
+				return
+			}
+			logger.Error().Err(err).Msg("Problem waiting for status change")
+			for _, r := range readers {
+				actx.readerError(r, err)
+			}
+			retry, changed := actx.loopError(ctx, &errs)
+			if !retry {
+				stop(err)
+				return
+			}
+			if changed {
+				rs = initializeReaderState(readers)
+			}
+			continue
 		}
 		for i := range rs {
 			if rs[i].EventState != rs[i].CurrentState {
 				if rs[i].EventState&scard.StatePresent != 0 {
 					logger.Debug().Msg("Card present")
-					rs[i].UserData, err = actx.readCardData(rs[i])
+					if actx.sleep.asleep(rs[i].Reader) {
+						logger.Debug().Str("Reader", rs[i].Reader).Msg("Reader asleep, not reading card")
+						rs[i].CurrentState = rs[i].EventState
+						continue
+					}
+					if actx.dispatchModel == DispatchSingleLoopAsync {
+						reads.Add(1)
+						removals := actx.removals.count(rs[i].Reader)
+						go func(state scard.ReaderState) {
+							defer reads.Done()
+							if !actx.awaitReadInterval(ctx, state.Reader) {
+								return
+							}
+							c, err := actx.readCardData(state)
+							if err != nil {
+								logger.Error().Err(err).Msg("Problem reading card data")
+								actx.cardError(state.Reader, err)
+								if actx.maxErrors == 0 && !actx.resilient {
+									stop(err)
+								}
+								return
+							}
+							if c != nil {
+								actx.cardRead(c, removals)
+								state.UserData = c
+							}
+							results <- state
+						}(rs[i])
+						rs[i].CurrentState = rs[i].EventState
+						continue
+					}
+					if !actx.awaitReadInterval(ctx, rs[i].Reader) {
+						return
+					}
+					c, err := actx.readCardData(rs[i])
 					if err != nil {
 						logger.Error().Err(err).Msg("Problem reading card data")
-						return
+						actx.cardError(rs[i].Reader, err)
+						retry, changed := actx.loopError(ctx, &errs)
+						if !retry {
+							stop(err)
+							return
+						}
+						if changed {
+							rs = initializeReaderState(readers)
+							break
+						}
+						rs[i].CurrentState = rs[i].EventState
+						continue
+					}
+					if errs.count > 0 {
+						errs.count = 0
+						actx.loopBackoff().Reset()
+					}
+					if c != nil {
+						actx.cardRead(c, actx.removals.count(rs[i].Reader))
+						rs[i].UserData = c
+					}
+				} else {
+					if actx.readCache != nil {
+						actx.readCache.clear(rs[i].Reader)
+					}
+					actx.conflicts.remove(rs[i].Reader)
+					actx.connections.drop(rs[i].Reader)
+					if rs[i].CurrentState&scard.StatePresent != 0 {
+						rs[i].UserData = actx.removals.removed(rs[i].Reader)
 					}
 				}
 				results <- rs[i]
@@ -1,17 +1,23 @@
 package acr122u
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ebfe/scard"
+	"github.com/rs/zerolog"
 )
 
 func TestEstablishContext(t *testing.T) {
 	t.Run("Error", func(t *testing.T) {
-		scardEstablishContext = func() (*scard.Context, error) {
+		scardEstablishContext = func(Scope) (*scard.Context, error) {
 			return nil, scard.ErrInternalError
 		}
 
@@ -20,8 +26,19 @@ func TestEstablishContext(t *testing.T) {
 		}
 	})
 
+	t.Run("Service unavailable", func(t *testing.T) {
+		scardEstablishContext = func(Scope) (*scard.Context, error) {
+			return nil, scard.ErrNoService
+		}
+
+		_, err := EstablishContext()
+		if !errors.Is(err, ErrPCSCUnavailable) || !errors.Is(err, ErrNoService) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
 	t.Run("OK", func(t *testing.T) {
-		scardEstablishContext = func() (*scard.Context, error) {
+		scardEstablishContext = func(Scope) (*scard.Context, error) {
 			return &scard.Context{}, nil
 		}
 
@@ -31,6 +48,93 @@ func TestEstablishContext(t *testing.T) {
 	})
 }
 
+func TestEstablishContextScope(t *testing.T) {
+	defer func(f func(Scope) (*scard.Context, error)) {
+		scardEstablishContext = f
+	}(scardEstablishContext)
+
+	for _, tc := range []struct {
+		name    string
+		options []Option
+		want    Scope
+	}{
+		{"Default", nil, ScopeSystem},
+		{"User", []Option{WithEstablishScope(ScopeUser)}, ScopeUser},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Scope
+
+			scardEstablishContext = func(scope Scope) (*scard.Context, error) {
+				got = scope
+				return nil, scard.ErrInternalError
+			}
+
+			if _, err := EstablishContext(tc.options...); err != scard.ErrInternalError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("scope = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEstablishContextWithRetry(t *testing.T) {
+	defer func(f func(Scope) (PCSCContext, error)) {
+		establishPCSCContext = f
+	}(establishPCSCContext)
+
+	backoff := BackoffConfig{Initial: time.Millisecond, Max: time.Millisecond}
+
+	t.Run("Eventual success", func(t *testing.T) {
+		var attempts int
+
+		establishPCSCContext = func(Scope) (PCSCContext, error) {
+			if attempts++; attempts <= 2 {
+				return nil, scard.ErrNoService
+			}
+			return &mockContext{}, nil
+		}
+
+		if _, err := EstablishContextWithRetry(context.Background(), backoff); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if attempts != 3 {
+			t.Fatalf("attempts = %d, want 3", attempts)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		establishPCSCContext = func(Scope) (PCSCContext, error) {
+			cancel()
+			return nil, scard.ErrNoService
+		}
+
+		_, err := EstablishContextWithRetry(ctx, backoff)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Invalid option", func(t *testing.T) {
+		var attempts int
+
+		establishPCSCContext = func(Scope) (PCSCContext, error) {
+			attempts++
+			return &mockContext{}, nil
+		}
+
+		_, err := EstablishContextWithRetry(context.Background(), backoff, WithUIDCommand([]byte{0xFF}))
+		if !errors.Is(err, ErrInvalidParameter) || attempts != 1 {
+			t.Fatalf("got = %v after %d attempts, want %v after 1", err, attempts, ErrInvalidParameter)
+		}
+	})
+}
+
 func TestNewContext(t *testing.T) {
 	t.Run("Error from IsValid", func(t *testing.T) {
 		_, err := newContext(&mockContext{
@@ -83,6 +187,63 @@ func TestNewContext(t *testing.T) {
 	})
 }
 
+func TestNewContextDedupeReaders(t *testing.T) {
+	actx, err := newContext(&mockContext{
+		listReaders: func() ([]string, error) {
+			return []string{
+				"ACS ACR122U SAM Interface 00 01",
+				"ACS ACR122U PICC Interface 00 00",
+				"ACS ACR122U PICC Interface 00 00",
+			}, nil
+		},
+	}, WithDedupeReaders())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := actx.Readers(), []string{"ACS ACR122U PICC Interface 00 00"}; !stringsEqual(got, want) {
+		t.Fatalf("actx.Readers() = %q, want %q", got, want)
+	}
+}
+
+func TestNewContextLogOptions(t *testing.T) {
+	var buf bytes.Buffer
+
+	actx, err := newContext(&mockContext{},
+		WithLogWriter(zerolog.ConsoleWriter{Out: &buf}),
+		WithLogLevel(LogInfo),
+		WithLogTimeFormat(time.RFC3339),
+		WithLogNoColor(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cw, ok := actx.logWriter.(zerolog.ConsoleWriter)
+	if !ok {
+		t.Fatalf("actx.logWriter = %T, want zerolog.ConsoleWriter", actx.logWriter)
+	}
+
+	if got, want := cw.TimeFormat, time.RFC3339; got != want {
+		t.Fatalf("cw.TimeFormat = %q, want %q", got, want)
+	}
+
+	if !cw.NoColor {
+		t.Fatalf("cw.NoColor = false, want true")
+	}
+
+	actx.logger.Debug().Msg("hidden")
+	actx.logger.Info().Msg("visible")
+
+	if got := buf.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "visible") {
+		t.Fatalf("unexpected log output: %q", got)
+	}
+
+	if got := buf.String(); strings.Contains(got, "\x1b[") {
+		t.Fatalf("log output contains colors: %q", got)
+	}
+}
+
 func TestContextRelease(t *testing.T) {
 	t.Run("Error from Release", func(t *testing.T) {
 		actx, err := newContext(&mockContext{
@@ -136,6 +297,211 @@ func TestContextConnect(t *testing.T) {
 	})
 }
 
+func TestContextConnectDisposition(t *testing.T) {
+	var got scard.Disposition
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return &mockCard{disconnect: func(d scard.Disposition) error {
+				got = d
+				return nil
+			}}, nil
+		},
+	}, WithDisposition(LeaveCard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := actx.connect("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.disconnect(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := scard.LeaveCard; got != want {
+		t.Fatalf("disposition = %v, want %v", got, want)
+	}
+}
+
+func TestContextReadOnly(t *testing.T) {
+	var sent [][]byte
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return &mockCard{
+				transmit: func(cmd []byte) ([]byte, error) {
+					sent = append(sent, cmd)
+					return append(make([]byte, 16), rcOperationSuccess...), nil
+				},
+				status: atrStatus(atrMifareUltralight),
+			}, nil
+		},
+	}, WithReadOnly())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := actx.connect("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		write func() error
+	}{
+		{"WriteBlock", func() error { return c.WriteBlock(4, make([]byte, 16)) }},
+		{"StoreData", func() error { return c.StoreData([]byte{0x01}, testKey, KeyA) }},
+		{"WritePage", func() error { return c.WritePage(4, make([]byte, 4)) }},
+		{"WriteNDEF", func() error { return c.WriteNDEF(testNDEFRecords[:1]) }},
+		{"LockPages", func() error { return c.LockPages(4, 15, true) }},
+		{"SetCCLocked", func() error { return c.SetCCLocked(true) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.write(); !errors.Is(err, ErrReadOnlyMode) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if len(sent) != 0 {
+		t.Fatalf("sent = %X, want nothing", sent)
+	}
+
+	if _, err := c.ReadPage(4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestContextVerifyWrites(t *testing.T) {
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return &mockCard{transmit: func(cmd []byte) ([]byte, error) {
+				// Writes succeed but read back as zeros
+				return append(make([]byte, 16), rcOperationSuccess...), nil
+			}}, nil
+		},
+	}, WithVerifyWrites())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := actx.connect("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.WritePage(4, []byte{0x01, 0x02, 0x03, 0x04}); !errors.Is(err, ErrWriteVerifyFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestContextReserve(t *testing.T) {
+	var (
+		reserved bool
+		modes    []scard.ShareMode
+	)
+
+	actx, err := newContext(&mockContext{
+		connect: func(reader string, mode scard.ShareMode, proto scard.Protocol) (PCSCCard, error) {
+			modes = append(modes, mode)
+			if reserved {
+				return nil, scard.ErrSharingViolation
+			}
+			reserved = true
+
+			return &mockCard{disconnect: func(scard.Disposition) error {
+				reserved = false
+				return nil
+			}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	release, err := actx.Reserve("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := actx.Reserve("Test"); !errors.Is(err, ErrReaderBusy) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	release()
+	release()
+
+	release, err = actx.Reserve("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	for _, m := range modes {
+		if m != scard.ShareExclusive {
+			t.Fatalf("mode = %v, want %v", m, scard.ShareExclusive)
+		}
+	}
+}
+
+func TestContextConnectExclusive(t *testing.T) {
+	var (
+		mode        scard.ShareMode
+		disposition scard.Disposition
+	)
+
+	actx, err := newContext(&mockContext{
+		connect: func(reader string, m scard.ShareMode, proto scard.Protocol) (PCSCCard, error) {
+			mode = m
+			return &mockCard{
+				transmit: uidTransmit,
+				disconnect: func(d scard.Disposition) error {
+					disposition = d
+					return nil
+				},
+			}, nil
+		},
+	}, WithShareMode(ShareShared))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := actx.ConnectExclusive("Test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mode != scard.ShareExclusive {
+		t.Fatalf("mode = %v, want %v", mode, scard.ShareExclusive)
+	}
+
+	if !bytes.Equal(c.UID(), testUID) {
+		t.Fatalf("c.UID() = %X, want %X", c.UID(), testUID)
+	}
+
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if disposition != scard.ResetCard {
+		t.Fatalf("disposition = %v, want %v", disposition, scard.ResetCard)
+	}
+
+	actx.context = &mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return nil, scard.ErrSharingViolation
+		},
+	}
+
+	if _, err := actx.ConnectExclusive("Test"); !errors.Is(err, ErrReaderBusy) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestContextWaitForStatusChange(t *testing.T) {
 	t.Run("Error from GetStatusChange", func(t *testing.T) {
 		actx, err := newContext(&mockContext{
@@ -174,68 +540,1589 @@ func TestContextWaitForStatusChange(t *testing.T) {
 	})
 }
 
-type mockContext struct {
-	release         func() error
-	isValid         func() (bool, error)
-	listReaders     func() ([]string, error)
-	connect         func(string, scard.ShareMode, scard.Protocol) (*scard.Card, error)
-	getStatusChange func([]scard.ReaderState, time.Duration) error
-}
+func TestContextReadCardDataDuration(t *testing.T) {
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			time.Sleep(5 * time.Millisecond)
+			return &mockCard{transmit: uidTransmit}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
-func (ctx *mockContext) Release() error {
-	if ctx.release != nil {
-		return ctx.release()
+	c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	return nil
+	if got, want := c.ReadDuration(), 5*time.Millisecond; got < want {
+		t.Fatalf("c.ReadDuration() = %v, want at least %v", got, want)
+	}
 }
 
-func (ctx *mockContext) IsValid() (bool, error) {
-	if ctx.isValid != nil {
-		return ctx.isValid()
+func TestContextBeforeConnect(t *testing.T) {
+	var (
+		calls    []string
+		hookErr  error
+		cardErrs []error
+	)
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			calls = append(calls, "connect")
+			return &mockCard{transmit: uidTransmit}, nil
+		},
+	}, WithBeforeConnect(func(reader string) error {
+		calls = append(calls, "before "+reader)
+		return hookErr
+	}), WithCardErrorHandler(func(reader string, err error) {
+		cardErrs = append(cardErrs, err)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	return true, nil
+	t.Run("Called before connect", func(t *testing.T) {
+		calls = nil
+
+		c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c == nil {
+			t.Fatalf("card not read")
+		}
+
+		if want := []string{"before Test", "connect"}; !stringsEqual(calls, want) {
+			t.Fatalf("calls = %q, want %q", calls, want)
+		}
+	})
+
+	t.Run("Error skips read", func(t *testing.T) {
+		calls, hookErr = nil, errors.New("relay")
+
+		c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c != nil {
+			t.Fatalf("card read")
+		}
+
+		if want := []string{"before Test"}; !stringsEqual(calls, want) {
+			t.Fatalf("calls = %q, want %q", calls, want)
+		}
+		if len(cardErrs) != 1 || !errors.Is(cardErrs[0], hookErr) {
+			t.Fatalf("card errors = %v, want [%v]", cardErrs, hookErr)
+		}
+	})
+
+	t.Run("Not called for direct connects", func(t *testing.T) {
+		calls, hookErr = nil, nil
+
+		if _, err := actx.ConnectExclusive("Test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := []string{"connect"}; !stringsEqual(calls, want) {
+			t.Fatalf("calls = %q, want %q", calls, want)
+		}
+	})
 }
 
-func (ctx *mockContext) ListReaders() ([]string, error) {
-	if ctx.listReaders != nil {
-		return ctx.listReaders()
+func TestContextATRPrefixFilter(t *testing.T) {
+	var connects int
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			connects++
+			return &mockCard{transmit: uidTransmit}, nil
+		},
+	}, WithATRPrefixFilter(atrMifareUltralight[:15], atrISODEP[:2]))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	return []string{"Test"}, nil
-}
+	for _, tc := range []struct {
+		name     string
+		atr      []byte
+		connects int
+	}{
+		{"Matching", atrMifareUltralight, 1},
+		{"Other prefix", atrISODEP, 1},
+		{"Not matching", atrMifareClassic1K, 0},
+		{"No ATR", nil, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			connects = 0
 
-func (ctx *mockContext) Connect(reader string, shareMode scard.ShareMode, protocol scard.Protocol) (*scard.Card, error) {
-	if ctx.connect != nil {
-		return ctx.connect(reader, shareMode, protocol)
+			c, err := actx.readCardData(scard.ReaderState{Reader: "Test", Atr: tc.atr})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if connects != tc.connects {
+				t.Fatalf("connects = %d, want %d", connects, tc.connects)
+			}
+			if (c != nil) != (tc.connects > 0) {
+				t.Fatalf("card read = %v, want %v", c != nil, tc.connects > 0)
+			}
+		})
 	}
 
-	return &scard.Card{}, nil
+	if _, err := newContext(&mockContext{}, WithATRPrefixFilter([]byte{})); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
 }
 
-func (ctx *mockContext) GetStatusChange(rs []scard.ReaderState, timeout time.Duration) error {
-	if ctx.getStatusChange != nil {
-		return ctx.getStatusChange(rs, timeout)
+func TestContextUIDCommand(t *testing.T) {
+	cmd := []byte{0xFF, 0xCA, 0x01, 0x00, 0x00}
+
+	t.Run("Custom command", func(t *testing.T) {
+		var sent [][]byte
+
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				return &mockCard{transmit: func(b []byte) ([]byte, error) {
+					sent = append(sent, b)
+					return uidTransmit(b)
+				}}, nil
+			},
+		}, WithUIDCommand(cmd))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := actx.readCardData(scard.ReaderState{Reader: "Test"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(sent) != 1 || !bytes.Equal(sent[0], cmd) {
+			t.Fatalf("sent = %X, want [%X]", sent, cmd)
+		}
+	})
+
+	t.Run("Too short", func(t *testing.T) {
+		if _, err := newContext(&mockContext{}, WithUIDCommand(cmd[:3])); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestContextUIDRetries(t *testing.T) {
+	t.Run("Retries", func(t *testing.T) {
+		var calls int
+
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				return &mockCard{transmit: func(b []byte) ([]byte, error) {
+					if calls++; calls <= 2 {
+						return rcOperationFailed, nil
+					}
+					return uidTransmit(b)
+				}}, nil
+			},
+		}, WithUIDRetries(2), WithUIDCommand(cmdGetUID))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(c.UID(), testUID) {
+			t.Fatalf("c.UID() = %X, want %X", c.UID(), testUID)
+		}
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		if _, err := newContext(&mockContext{}, WithUIDRetries(-1)); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestContextCardTypeFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		atr  []byte
+		want bool
+	}{
+		{"MIFARE Classic", atrMifareClassic1K, true},
+		{"NTAG", atrMifareUltralight, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var disconnected bool
+
+			actx, err := newContext(&mockContext{
+				connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+					return &mockCard{
+						transmit: uidTransmit,
+						status:   atrStatus(tc.atr),
+						disconnect: func(scard.Disposition) error {
+							disconnected = true
+							return nil
+						},
+					}, nil
+				},
+			}, WithCardTypeFilter(CardTypeMifareClassic1K, CardTypeMifareClassic4K))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			c, err := actx.readCardData(scard.ReaderState{Reader: "Test"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := c != nil; got != tc.want {
+				t.Fatalf("dispatched = %v, want %v", got, tc.want)
+			}
+
+			if disconnected == tc.want {
+				t.Fatalf("disconnected = %v, want %v", disconnected, !tc.want)
+			}
+		})
 	}
+}
 
-	for i := range rs {
-		rs[i].EventState = scard.StatePresent
+func TestContextReadCardDataCache(t *testing.T) {
+	var (
+		connects   int
+		identities int
+		uid        = testUID
+	)
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			connects++
+			return &mockCard{transmit: func([]byte) ([]byte, error) {
+				return append(append([]byte{}, uid...), 0x90, 0x00), nil
+			}}, nil
+		},
+	}, WithReadCache(time.Minute), WithIdentityFunc(func(c Card) (string, error) {
+		identities++
+		return fmt.Sprintf("%X", c.UID()), nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	return nil
-}
+	state := scard.ReaderState{Reader: "Test", Atr: []byte{0x3B, 0x8F}}
 
-func getStatusChangeFunc(sf scard.StateFlag) func([]scard.ReaderState, time.Duration) error {
-	return func(rs []scard.ReaderState, timeout time.Duration) error {
-		for i := range rs {
-			rs[i].EventState = sf
+	read := func() *card {
+		t.Helper()
+		c, err := actx.readCardData(state)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.disconnected {
+			t.Fatalf("card is disconnected")
 		}
+		return c
+	}
 
-		return nil
+	for i := 0; i < 2; i++ {
+		if got, want := read().identity, fmt.Sprintf("%X", testUID); got != want {
+			t.Fatalf("c.identity = %q, want %q", got, want)
+		}
+	}
+
+	if got, want := identities, 1; got != want {
+		t.Fatalf("identities = %d, want %d", got, want)
+	}
+
+	// A card swapped for another with the same ATR is read again
+	uid = []byte{0x04, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+
+	if got, want := read().identity, fmt.Sprintf("%X", uid); got != want {
+		t.Fatalf("c.identity = %q, want %q", got, want)
+	}
+
+	if got, want := identities, 2; got != want {
+		t.Fatalf("identities = %d, want %d", got, want)
+	}
+
+	actx.readCache.clear("Test")
+	read()
+
+	if got, want := identities, 3; got != want {
+		t.Fatalf("identities = %d, want %d", got, want)
+	}
+
+	actx.readCache.clear("Test")
+	uid = []byte{0x08, 0x11, 0x22, 0x33}
+
+	for i := 0; i < 2; i++ {
+		read()
+	}
+
+	if got, want := identities, 5; got != want {
+		t.Fatalf("identities = %d, want %d (random UIDs are not cached)", got, want)
+	}
+
+	if got, want := connects, 6; got != want {
+		t.Fatalf("connects = %d, want %d", got, want)
 	}
 }
 
+func TestContextPing(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		ctx     *mockContext
+		options []Option
+		want    error
+	}{
+		{
+			name: "OK",
+			ctx:  &mockContext{},
+		},
+		{
+			name: "Firmware",
+			ctx: &mockContext{
+				connect: escapeConnect(t, func([]byte) []byte {
+					return []byte{0xD5, 0x03, 0x32, 0x01, 0x06, 0x07, 0x90, 0x00}
+				}),
+			},
+			options: []Option{WithPingFirmware()},
+		},
+		{
+			name: "Firmware failed",
+			ctx: &mockContext{
+				connect: escapeConnect(t, func([]byte) []byte {
+					return rcOperationFailed
+				}),
+			},
+			options: []Option{WithPingFirmware()},
+			want:    ErrOperationFailed,
+		},
+		{
+			name: "Invalid context",
+			ctx: &mockContext{
+				isValid: func() (bool, error) {
+					return false, scard.ErrInvalidHandle
+				},
+			},
+			want: ErrInvalidHandle,
+		},
+		{
+			name: "Service stopped",
+			ctx: &mockContext{
+				isValid: func() (bool, error) {
+					return false, scard.ErrServiceStopped
+				},
+			},
+			want: ErrPCSCUnavailable,
+		},
+		{
+			name: "No readers",
+			ctx: &mockContext{
+				listReaders: func() ([]string, error) {
+					return nil, nil
+				},
+			},
+			want: ErrNoReadersAvailable,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actx, err := newContext(&mockContext{}, tc.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actx.context = tc.ctx
+
+			if err := actx.Ping(); !errors.Is(err, tc.want) {
+				t.Fatalf("got = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestContextServeMiddleware(t *testing.T) {
+	var calls []string
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent),
+	}, WithMiddleware(func(next Handler) Handler {
+		return HandlerFunc(func(c Card) {
+			calls = append(calls, "middleware")
+			next.ServeCard(c)
+		})
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		calls = append(calls, "handler")
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"middleware", "handler"}; !stringsEqual(calls, want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestContextServeStateLog(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		level LogLevel
+		want  string
+		hex   bool
+	}{
+		{"Info", LogInfo, `"Evt state":"StatePresent"`, false},
+		{"Trace", LogTrace, `"Evt state":"0x0020 (StatePresent)"`, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf syncBuffer
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			actx, err := newContext(&mockContext{
+				connect:         uidConnect,
+				getStatusChange: statusSequence(scard.StatePresent),
+			}, WithLogWriter(&buf), WithLogLevel(tc.level))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := actx.ServeFunc(ctx, func(Card) { cancel() }); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(buf.String(), tc.want) {
+				t.Fatalf("log = %s, want %s", buf.String(), tc.want)
+			}
+			if got := strings.Contains(buf.String(), `state":"0x`); got != tc.hex {
+				t.Fatalf("hex logged = %v, want %v", got, tc.hex)
+			}
+		})
+	}
+}
+
+func TestContextServeMinReadInterval(t *testing.T) {
+	var reads []time.Time
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := 20 * time.Millisecond
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			reads = append(reads, time.Now())
+			return &mockCard{transmit: uidTransmit}, nil
+		},
+		getStatusChange: statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent, scard.StateEmpty, scard.StatePresent),
+	}, WithClock(newMockClock()), WithMinReadInterval(interval), WithInlineDispatch())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		if len(reads) == 3 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reads) != 3 {
+		t.Fatalf("reads = %d, want 3", len(reads))
+	}
+	for i := 1; i < len(reads); i++ {
+		if got := reads[i].Sub(reads[i-1]); got < interval {
+			t.Fatalf("read %d after %v, want at least %v", i, got, interval)
+		}
+	}
+}
+
+func TestContextServeMaxRuntime(t *testing.T) {
+	t.Run("Runtime passed", func(t *testing.T) {
+		var polls int
+
+		clock := newMockClock()
+
+		actx, err := newContext(&mockContext{
+			getStatusChange: func([]scard.ReaderState, time.Duration) error {
+				polls++
+				clock.Advance(time.Second)
+				return scard.ErrTimeout
+			},
+		}, WithClock(clock), WithMaxRuntime(5*time.Second))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := actx.ServeFunc(context.Background(), func(Card) {}); !errors.Is(err, ErrMaxRuntimeReached) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if polls != 5 {
+			t.Fatalf("polls = %d, want 5", polls)
+		}
+	})
+
+	t.Run("Context done first", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		clock := newMockClock()
+
+		actx, err := newContext(&mockContext{
+			getStatusChange: func([]scard.ReaderState, time.Duration) error {
+				clock.Advance(time.Second)
+				cancel()
+				return scard.ErrTimeout
+			},
+		}, WithClock(clock), WithMaxRuntime(time.Hour))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := actx.ServeFunc(ctx, func(Card) {}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Real time", func(t *testing.T) {
+		actx, err := newContext(&mockContext{
+			getStatusChange: func([]scard.ReaderState, time.Duration) error {
+				time.Sleep(time.Millisecond)
+				return scard.ErrTimeout
+			},
+		}, WithClock(newMockClock()), WithMaxRuntime(20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := actx.ServeFunc(context.Background(), func(Card) {}); !errors.Is(err, ErrMaxRuntimeReached) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		if _, err := newContext(&mockContext{}, WithMaxRuntime(-time.Second)); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestContextSetHandler(t *testing.T) {
+	var calls []string
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent),
+	}, WithMiddleware(func(next Handler) Handler {
+		return HandlerFunc(func(c Card) {
+			calls = append(calls, "middleware")
+			next.ServeCard(c)
+		})
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		calls = append(calls, "old")
+		actx.SetHandler(HandlerFunc(func(c Card) {
+			calls = append(calls, "new")
+			cancel()
+		}))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"middleware", "old", "middleware", "new"}; !stringsEqual(calls, want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestContextServeOrderedBuffer(t *testing.T) {
+	const taps = 10
+
+	var (
+		states   []scard.StateFlag
+		connects int32
+		got      []byte
+		release  = make(chan struct{})
+	)
+
+	for i := 0; i < taps; i++ {
+		states = append(states, scard.StatePresent, scard.StateEmpty)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			uid := byte(atomic.AddInt32(&connects, 1))
+			return &mockCard{transmit: func([]byte) ([]byte, error) {
+				return []byte{uid, 0x00, 0x00, 0x00, 0x90, 0x00}, nil
+			}}, nil
+		},
+		getStatusChange: statusSequence(states...),
+	}, WithOrderedBuffer(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		n := atomic.LoadInt32(&connects)
+		time.Sleep(50 * time.Millisecond)
+		if m := atomic.LoadInt32(&connects); m != n || m >= taps {
+			t.Errorf("connects = %d then %d, want reading blocked", n, m)
+		}
+		close(release)
+	}()
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		<-release
+		if got = append(got, c.UID()[0]); len(got) == taps {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, uid := range got {
+		if uid != byte(i+1) {
+			t.Fatalf("got = %X, want cards in tap order", got)
+		}
+	}
+
+	if _, err := newContext(&mockContext{}, WithOrderedBuffer(0)); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestContextServeDispatcher(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []Option
+		want    int32
+	}{
+		{"Dispatcher", nil, 3},
+		{"Inline", []Option{WithInlineDispatch()}, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				connects int32
+				handled  int
+				release  = make(chan struct{})
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			actx, err := newContext(&mockContext{
+				connect: func(reader string, m scard.ShareMode, p scard.Protocol) (PCSCCard, error) {
+					atomic.AddInt32(&connects, 1)
+					return uidConnect(reader, m, p)
+				},
+				getStatusChange: statusSequence(
+					scard.StatePresent, scard.StateEmpty,
+					scard.StatePresent, scard.StateEmpty,
+					scard.StatePresent, scard.StateEmpty,
+				),
+			}, tc.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			go func() {
+				for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&connects) < tc.want && time.Now().Before(deadline); {
+					time.Sleep(time.Millisecond)
+				}
+				time.Sleep(20 * time.Millisecond)
+				if got := atomic.LoadInt32(&connects); got != tc.want {
+					t.Errorf("connects = %d while handling the first card, want %d", got, tc.want)
+				}
+				close(release)
+			}()
+
+			err = actx.ServeFunc(ctx, func(c Card) {
+				<-release
+				if handled++; handled == 3 {
+					cancel()
+				}
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// customCardData is a card data payload other than *card
+type customCardData struct {
+	*card
+	payload string
+}
+
+func TestContextDispatchState(t *testing.T) {
+	actx, err := newContext(&mockContext{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []cardData
+
+	dispatch := func(ctx context.Context, c cardData) {
+		got = append(got, c)
+	}
+
+	custom := &customCardData{card: newCard("Test", &mockCard{}), payload: "custom"}
+
+	for _, tc := range []struct {
+		name  string
+		state scard.ReaderState
+		err   error
+	}{
+		{"Custom", scard.ReaderState{EventState: scard.StatePresent, UserData: custom}, nil},
+		{"No card", scard.ReaderState{EventState: scard.StatePresent}, nil},
+		{"Empty", scard.ReaderState{EventState: scard.StateEmpty, UserData: "ignored"}, nil},
+		{"Unhandled", scard.ReaderState{EventState: scard.StatePresent, UserData: "unhandled"}, ErrUnhandledCardData},
+	} {
+		if err := actx.dispatchState(context.Background(), tc.state, dispatch); err != tc.err {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+
+	if len(got) != 1 || got[0].(*customCardData).payload != "custom" {
+		t.Fatalf("dispatched = %v, want [%v]", got, custom)
+	}
+}
+
+func TestContextServeCardValues(t *testing.T) {
+	type key string
+
+	var got []any
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent),
+	}, WithMiddleware(func(next Handler) Handler {
+		return HandlerFunc(func(c Card) {
+			next.ServeCard(c.WithValue(key("user"), "alice"))
+		})
+	}, func(next Handler) Handler {
+		return HandlerFunc(func(c Card) {
+			got = append(got, c.Value(key("user")))
+			next.ServeCard(c.WithValue(key("role"), "admin"))
+		})
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		got = append(got, c.Value(key("user")), c.Value(key("role")), c.Value(key("missing")))
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []any{"alice", "alice", "admin", nil}
+	if len(got) != len(want) {
+		t.Fatalf("got = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestContextServeContextHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []Option
+		want    error
+	}{
+		{"Serve cancelled", nil, context.Canceled},
+		{"Handler timeout", []Option{WithHandlerTimeout(10 * time.Millisecond)}, context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got error
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			actx, err := newContext(&mockContext{
+				connect:         uidConnect,
+				getStatusChange: statusSequence(scard.StatePresent),
+			}, tc.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = actx.Serve(ctx, ContextHandlerFunc(func(hctx context.Context, c Card) error {
+				if tc.want == context.Canceled {
+					cancel()
+				}
+
+				select {
+				case <-hctx.Done():
+					got = hctx.Err()
+				case <-time.After(time.Second):
+					t.Errorf("handler context was not cancelled")
+				}
+
+				cancel()
+
+				return got
+			}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("got = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestContextServeDispatchModels(t *testing.T) {
+	readers := []string{"r1", "r2"}
+
+	for _, tc := range []struct {
+		name    string
+		model   DispatchModel
+		signals int
+		perCall int
+	}{
+		{"Single loop", DispatchSingleLoop, 1, 2},
+		{"Single loop async", DispatchSingleLoopAsync, 1, 2},
+		{"Per reader", DispatchPerReader, 2, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				signals int
+				present = map[string]bool{}
+				served  = map[string]bool{}
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			actx, err := newContext(&mockContext{
+				listReaders: func() ([]string, error) {
+					return readers, nil
+				},
+				connect: uidConnect,
+				getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if present[rs[0].Reader] {
+						time.Sleep(time.Millisecond)
+						return scard.ErrTimeout
+					}
+
+					if got := len(rs); got != tc.perCall {
+						t.Errorf("len(rs) = %d, want %d", got, tc.perCall)
+					}
+
+					for i := range rs {
+						present[rs[i].Reader] = true
+						rs[i].EventState = scard.StatePresent
+					}
+					signals++
+
+					return nil
+				},
+			}, WithDispatchModel(tc.model))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = actx.ServeFunc(ctx, func(c Card) {
+				if served[c.Reader()] = true; len(served) == len(readers) {
+					cancel()
+				}
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(served) != len(readers) {
+				t.Fatalf("served = %v, want all of %q", served, readers)
+			}
+
+			if signals != tc.signals {
+				t.Fatalf("signals = %d, want %d", signals, tc.signals)
+			}
+		})
+	}
+}
+
+func TestContextServeMap(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		present = map[string]bool{}
+		polled  = map[string]bool{}
+		served  = map[string][]string{}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		listReaders: func() ([]string, error) {
+			return []string{"r1", "r2", "r3"}, nil
+		},
+		connect: uidConnect,
+		getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(rs) != 1 {
+				t.Errorf("len(rs) = %d, want 1", len(rs))
+			}
+
+			polled[rs[0].Reader] = true
+			if present[rs[0].Reader] {
+				time.Sleep(time.Millisecond)
+				return scard.ErrTimeout
+			}
+
+			present[rs[0].Reader] = true
+			rs[0].EventState = scard.StatePresent
+
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler := func(name string) Handler {
+		return HandlerFunc(func(c Card) {
+			mu.Lock()
+			defer mu.Unlock()
+			if served[name] = append(served[name], c.Reader()); len(served) == 2 {
+				cancel()
+			}
+		})
+	}
+
+	err = actx.ServeMap(ctx, map[string]Handler{"r1": handler("h1"), "r2": handler("h2")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := served["h1"]; !stringsEqual(got, []string{"r1"}) {
+		t.Fatalf("h1 served %q, want [r1]", got)
+	}
+	if got := served["h2"]; !stringsEqual(got, []string{"r2"}) {
+		t.Fatalf("h2 served %q, want [r2]", got)
+	}
+	if polled["r3"] {
+		t.Fatalf("r3 polled, want only the readers with handlers")
+	}
+
+	t.Run("Slow handler", func(t *testing.T) {
+		actx, err := newContext(&mockContext{
+			listReaders: func() ([]string, error) {
+				return []string{"r1", "r2"}, nil
+			},
+			connect:         uidConnect,
+			getStatusChange: perReaderSequence(scard.StatePresent),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// r1 is handled until r2 has been handled, which a shared
+		// dispatcher would only do after r1
+		r2Handled := make(chan struct{})
+		err = actx.ServeMap(ctx, map[string]Handler{
+			"r1": HandlerFunc(func(Card) {
+				select {
+				case <-r2Handled:
+					cancel()
+				case <-ctx.Done():
+					t.Errorf("r2 not handled while r1 was handled")
+				}
+			}),
+			"r2": HandlerFunc(func(Card) { close(r2Handled) }),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Reader errors", func(t *testing.T) {
+		var (
+			r1Failed = make(chan struct{})
+			r2Polls  int
+		)
+
+		actx, err := newContext(&mockContext{
+			listReaders: func() ([]string, error) {
+				return []string{"r1", "r2"}, nil
+			},
+			connect: uidConnect,
+			getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+				if rs[0].Reader == "r1" {
+					return scard.ErrReaderUnavailable
+				}
+				// r2 reads a card after r1 failed, then fails too
+				if r2Polls++; r2Polls > 1 {
+					return scard.ErrReaderUnavailable
+				}
+				<-r1Failed
+				rs[0].EventState = scard.StatePresent
+				return nil
+			},
+		}, WithReaderErrorHandler(func(reader string, err error) {
+			if reader == "r1" {
+				close(r1Failed)
+			}
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var served bool
+		err = actx.ServeMap(context.Background(), map[string]Handler{
+			"r1": HandlerFunc(func(Card) {}),
+			"r2": HandlerFunc(func(Card) { served = true }),
+		})
+
+		var errs ReaderErrors
+		if !errors.As(err, &errs) || len(errs) != 2 || !errors.Is(err, ErrReaderUnavailable) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if errs[0].Reader != "r1" || errs[1].Reader != "r2" {
+			t.Fatalf("reader errors = %v, want r1 then r2", errs)
+		}
+
+		if !served {
+			t.Fatalf("r2 not served")
+		}
+	})
+
+	t.Run("Unknown reader", func(t *testing.T) {
+		if err := actx.ServeMap(ctx, map[string]Handler{"r4": handler("h4")}); !errors.Is(err, ErrUnknownReader) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("No handlers", func(t *testing.T) {
+		if err := actx.ServeMap(ctx, nil); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestContextServeReaderOrder(t *testing.T) {
+	var served []string
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		listReaders: func() ([]string, error) {
+			return []string{"exit", "entry"}, nil
+		},
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent),
+	}, WithReaderOrder([]string{"entry"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = actx.ServeFunc(ctx, func(c Card) {
+		if served = append(served, c.Reader()); len(served) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"entry", "exit"}; !stringsEqual(served, want) {
+		t.Fatalf("served = %q, want %q", served, want)
+	}
+
+	if _, err := newContext(&mockContext{}, WithReaderOrder([]string{"Unknown"})); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestContextReadN(t *testing.T) {
+	uids := [][]byte{{0x0A, 0, 0, 0}, {0x0A, 0, 0, 0}, {0x0B, 0, 0, 0}, {0x0A, 0, 0, 0}, {0x0C, 0, 0, 0}, {0x0D, 0, 0, 0}}
+
+	newReadNContext := func(t *testing.T, uids [][]byte, options ...Option) *Context {
+		var connects int
+
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				uid := uids[connects%len(uids)]
+				connects++
+
+				return &mockCard{transmit: func([]byte) ([]byte, error) {
+					return append(append([]byte{}, uid...), rcOperationSuccess...), nil
+				}}, nil
+			},
+			getStatusChange: statusSequence(
+				scard.StatePresent, scard.StateEmpty,
+				scard.StatePresent, scard.StateEmpty,
+				scard.StatePresent, scard.StateEmpty,
+				scard.StatePresent, scard.StateEmpty,
+				scard.StatePresent, scard.StateEmpty,
+			),
+		}, options...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return actx
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		snapshots, err := newReadNContext(t, uids).ReadN(context.Background(), 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := len(snapshots), 3; got != want {
+			t.Fatalf("len(snapshots) = %d, want %d", got, want)
+		}
+
+		for i, want := range [][]byte{uids[0], uids[2], uids[4]} {
+			if got := snapshots[i].UID; !bytes.Equal(got, want) {
+				t.Fatalf("snapshots[%d].UID = %X, want %X", i, got, want)
+			}
+		}
+	})
+
+	t.Run("Context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		snapshots, err := newReadNContext(t, uids).ReadN(ctx, 5)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := len(snapshots), 3; got != want {
+			t.Fatalf("len(snapshots) = %d, want %d", got, want)
+		}
+	})
+	t.Run("Read error", func(t *testing.T) {
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				return nil, scard.ErrUnresponsiveCard
+			},
+			getStatusChange: statusSequence(scard.StatePresent),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		snapshots, err := actx.ReadN(context.Background(), 2)
+		if !errors.Is(err, scard.ErrUnresponsiveCard) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := len(snapshots), 0; got != want {
+			t.Fatalf("len(snapshots) = %d, want %d", got, want)
+		}
+	})
+	t.Run("Random UIDs", func(t *testing.T) {
+		random := [][]byte{{0x08, 0x11, 0x22, 0x33}, {0x08, 0x11, 0x22, 0x33}}
+
+		snapshots, err := newReadNContext(t, random).ReadN(context.Background(), 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := len(snapshots), 2; got != want {
+			t.Fatalf("len(snapshots) = %d, want %d", got, want)
+		}
+	})
+	t.Run("Identity", func(t *testing.T) {
+		random := [][]byte{{0x08, 0x11, 0x22, 0x33}, {0x08, 0x44, 0x55, 0x66}, {0x08, 0x77, 0x88, 0x99}}
+		identities := map[byte]string{0x11: "alice", 0x44: "alice", 0x77: "bob"}
+
+		snapshots, err := newReadNContext(t, random, WithIdentityFunc(func(c Card) (string, error) {
+			return identities[c.UID()[1]], nil
+		})).ReadN(context.Background(), 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(snapshots) != 2 || !bytes.Equal(snapshots[0].UID, random[0]) || !bytes.Equal(snapshots[1].UID, random[2]) {
+			t.Fatalf("snapshots = %v, want UIDs %X and %X", snapshots, random[0], random[2])
+		}
+	})
+	t.Run("Identity error", func(t *testing.T) {
+		snapshots, err := newReadNContext(t, uids, WithIdentityFunc(func(Card) (string, error) {
+			return "", ErrOperationFailed
+		})).ReadN(context.Background(), 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, want := range [][]byte{uids[0], uids[2], uids[4]} {
+			if got := snapshots[i].UID; !bytes.Equal(got, want) {
+				t.Fatalf("snapshots[%d].UID = %X, want %X", i, got, want)
+			}
+		}
+	})
+}
+
+func TestContextServeIdleCallback(t *testing.T) {
+	var idles []time.Duration
+
+	clk := newMockClock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+			clk.Advance(time.Second)
+			return scard.ErrTimeout
+		},
+	}, WithClock(clk), WithIdleCallback(3*time.Second, func(idle time.Duration) {
+		if idles = append(idles, idle); len(idles) == 2 {
+			cancel()
+		}
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.ServeFunc(ctx, func(Card) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []time.Duration{3 * time.Second, 6 * time.Second}; !durationsEqual(idles, want) {
+		t.Fatalf("idles = %v, want %v", idles, want)
+	}
+}
+
+func TestContextIdleCallbackInvalid(t *testing.T) {
+	for _, after := range []time.Duration{0, -time.Second} {
+		if _, err := newContext(&mockContext{}, WithIdleCallback(after, func(time.Duration) {})); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("WithIdleCallback(%v): unexpected error: %v", after, err)
+		}
+	}
+}
+
+func TestContextServeRemovalHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		inline bool
+	}{{"Dispatcher", false}, {"Inline", true}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				handled []byte
+				removed []*CardSnapshot
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			options := []Option{WithRemovalHandler(func(reader string, last *CardSnapshot) {
+				if reader != "Test" {
+					t.Errorf("reader = %q, want Test", reader)
+				}
+				removed = append(removed, last)
+				cancel()
+			})}
+			if tc.inline {
+				options = append(options, WithInlineDispatch())
+			}
+
+			actx, err := newContext(&mockContext{
+				connect:         uidConnect,
+				getStatusChange: statusSequence(scard.StateEmpty, scard.StatePresent, scard.StateEmpty),
+			}, options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := actx.ServeFunc(ctx, func(c Card) {
+				handled = c.UID()
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(handled, testUID) {
+				t.Fatalf("handled = %X, want %X", handled, testUID)
+			}
+
+			if len(removed) != 1 || removed[0] == nil || !bytes.Equal(removed[0].UID, handled) {
+				t.Fatalf("removed = %v, want UID %X", removed, handled)
+			}
+
+			if last := actx.removals.removed("Test"); last.(cardRemoval).last != nil {
+				t.Fatalf("snapshot kept after removal: %v", last)
+			}
+		})
+	}
+}
+
+func TestContextServeUIDBlocklist(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		blocklist [][]byte
+		denied    bool
+	}{
+		{"Blocked", [][]byte{{0x01, 0x02, 0x03, 0x04}, testUID}, true},
+		{"Allowed", [][]byte{{0x01, 0x02, 0x03, 0x04}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var handled, denied []byte
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			actx, err := newContext(&mockContext{
+				connect:         uidConnect,
+				getStatusChange: statusSequence(scard.StatePresent),
+			}, WithUIDBlocklist(tc.blocklist), WithDeniedCallback(func(c Card) {
+				denied = c.UID()
+				cancel()
+			}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := actx.ServeFunc(ctx, func(c Card) {
+				handled = c.UID()
+				cancel()
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.denied && (handled != nil || !bytes.Equal(denied, testUID)) {
+				t.Fatalf("handled = %X, denied = %X, want denied %X", handled, denied, testUID)
+			}
+
+			if !tc.denied && (denied != nil || !bytes.Equal(handled, testUID)) {
+				t.Fatalf("handled = %X, denied = %X, want handled %X", handled, denied, testUID)
+			}
+		})
+	}
+}
+
+type mockContext struct {
+	release         func() error
+	isValid         func() (bool, error)
+	listReaders     func() ([]string, error)
+	connect         func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error)
+	getStatusChange func([]scard.ReaderState, time.Duration) error
+}
+
+func (ctx *mockContext) Release() error {
+	if ctx.release != nil {
+		return ctx.release()
+	}
+
+	return nil
+}
+
+func (ctx *mockContext) IsValid() (bool, error) {
+	if ctx.isValid != nil {
+		return ctx.isValid()
+	}
+
+	return true, nil
+}
+
+func (ctx *mockContext) ListReaders() ([]string, error) {
+	if ctx.listReaders != nil {
+		return ctx.listReaders()
+	}
+
+	return []string{"Test"}, nil
+}
+
+func (ctx *mockContext) Connect(reader string, shareMode scard.ShareMode, protocol scard.Protocol) (PCSCCard, error) {
+	if ctx.connect != nil {
+		return ctx.connect(reader, shareMode, protocol)
+	}
+
+	return &mockCard{}, nil
+}
+
+func (ctx *mockContext) GetStatusChange(rs []scard.ReaderState, timeout time.Duration) error {
+	if ctx.getStatusChange != nil {
+		return ctx.getStatusChange(rs, timeout)
+	}
+
+	for i := range rs {
+		rs[i].EventState = scard.StatePresent
+	}
+
+	return nil
+}
+
+func TestContextServeErrorHandlers(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		connect         func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error)
+		getStatusChange func([]scard.ReaderState, time.Duration) error
+		wantReader      error
+		wantCard        error
+	}{
+		{
+			name: "Reader error",
+			getStatusChange: func([]scard.ReaderState, time.Duration) error {
+				return scard.ErrReaderUnavailable
+			},
+			wantReader: ErrReaderUnavailable,
+		},
+		{
+			name: "Card error",
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				return &mockCard{transmit: func([]byte) ([]byte, error) {
+					return rcOperationFailed, nil
+				}}, nil
+			},
+			getStatusChange: statusSequence(scard.StatePresent),
+			wantCard:        ErrOperationFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var readerErr, cardErr error
+
+			actx, err := newContext(&mockContext{
+				connect:         tc.connect,
+				getStatusChange: tc.getStatusChange,
+			}, WithReaderErrorHandler(func(reader string, err error) {
+				if reader != "Test" {
+					t.Fatalf("reader = %q, want %q", reader, "Test")
+				}
+				readerErr = err
+			}), WithCardErrorHandler(func(reader string, err error) {
+				if reader != "Test" {
+					t.Fatalf("reader = %q, want %q", reader, "Test")
+				}
+				cardErr = err
+			}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The error that stopped reading is returned by Serve
+			wantServe := tc.wantReader
+			if wantServe == nil {
+				wantServe = tc.wantCard
+			}
+
+			if err := actx.ServeFunc(context.Background(), func(Card) {}); !errors.Is(err, wantServe) {
+				t.Fatalf("actx.ServeFunc() = %v, want %v", err, wantServe)
+			}
+
+			if !errors.Is(readerErr, tc.wantReader) {
+				t.Fatalf("reader error = %v, want %v", readerErr, tc.wantReader)
+			}
+
+			if !errors.Is(cardErr, tc.wantCard) {
+				t.Fatalf("card error = %v, want %v", cardErr, tc.wantCard)
+			}
+		})
+	}
+}
+
+func getStatusChangeFunc(sf scard.StateFlag) func([]scard.ReaderState, time.Duration) error {
+	return func(rs []scard.ReaderState, timeout time.Duration) error {
+		for i := range rs {
+			rs[i].EventState = sf
+		}
+
+		return nil
+	}
+}
+
+// statusSequence returns a getStatusChange func reporting the states in order
+// on all readers, followed by timeouts
+func statusSequence(states ...scard.StateFlag) func([]scard.ReaderState, time.Duration) error {
+	var i int
+
+	return func(rs []scard.ReaderState, timeout time.Duration) error {
+		if i >= len(states) {
+			time.Sleep(time.Millisecond)
+			return scard.ErrTimeout
+		}
+
+		for j := range rs {
+			rs[j].EventState = states[i]
+		}
+		i++
+
+		return nil
+	}
+}
+
+// perReaderSequence returns a getStatusChange func reporting the states in
+// order on each reader polled on its own, followed by timeouts
+func perReaderSequence(states ...scard.StateFlag) func([]scard.ReaderState, time.Duration) error {
+	var (
+		mu    sync.Mutex
+		polls = map[string]int{}
+	)
+
+	return func(rs []scard.ReaderState, timeout time.Duration) error {
+		mu.Lock()
+		i := polls[rs[0].Reader]
+		polls[rs[0].Reader]++
+		mu.Unlock()
+
+		if i >= len(states) {
+			time.Sleep(time.Millisecond)
+			return scard.ErrTimeout
+		}
+
+		rs[0].EventState = states[i]
+
+		return nil
+	}
+}
+
+// uidConnect connects to a card responding with testUID
+func uidConnect(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+	return &mockCard{transmit: uidTransmit}, nil
+}
+
 func stringsEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
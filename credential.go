@@ -0,0 +1,187 @@
+package acr122u
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// credentialSize is the size of an encoded Credential, one MIFARE Classic
+// block or four MIFARE Ultralight/NTAG pages
+const credentialSize = 16
+
+// Credential is an access credential stored on a card in a fixed 16 byte
+// layout, all values big endian:
+//
+//	0     Version
+//	1-2   FacilityCode
+//	3-6   CardNumber
+//	7-8   IssueDate year
+//	9     IssueDate month
+//	10    IssueDate day
+//	11-14 Reserved, zero
+//	15    Checksum, the inverted XOR of bytes 0-14
+type Credential struct {
+	Version      byte
+	FacilityCode uint16
+	CardNumber   uint32
+
+	// IssueDate is stored as a UTC date, the time of day is not stored
+	IssueDate time.Time
+}
+
+// MarshalBinary encodes the credential in the 16 byte layout
+func (cred Credential) MarshalBinary() ([]byte, error) {
+	b := make([]byte, credentialSize)
+	b[0] = cred.Version
+	binary.BigEndian.PutUint16(b[1:], cred.FacilityCode)
+	binary.BigEndian.PutUint32(b[3:], cred.CardNumber)
+
+	if !cred.IssueDate.IsZero() {
+		y, m, d := cred.IssueDate.UTC().Date()
+		if y < 0 || y > 0xFFFF {
+			return nil, wrapError(fmt.Sprintf("issue date year %d", y), ErrInvalidParameter)
+		}
+		binary.BigEndian.PutUint16(b[7:], uint16(y))
+		b[9], b[10] = byte(m), byte(d)
+	}
+
+	b[15] = credentialChecksum(b)
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the credential from the 16 byte layout, returning
+// ErrInvalidCredential if the checksum does not match
+func (cred *Credential) UnmarshalBinary(b []byte) error {
+	if len(b) != credentialSize {
+		return wrapError(fmt.Sprintf("credential length %d", len(b)), ErrInvalidCredential)
+	}
+
+	if b[15] != credentialChecksum(b) {
+		return wrapError(fmt.Sprintf("credential checksum %02X", b[15]), ErrInvalidCredential)
+	}
+
+	*cred = Credential{
+		Version:      b[0],
+		FacilityCode: binary.BigEndian.Uint16(b[1:]),
+		CardNumber:   binary.BigEndian.Uint32(b[3:]),
+	}
+
+	if y := binary.BigEndian.Uint16(b[7:]); y != 0 || b[9] != 0 || b[10] != 0 {
+		cred.IssueDate = time.Date(int(y), time.Month(b[9]), int(b[10]), 0, 0, 0, 0, time.UTC)
+	}
+
+	return nil
+}
+
+// credentialChecksum returns the inverted XOR of bytes 0-14, so a blank
+// block of zeros is not a valid credential
+func credentialChecksum(b []byte) byte {
+	var sum byte
+	for _, v := range b[:credentialSize-1] {
+		sum ^= v
+	}
+
+	return ^sum
+}
+
+// WriteCredential writes the credential to the MIFARE Classic data block at,
+// authenticating with the key, or to the four MIFARE Ultralight/NTAG pages
+// starting at page at, where the key is ignored. The pages must lie within
+// the data area declared by the capability container.
+func (c *card) WriteCredential(cred Credential, at byte, key [6]byte, keyType KeyType) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	b, err := cred.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	t, err := c.credentialLocation(at)
+	if err != nil {
+		return err
+	}
+
+	if t == CardTypeMifareUltralight {
+		for i := 0; i < credentialSize; i += ntagPageSize {
+			if err := c.WritePage(at+byte(i/ntagPageSize), b[i:i+ntagPageSize]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := c.Authenticate(at, key, keyType); err != nil {
+		return err
+	}
+
+	return c.WriteBlock(at, b)
+}
+
+// ReadCredential reads a credential written by WriteCredential
+func (c *card) ReadCredential(at byte, key [6]byte, keyType KeyType) (Credential, error) {
+	var cred Credential
+
+	t, err := c.credentialLocation(at)
+	if err != nil {
+		return cred, err
+	}
+
+	var b []byte
+	if t == CardTypeMifareUltralight {
+		// READ returns four pages
+		resp, err := c.transmit([]byte{0xFF, 0xB0, 0x00, at, credentialSize})
+		if err != nil {
+			return cred, wrapError(fmt.Sprintf("read page %d", at), err)
+		}
+		b = resp
+	} else {
+		if err := c.Authenticate(at, key, keyType); err != nil {
+			return cred, err
+		}
+		if b, err = c.ReadBlock(at); err != nil {
+			return cred, err
+		}
+	}
+
+	err = cred.UnmarshalBinary(b)
+
+	return cred, err
+}
+
+// credentialLocation returns the card type, or an error if the credential
+// cannot be stored at the block or page
+func (c *card) credentialLocation(at byte) (CardType, error) {
+	t, err := c.Type()
+	if err != nil {
+		return t, err
+	}
+
+	if t == CardTypeMifareUltralight {
+		if at < type2DataPage {
+			return t, wrapError(fmt.Sprintf("credential page %d", at), ErrInvalidParameter)
+		}
+		// The pages after the data area hold the lock bytes and configuration
+		size, err := c.type2DataSize()
+		if err != nil {
+			return t, err
+		}
+		if int(at)+credentialSize/ntagPageSize > type2DataPage+size/ntagPageSize {
+			return t, wrapError(fmt.Sprintf("credential page %d past the %d byte data area", at, size), ErrInvalidParameter)
+		}
+		return t, nil
+	}
+
+	if err := ValidateBlock(t, at); err != nil {
+		return t, err
+	}
+
+	if at == 0 || at == TrailerBlock(SectorForBlock(at)) {
+		return t, wrapError(fmt.Sprintf("credential block %d", at), ErrInvalidParameter)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,148 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testCredential = Credential{
+	Version:      1,
+	FacilityCode: 0x1234,
+	CardNumber:   0x00ABCDEF,
+	IssueDate:    time.Date(2023, time.March, 14, 0, 0, 0, 0, time.UTC),
+}
+
+var testCredentialBytes = []byte{0x01, 0x12, 0x34, 0x00, 0xAB, 0xCD, 0xEF, 0x07, 0xE7, 0x03, 0x0E, 0x00, 0x00, 0x00, 0x00, 0xBC}
+
+func TestCredentialMarshal(t *testing.T) {
+	got, err := testCredential.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got, testCredentialBytes) {
+		t.Fatalf("MarshalBinary() = %X, want %X", got, testCredentialBytes)
+	}
+}
+
+func TestCredentialUnmarshal(t *testing.T) {
+	corrupt := append([]byte{}, testCredentialBytes...)
+	corrupt[4] ^= 0x01
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want Credential
+		err  error
+	}{
+		{"OK", testCredentialBytes, testCredential, nil},
+		{"No issue date", []byte{0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFE}, Credential{Version: 2, FacilityCode: 1, CardNumber: 2}, nil},
+		{"Corrupt", corrupt, Credential{}, ErrInvalidCredential},
+		{"Blank", make([]byte, 16), Credential{}, ErrInvalidCredential},
+		{"Short", testCredentialBytes[:15], Credential{}, ErrInvalidCredential},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Credential
+
+			err := got.UnmarshalBinary(tc.data)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("UnmarshalBinary() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCardCredential(t *testing.T) {
+	t.Run("MIFARE Classic", func(t *testing.T) {
+		m := newMockMifare(atrMifareClassic1K)
+		c := m.card()
+
+		if err := c.WriteCredential(testCredential, 4, m.key, KeyA); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(m.blocks[4], testCredentialBytes) {
+			t.Fatalf("block 4 = %X, want %X", m.blocks[4], testCredentialBytes)
+		}
+
+		got, err := c.ReadCredential(4, m.key, KeyA)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != testCredential {
+			t.Fatalf("ReadCredential() = %+v, want %+v", got, testCredential)
+		}
+
+		m.blocks[4][8] ^= 0x10
+
+		if _, err := c.ReadCredential(4, m.key, KeyA); !errors.Is(err, ErrInvalidCredential) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("NTAG", func(t *testing.T) {
+		m := newMockNTAG(45)
+		c := m.card()
+
+		if err := c.WriteCredential(testCredential, 8, [6]byte{}, KeyA); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := bytes.Join(m.pages[8:12], nil); !bytes.Equal(got, testCredentialBytes) {
+			t.Fatalf("pages 8-11 = %X, want %X", got, testCredentialBytes)
+		}
+
+		got, err := c.ReadCredential(8, [6]byte{}, KeyA)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != testCredential {
+			t.Fatalf("ReadCredential() = %+v, want %+v", got, testCredential)
+		}
+
+		// The last four pages of the data area hold a credential, the
+		// dynamic lock and configuration pages after it do not
+		if err := c.WriteCredential(testCredential, 36, [6]byte{}, KeyA); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, at := range []byte{37, 38} {
+			if err := c.WriteCredential(testCredential, at, [6]byte{}, KeyA); !errors.Is(err, ErrInvalidParameter) {
+				t.Fatalf("WriteCredential(%d) = %v, want %v", at, err, ErrInvalidParameter)
+			}
+		}
+
+		if got := m.pages[40]; !bytes.Equal(got, make([]byte, ntagPageSize)) {
+			t.Fatalf("page 40 = %X, want zeros", got)
+		}
+	})
+
+	t.Run("Invalid location", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			atr  []byte
+			at   byte
+			err  error
+		}{
+			{"Manufacturer block", atrMifareClassic1K, 0, ErrInvalidParameter},
+			{"Trailer", atrMifareClassic1K, 7, ErrInvalidParameter},
+			{"Missing block", atrMifareClassic1K, 64, ErrInvalidParameter},
+			{"Capability container", atrMifareUltralight, 3, ErrInvalidParameter},
+			{"ISO-DEP", atrISODEP, 4, ErrNotSupported},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				c := statusCard(atrStatus(tc.atr))
+
+				if err := c.WriteCredential(testCredential, tc.at, testKey, KeyA); !errors.Is(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package acr122u
+
+import "fmt"
+
+// DESFire status codes, sent as SW2 with SW1 0x91
+const (
+	desfireSW1             = 0x91
+	desfireOK              = 0x00
+	desfireAdditionalFrame = 0xAF
+)
+
+// TransmitDESFire sends the native DESFire command wrapped in an ISO 7816
+// APDU (90 <cmd> 00 00 Lc <data> 00), requesting additional frames while
+// the card answers 91 AF and returning the concatenated response data
+func (c *card) TransmitDESFire(cmd byte, data []byte) ([]byte, error) {
+	if len(data) > 0xFF {
+		return nil, wrapError("DESFire data length", ErrInvalidParameter)
+	}
+
+	var resp []byte
+
+	for {
+		apdu := []byte{0x90, cmd, 0x00, 0x00}
+		if len(data) > 0 {
+			apdu = append(append(apdu, byte(len(data))), data...)
+		}
+		apdu = append(apdu, 0x00)
+
+		frame, sw, err := c.transmitSW(apdu)
+		if err != nil {
+			return nil, err
+		}
+
+		if sw>>8 != desfireSW1 {
+			return nil, wrapError(fmt.Sprintf("DESFire command %02X status %04X", cmd, sw), ErrOperationFailed)
+		}
+
+		resp = append(resp, frame...)
+
+		switch byte(sw) {
+		case desfireOK:
+			return resp, nil
+		case desfireAdditionalFrame:
+			cmd, data = desfireAdditionalFrame, nil
+		default:
+			return nil, wrapError(fmt.Sprintf("DESFire command %02X status %02X", cmd, byte(sw)), ErrOperationFailed)
+		}
+	}
+}
+
+// DESFireListApplications returns the application IDs (GetApplicationIDs)
+func (c *card) DESFireListApplications() ([][3]byte, error) {
+	resp, err := c.TransmitDESFire(0x6A, nil)
+	if err != nil {
+		return nil, wrapError("get application IDs", err)
+	}
+
+	if len(resp)%3 != 0 {
+		return nil, wrapError(fmt.Sprintf("application IDs %X", resp), ErrOperationFailed)
+	}
+
+	aids := make([][3]byte, len(resp)/3)
+	for i := range aids {
+		copy(aids[i][:], resp[i*3:])
+	}
+
+	return aids, nil
+}
+
+// DESFireListFiles returns the file IDs of the selected application (GetFileIDs)
+func (c *card) DESFireListFiles() ([]byte, error) {
+	resp, err := c.TransmitDESFire(0x6F, nil)
+	if err != nil {
+		return nil, wrapError("get file IDs", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,107 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// desfireCard responds to the APDUs in order with the responses
+func desfireCard(t *testing.T, exchanges ...[2][]byte) *card {
+	var i int
+
+	return transmitCard(func(cmd []byte) ([]byte, error) {
+		if i >= len(exchanges) {
+			t.Fatalf("unexpected command %X", cmd)
+		}
+
+		e := exchanges[i]
+		i++
+
+		if !bytes.Equal(cmd, e[0]) {
+			t.Fatalf("cmd = %X, want %X", cmd, e[0])
+		}
+
+		return e[1], nil
+	})
+}
+
+func TestCardTransmitDESFire(t *testing.T) {
+	t.Run("Data", func(t *testing.T) {
+		c := desfireCard(t,
+			[2][]byte{{0x90, 0x5A, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03, 0x00}, {0x91, 0x00}},
+		)
+
+		if _, err := c.TransmitDESFire(0x5A, []byte{0x01, 0x02, 0x03}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Error status", func(t *testing.T) {
+		c := desfireCard(t,
+			[2][]byte{{0x90, 0x6F, 0x00, 0x00, 0x00}, {0x91, 0xA0}},
+		)
+
+		if _, err := c.TransmitDESFire(0x6F, nil); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Not DESFire", func(t *testing.T) {
+		c := desfireCard(t,
+			[2][]byte{{0x90, 0x6A, 0x00, 0x00, 0x00}, {0x6E, 0x00}},
+		)
+
+		if _, err := c.TransmitDESFire(0x6A, nil); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCardDESFireListApplications(t *testing.T) {
+	c := desfireCard(t,
+		[2][]byte{{0x90, 0x6A, 0x00, 0x00, 0x00}, {0x01, 0x00, 0x00, 0x02, 0x00, 0x00, 0x91, 0xAF}},
+		[2][]byte{{0x90, 0xAF, 0x00, 0x00, 0x00}, {0x56, 0x34, 0x12, 0x91, 0x00}},
+	)
+
+	got, err := c.DESFireListApplications()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := [][3]byte{{0x01, 0x00, 0x00}, {0x02, 0x00, 0x00}, {0x56, 0x34, 0x12}}
+	if len(got) != len(want) {
+		t.Fatalf("applications = %X, want %X", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("applications = %X, want %X", got, want)
+		}
+	}
+
+	t.Run("Truncated", func(t *testing.T) {
+		c := desfireCard(t,
+			[2][]byte{{0x90, 0x6A, 0x00, 0x00, 0x00}, {0x01, 0x00, 0x91, 0x00}},
+		)
+
+		if _, err := c.DESFireListApplications(); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCardDESFireListFiles(t *testing.T) {
+	c := desfireCard(t,
+		[2][]byte{{0x90, 0x6F, 0x00, 0x00, 0x00}, {0x00, 0x01, 0x02, 0x91, 0x00}},
+	)
+
+	got, err := c.DESFireListFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []byte{0x00, 0x01, 0x02}; !bytes.Equal(got, want) {
+		t.Fatalf("files = %X, want %X", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package acr122u
+
+import "fmt"
+
+// dumpKeys are the well-known MIFARE Classic keys tried by Dump
+var dumpKeys = [][6]byte{
+	{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+	{0xA0, 0xA1, 0xA2, 0xA3, 0xA4, 0xA5},
+	{0xD3, 0xF7, 0xD3, 0xF7, 0xD3, 0xF7},
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+}
+
+// CardReport is the result of Dump. Sections that could not be read are
+// left empty and their error is recorded in Errors by section name.
+type CardReport struct {
+	Reader string
+	UID    []byte
+	ATR    []byte
+	Type   CardType
+
+	// ATQA (SENS_RES) and SAK (SEL_RES) of ISO14443-A cards, section "target"
+	ATQA []byte
+	SAK  byte
+
+	// Version is the GET_VERSION response of NTAG and Ultralight EV1 tags and
+	// Capacity their user memory size in bytes, section "version"
+	Version  []byte
+	Capacity int
+
+	// Sectors of MIFARE Classic and SL1 MIFARE Plus cards, section "sectors"
+	Sectors []SectorReport
+
+	// NDEF message of Type 2 and Type 4 tags, section "ndef"
+	NDEF []*NDEFRecord
+
+	Errors map[string]error
+}
+
+// SectorReport is a MIFARE Classic sector read by Dump
+type SectorReport struct {
+	Sector int
+
+	// Key is the well-known key A that authenticated the sector, if any
+	Key *[6]byte
+
+	// Blocks of the sector, including the trailer
+	Blocks [][]byte
+
+	Err error
+}
+
+// Dump reads everything that can safely be read from the card based on its
+// type. Errors reading a section are recorded in the report, only failing to
+// read the card status returns an error.
+func (c *card) Dump() (*CardReport, error) {
+	s, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &CardReport{
+		Reader: c.reader,
+		UID:    c.uid,
+		ATR:    s.Atr,
+		Type:   cardTypeFromATR(s.Atr),
+		Errors: map[string]error{},
+	}
+
+	if r.Type != CardTypeFeliCa && r.Type != CardTypeTopaz {
+		if r.ATQA, r.SAK, err = c.target(); err != nil {
+			r.Errors["target"] = err
+		}
+	}
+
+	switch r.Type {
+	case CardTypeMifareUltralight:
+		if r.Version, err = c.ntagVersion(); err != nil {
+			r.Errors["version"] = err
+		}
+		r.Capacity = ntagStorageSize(r.Version)
+	case CardTypeMifareMini, CardTypeMifareClassic1K, CardTypeMifareClassic4K, CardTypeMifarePlus:
+		if sectors, err := c.mifareSectors(); err != nil {
+			r.Errors["sectors"] = err
+		} else {
+			r.Sectors = c.dumpSectors(sectors)
+		}
+	}
+
+	if r.Type == CardTypeMifareUltralight || r.Type == CardTypeISODEP {
+		if r.NDEF, err = c.ReadNDEF(); err != nil {
+			r.Errors["ndef"] = err
+		}
+	}
+
+	return r, nil
+}
+
+// target returns the ATQA and SAK of the card by listing it with the PN532
+// InListPassiveTarget command (106 kbps type A)
+func (c *card) target() ([]byte, byte, error) {
+	resp, err := c.pn532(0x4A, 0x01, 0x00)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// NbTg Tg SENS_RES(2) SEL_RES ...
+	if len(resp) < 5 || resp[0] != 0x01 {
+		return nil, 0, wrapError(fmt.Sprintf("target response %X", resp), ErrOperationFailed)
+	}
+
+	return resp[2:4], resp[4], nil
+}
+
+// dumpSectors reads the sectors authenticating with the well-known keys
+func (c *card) dumpSectors(sectors int) []SectorReport {
+	var reports []SectorReport
+
+	for sector := 0; sector < sectors; sector++ {
+		r := SectorReport{Sector: sector, Err: ErrOperationFailed}
+		first := firstBlockOfSector(byte(sector))
+
+		for i := range dumpKeys {
+			if err := c.Authenticate(first, dumpKeys[i], KeyA); err != nil {
+				continue
+			}
+			key := dumpKeys[i]
+			r.Key, r.Err = &key, nil
+			break
+		}
+
+		if r.Err != nil {
+			r.Err = wrapError("no well-known key", r.Err)
+			reports = append(reports, r)
+			continue
+		}
+
+		for i := byte(0); i < BlocksInSector(byte(sector)); i++ {
+			data, err := c.ReadBlock(first + i)
+			if err != nil {
+				r.Err = err
+				break
+			}
+			r.Blocks = append(r.Blocks, data)
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports
+}
@@ -0,0 +1,85 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCardDumpNTAG(t *testing.T) {
+	m := newMockNTAG(ntag213Pages)
+	c := m.card()
+	c.uid = testUID
+
+	if err := c.WriteNDEF(testNDEFRecords[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := c.Dump()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(r.Errors) != 0 {
+		t.Fatalf("r.Errors = %v, want none", r.Errors)
+	}
+
+	if r.Type != CardTypeMifareUltralight || !bytes.Equal(r.ATR, atrMifareUltralight) || !bytes.Equal(r.UID, testUID) {
+		t.Fatalf("r = %v %X %X, want %v %X %X", r.Type, r.ATR, r.UID, CardTypeMifareUltralight, atrMifareUltralight, testUID)
+	}
+
+	if want := []byte{0x00, 0x44}; !bytes.Equal(r.ATQA, want) || r.SAK != 0x00 {
+		t.Fatalf("ATQA/SAK = %X/%02X, want %X/00", r.ATQA, r.SAK, want)
+	}
+
+	if !bytes.Equal(r.Version, m.version) || r.Capacity != 144 {
+		t.Fatalf("version = %X (%d bytes), want %X (144 bytes)", r.Version, r.Capacity, m.version)
+	}
+
+	if !ndefRecordsEqual(r.NDEF, testNDEFRecords[:1]) {
+		t.Fatalf("r.NDEF = %v, want %v", r.NDEF, testNDEFRecords[:1])
+	}
+
+	if r.Sectors != nil {
+		t.Fatalf("r.Sectors = %v, want none", r.Sectors)
+	}
+}
+
+func TestCardDumpMifareClassic(t *testing.T) {
+	m := newMockMifare(atrMifareClassic1K)
+	m.key = [6]byte{0xA0, 0xA1, 0xA2, 0xA3, 0xA4, 0xA5}
+
+	r, err := m.card().Dump()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := r.Errors["target"]; !ok {
+		t.Fatalf("r.Errors = %v, want target error", r.Errors)
+	}
+
+	if len(r.Sectors) != 16 {
+		t.Fatalf("len(r.Sectors) = %d, want 16", len(r.Sectors))
+	}
+
+	for _, s := range r.Sectors {
+		if s.Err != nil || s.Key == nil || *s.Key != m.key || len(s.Blocks) != 4 {
+			t.Fatalf("sector %d = %v, want 4 blocks read with key %X", s.Sector, s, m.key)
+		}
+	}
+
+	if !bytes.Equal(r.Sectors[1].Blocks[3], testTrailer) {
+		t.Fatalf("sector 1 trailer = %X, want %X", r.Sectors[1].Blocks[3], testTrailer)
+	}
+
+	m.key = [6]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}
+
+	r, err = m.card().Dump()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Sectors[0].Err; !errors.Is(err, ErrOperationFailed) || r.Sectors[0].Key != nil {
+		t.Fatalf("sector 0 = %v, want no key", r.Sectors[0])
+	}
+}
@@ -0,0 +1,61 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// EnrollConfirm waits for a card to be tapped twice, returning the snapshot
+// of the second tap if the UID and ATR of both taps match. The second tap
+// only counts once the card was removed from the reader of the first tap,
+// as a reader also reports a card again when just its state changes.
+// Returns ErrCardMismatch if the taps differ, ErrNotSupported for cards with
+// random UIDs, the error that stopped reading, or the error of ctx if it is
+// done before the second tap.
+func (actx *Context) EnrollConfirm(ctx context.Context) (*CardSnapshot, error) {
+	var (
+		snapshots    []CardSnapshot
+		removals     int
+		tapErr       error
+		ctx2, cancel = context.WithCancel(ctx)
+	)
+	defer cancel()
+	err := actx.ServeFunc(ctx2, func(c Card) {
+		if len(snapshots) == 2 || tapErr != nil {
+			return
+		}
+		if c.UIDIsRandom() {
+			tapErr = wrapError(fmt.Sprintf("random UID %X", c.UID()), ErrNotSupported)
+			cancel()
+			return
+		}
+		if len(snapshots) == 0 {
+			removals = actx.removalsBefore(c)
+		} else if !actx.removedSince(snapshots[0].Reader, removals, c) {
+			// The card has not left the field since the first tap
+			return
+		}
+		snapshots = append(snapshots, newCardSnapshot(c))
+		if len(snapshots) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tapErr != nil {
+		return nil, tapErr
+	}
+	if len(snapshots) < 2 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrShutdown
+	}
+	first, second := snapshots[0], snapshots[1]
+	if !bytes.Equal(first.UID, second.UID) || !bytes.Equal(first.ATR, second.ATR) {
+		return nil, wrapError(fmt.Sprintf("tapped %X then %X", first.UID, second.UID), ErrCardMismatch)
+	}
+	return &second, nil
+}
@@ -0,0 +1,129 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextEnrollConfirm(t *testing.T) {
+	newEnrollContext := func(t *testing.T, uids ...[]byte) *Context {
+		var connects int
+
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				uid := uids[connects%len(uids)]
+				connects++
+
+				return &mockCard{
+					transmit: func([]byte) ([]byte, error) {
+						return append(append([]byte{}, uid...), rcOperationSuccess...), nil
+					},
+					status: atrStatus(atrMifareClassic1K),
+				}, nil
+			},
+			getStatusChange: statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent, scard.StateEmpty),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return actx
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		uid := []byte{0x0A, 0x01, 0x02, 0x03}
+
+		s, err := newEnrollContext(t, uid, uid).EnrollConfirm(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(s.UID, uid) || s.Type != CardTypeMifareClassic1K {
+			t.Fatalf("snapshot = %+v", s)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		_, err := newEnrollContext(t, []byte{0x0A, 0x01, 0x02, 0x03}, []byte{0x0B, 0x01, 0x02, 0x03}).EnrollConfirm(context.Background())
+		if !errors.Is(err, ErrCardMismatch) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Read error", func(t *testing.T) {
+		actx, err := newContext(&mockContext{
+			connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+				return nil, scard.ErrUnresponsiveCard
+			},
+			getStatusChange: statusSequence(scard.StatePresent),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s, err := actx.EnrollConfirm(context.Background())
+		if !errors.Is(err, scard.ErrUnresponsiveCard) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s != nil {
+			t.Fatalf("snapshot = %+v, want nil", s)
+		}
+	})
+
+	t.Run("Random UID", func(t *testing.T) {
+		_, err := newEnrollContext(t, []byte{0x08, 0x01, 0x02, 0x03}).EnrollConfirm(context.Background())
+		if !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Not removed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		var connects int32
+
+		actx, err := newContext(&mockContext{
+			connect: func(reader string, mode scard.ShareMode, protocol scard.Protocol) (PCSCCard, error) {
+				atomic.AddInt32(&connects, 1)
+				return uidConnect(reader, mode, protocol)
+			},
+			getStatusChange: statusSequence(scard.StatePresent, scard.StatePresent|scard.StateInuse, scard.StatePresent, scard.StatePresent|scard.StateInuse),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := actx.EnrollConfirm(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n := atomic.LoadInt32(&connects); n < 2 {
+			t.Fatalf("card read %d times, want at least 2", n)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		actx, err := newContext(&mockContext{
+			connect:         uidConnect,
+			getStatusChange: statusSequence(scard.StatePresent),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := actx.EnrollConfirm(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package acr122u
+
+// Erase wipes the user data of the card, which is irreversible, so confirm
+// must be true.
+//
+// For MIFARE Classic cards all data blocks except the manufacturer block are
+// zeroed, authenticating each sector with the key. Sector trailers are left
+// untouched, so keys and access conditions are kept.
+//
+// For MIFARE Ultralight/NTAG cards the key is ignored. The user pages of the
+// data area given by the capability container are zeroed and an empty NDEF
+// message TLV is written. The UID, lock, capability container and
+// configuration pages are left untouched.
+func (c *card) Erase(key [6]byte, keyType KeyType, confirm bool) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	if !confirm {
+		return ErrNotConfirmed
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case CardTypeMifareUltralight:
+		return c.eraseType2()
+	default:
+		return c.eraseMifare(key, keyType)
+	}
+}
+
+// eraseMifare zeroes the data blocks of a MIFARE Classic card
+func (c *card) eraseMifare(key [6]byte, keyType KeyType) error {
+	blocks, err := c.mifareDataBlocks()
+	if err != nil {
+		return err
+	}
+
+	// Sector 0 data blocks following the manufacturer block
+	blocks = append([]byte{0x01, 0x02}, blocks...)
+
+	c.authenticated = false
+
+	zero := make([]byte, mifareBlockSize)
+
+	for _, block := range blocks {
+		if err := c.authenticateSector(block, key, keyType); err != nil {
+			return err
+		}
+
+		if err := c.WriteBlock(block, zero); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eraseType2 zeroes the data area of a Type 2 tag, starting it with an
+// empty NDEF message TLV
+func (c *card) eraseType2() error {
+	size, err := c.type2DataSize()
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, size)
+	copy(data, []byte{tlvNDEF, 0x00, tlvTerminator})
+
+	for i := 0; i < size; i += ntagPageSize {
+		page := byte(type2DataPage + i/ntagPageSize)
+
+		if err := c.WritePage(page, data[i:i+ntagPageSize]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCardEraseMifare(t *testing.T) {
+	m := newMockMifare(atrMifareClassic1K)
+	for i := 1; i < len(m.blocks); i++ {
+		if i%4 != 3 {
+			m.blocks[i] = bytes.Repeat([]byte{0x42}, mifareBlockSize)
+		}
+	}
+	manufacturer := append([]byte{}, m.blocks[0]...)
+
+	c := m.card()
+
+	if err := c.Erase(m.key, KeyA, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zero := make([]byte, mifareBlockSize)
+	for i, block := range m.blocks {
+		want := zero
+		switch {
+		case i == 0:
+			want = manufacturer
+		case i%4 == 3:
+			want = testTrailer
+		}
+		if !bytes.Equal(block, want) {
+			t.Fatalf("block %d = %X, want %X", i, block, want)
+		}
+	}
+}
+
+func TestCardEraseNTAG(t *testing.T) {
+	m := newMockNTAG(45)
+	for i := 4; i < len(m.pages); i++ {
+		m.pages[i] = []byte{0x42, 0x42, 0x42, 0x42}
+	}
+	var want [][]byte
+	for _, page := range m.pages {
+		want = append(want, append([]byte{}, page...))
+	}
+
+	// Data area of 0x12 * 8 bytes, pages 4-39
+	want[4] = []byte{tlvNDEF, 0x00, tlvTerminator, 0x00}
+	for i := 5; i < 40; i++ {
+		want[i] = make([]byte, ntagPageSize)
+	}
+
+	c := m.card()
+
+	if err := c.Erase([6]byte{}, KeyA, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := range m.pages {
+		if !bytes.Equal(m.pages[i], want[i]) {
+			t.Fatalf("page %d = %X, want %X", i, m.pages[i], want[i])
+		}
+	}
+
+	if _, err := c.ReadNDEF(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCardEraseRefused(t *testing.T) {
+	m := newMockNTAG(45)
+
+	t.Run("Not confirmed", func(t *testing.T) {
+		if err := m.card().Erase([6]byte{}, KeyA, false); !errors.Is(err, ErrNotConfirmed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Read-only", func(t *testing.T) {
+		c := m.card()
+		c.readOnly = true
+
+		if err := c.Erase([6]byte{}, KeyA, true); !errors.Is(err, ErrReadOnlyMode) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		c := statusCard(atrStatus(atrISODEP))
+
+		if err := c.Erase([6]byte{}, KeyA, true); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -14,10 +14,126 @@ var (
 	// ErrShutdown is returned when the library detects an interrupt signal
 	ErrShutdown = errors.New("shutting down")
 
+	// ErrMaxRuntimeReached is returned by Serve when the runtime set by
+	// WithMaxRuntime has passed
+	ErrMaxRuntimeReached = errors.New("maximum runtime reached")
+
 	// Called if the card payload wasn't deserializable to a card struct.
 	ErrUnhandledCardData = errors.New("unknown card data")
+
+	// ErrShortResponse is returned when a response has no status word
+	ErrShortResponse = errors.New("short response")
+
+	// ErrInvalidParameter is returned when a parameter is out of range
+	ErrInvalidParameter = errors.New("invalid parameter")
+
+	// ErrWrongPIN is returned when the card rejects a PIN
+	ErrWrongPIN = errors.New("wrong PIN")
+
+	// ErrPINBlocked is returned when the PIN is blocked (0x69 0x83)
+	ErrPINBlocked = errors.New("PIN blocked")
+
+	// ErrNotSupported is returned when an operation is not supported by the card
+	ErrNotSupported = errors.New("not supported")
+
+	// ErrCapacityExceeded is returned when data does not fit on the card
+	ErrCapacityExceeded = errors.New("data exceeds card capacity")
+
+	// ErrNotConfirmed is returned when an irreversible operation was not confirmed
+	ErrNotConfirmed = errors.New("irreversible operation not confirmed")
+
+	// ErrReadOnlyMode is returned when writing to a card of a read-only context
+	ErrReadOnlyMode = errors.New("read-only mode")
+
+	// ErrWatchdogTimeout is passed to the reader error handler when
+	// WithWatchdog restarts a stalled read loop
+	ErrWatchdogTimeout = errors.New("read loop stalled")
+
+	// ErrCardChanged is returned by Card.Refresh when the card answers with
+	// another UID than when it was read
+	ErrCardChanged = errors.New("card changed")
+
+	// ErrCardMismatch is returned by EnrollConfirm when the two taps read
+	// different cards
+	ErrCardMismatch = errors.New("cards do not match")
+
+	// ErrWriteVerifyFailed is returned when data read back after a write
+	// differs from the data written
+	ErrWriteVerifyFailed = errors.New("write verification failed")
+
+	// ErrCardRemoved is returned when transmitting to a card that was removed
+	ErrCardRemoved = errors.New("card removed")
+
+	// ErrReaderBusy is returned when another process holds the reader exclusively
+	ErrReaderBusy = errors.New("reader busy")
+
+	// ErrInvalidTLV is returned when BER-TLV data could not be parsed
+	ErrInvalidTLV = errors.New("invalid TLV data")
+
+	// ErrInvalidNDEF is returned when an NDEF message could not be parsed
+	ErrInvalidNDEF = errors.New("invalid NDEF message")
+
+	// ErrInvalidAccessBits is returned when the access bits of a MIFARE
+	// Classic sector trailer do not match their inverted copy
+	ErrInvalidAccessBits = errors.New("invalid access bits")
+
+	// ErrInvalidCredential is returned when a credential read from a card
+	// is corrupt
+	ErrInvalidCredential = errors.New("invalid credential")
+
+	// ErrPCSCUnavailable is returned when the PC/SC service is not running.
+	// The original ErrNoService or ErrServiceStopped error is preserved.
+	ErrPCSCUnavailable = errors.New("PC/SC service unavailable")
+)
+
+// Errors returned by the PC/SC layer, re-exported so they can be
+// matched using errors.Is without importing the scard package
+var (
+	ErrCancelled          error = scard.ErrCancelled
+	ErrInvalidHandle      error = scard.ErrInvalidHandle
+	ErrUnknownReader      error = scard.ErrUnknownReader
+	ErrTimeout            error = scard.ErrTimeout
+	ErrSharingViolation   error = scard.ErrSharingViolation
+	ErrNoSmartcard        error = scard.ErrNoSmartcard
+	ErrReaderUnavailable  error = scard.ErrReaderUnavailable
+	ErrNoService          error = scard.ErrNoService
+	ErrServiceStopped     error = scard.ErrServiceStopped
+	ErrNoReadersAvailable error = scard.ErrNoReadersAvailable
+	ErrUnresponsiveCard   error = scard.ErrUnresponsiveCard
+	ErrUnpoweredCard      error = scard.ErrUnpoweredCard
+	ErrResetCard          error = scard.ErrResetCard
+	ErrRemovedCard        error = scard.ErrRemovedCard
 )
 
+// pcscGuidance explains how to fix ErrPCSCUnavailable
+const pcscGuidance = "make sure the PC/SC daemon is running, on Linux start pcscd (systemctl start pcscd.socket)"
+
+// pcscUnavailableError is a PC/SC service error that is also ErrPCSCUnavailable
+type pcscUnavailableError struct {
+	err error
+}
+
+func (e pcscUnavailableError) Error() string {
+	return fmt.Sprintf("%v, %v [%v]", ErrPCSCUnavailable, pcscGuidance, e.err)
+}
+
+func (e pcscUnavailableError) Is(target error) bool {
+	return target == ErrPCSCUnavailable
+}
+
+func (e pcscUnavailableError) Unwrap() error {
+	return e.err
+}
+
+// pcscError wraps PC/SC service errors so they are also ErrPCSCUnavailable
+func pcscError(err error) error {
+	if errors.Is(err, scard.ErrNoService) || errors.Is(err, scard.ErrServiceStopped) {
+		return pcscUnavailableError{err}
+	}
+
+	return err
+}
+
 func wrapError(message string, err error) error {
 	switch v := err.(type) {
 	case scard.Error:
@@ -0,0 +1,51 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestReexportedErrors(t *testing.T) {
+	for _, tc := range []struct {
+		scard error
+		err   error
+	}{
+		{scard.ErrCancelled, ErrCancelled},
+		{scard.ErrInvalidHandle, ErrInvalidHandle},
+		{scard.ErrUnknownReader, ErrUnknownReader},
+		{scard.ErrTimeout, ErrTimeout},
+		{scard.ErrSharingViolation, ErrSharingViolation},
+		{scard.ErrNoSmartcard, ErrNoSmartcard},
+		{scard.ErrReaderUnavailable, ErrReaderUnavailable},
+		{scard.ErrNoService, ErrNoService},
+		{scard.ErrServiceStopped, ErrServiceStopped},
+		{scard.ErrNoReadersAvailable, ErrNoReadersAvailable},
+		{scard.ErrUnresponsiveCard, ErrUnresponsiveCard},
+		{scard.ErrUnpoweredCard, ErrUnpoweredCard},
+		{scard.ErrResetCard, ErrResetCard},
+		{scard.ErrRemovedCard, ErrRemovedCard},
+	} {
+		if err := wrapError("test", tc.scard); !errors.Is(err, tc.err) {
+			t.Fatalf("errors.Is(%v, %v) = false, want true", err, tc.err)
+		}
+	}
+
+	if errors.Is(scard.ErrRemovedCard, ErrResetCard) {
+		t.Fatalf("errors.Is(scard.ErrRemovedCard, ErrResetCard) = true, want false")
+	}
+}
+
+func TestPCSCError(t *testing.T) {
+	for _, err := range []error{scard.ErrNoService, scard.ErrServiceStopped} {
+		got := pcscError(wrapError("test", err))
+		if !errors.Is(got, ErrPCSCUnavailable) || !errors.Is(got, err) {
+			t.Fatalf("pcscError(%v) = %v, want ErrPCSCUnavailable and %v", err, got, err)
+		}
+	}
+
+	if err := pcscError(scard.ErrNoReadersAvailable); errors.Is(err, ErrPCSCUnavailable) || err != scard.ErrNoReadersAvailable {
+		t.Fatalf("pcscError(%v) = %v, want %v", scard.ErrNoReadersAvailable, err, scard.ErrNoReadersAvailable)
+	}
+}
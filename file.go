@@ -0,0 +1,53 @@
+package acr122u
+
+import "fmt"
+
+// readFileChunk is the number of bytes requested by each READ BINARY of
+// ReadFile, the reader limits responses to a short APDU
+const readFileChunk = 0xFF
+
+// readFileMax is the largest file ReadFile reads, as READ BINARY offsets
+// are limited to 15 bits
+const readFileMax = 0x8000
+
+// ReadFile selects the ISO7816 elementary file by file ID and reads it
+// with READ BINARY at increasing offsets until the end of the file, which
+// is reached on a short response, 0x62 0x82 (end of file) or 0x6B 0x00
+// (offset beyond the end of the file).
+func (c *card) ReadFile(fid [2]byte) ([]byte, error) {
+	if err := c.selectFile(fid); err != nil {
+		return nil, err
+	}
+
+	var file []byte
+
+	for le := readFileChunk; ; {
+		if len(file) >= readFileMax {
+			return nil, wrapError(fmt.Sprintf("file %X larger than %d bytes", fid, readFileMax), ErrCapacityExceeded)
+		}
+
+		offset := len(file)
+		data, sw, err := c.transmitSW([]byte{0x00, 0xB0, byte(offset >> 8), byte(offset), byte(le)})
+		if err != nil {
+			return nil, wrapError(fmt.Sprintf("read file %X at %d", fid, offset), err)
+		}
+
+		switch {
+		case sw == uint16(SWSuccess):
+			file = append(file, data...)
+			if len(data) < le {
+				return file, nil
+			}
+			le = readFileChunk
+		case sw == uint16(SWEndOfFile):
+			return append(file, data...), nil
+		case sw == uint16(SWWrongP1P2) && offset > 0:
+			return file, nil
+		case sw&0xFF00 == 0x6C00 && le != int(sw&0xFF) && sw&0xFF != 0:
+			// Wrong Le, the byte count left in the file is returned
+			le = int(sw & 0xFF)
+		default:
+			return nil, wrapError(fmt.Sprintf("read file %X at %d %s", fid, offset, statusText(sw)), ErrOperationFailed)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// fileCard returns a card holding the file with ID 01 1E, answering READ
+// BINARY beyond the end with eofSW, or with the short data if eofSW is 0
+func fileCard(file []byte, eofSW []byte) (*card, *[]int) {
+	var (
+		offsets  []int
+		selected bool
+	)
+
+	return transmitCard(func(cmd []byte) ([]byte, error) {
+		switch {
+		case bytes.Equal(cmd, []byte{0x00, 0xA4, 0x00, 0x0C, 0x02, 0x01, 0x1E}):
+			selected = true
+			return rcOperationSuccess, nil
+		case !selected:
+			return []byte{0x6A, 0x82}, nil
+		case cmd[1] == 0xB0:
+			offset, le := int(cmd[2])<<8|int(cmd[3]), int(cmd[4])
+			offsets = append(offsets, offset)
+			if offset >= len(file) {
+				return []byte{0x6B, 0x00}, nil
+			}
+			end := offset + le
+			if end > len(file) {
+				if eofSW != nil {
+					return append(append([]byte{}, file[offset:]...), eofSW...), nil
+				}
+				end = len(file)
+			}
+			return append(append([]byte{}, file[offset:end]...), rcOperationSuccess...), nil
+		default:
+			return rcOperationFailed, nil
+		}
+	}), &offsets
+}
+
+func TestCardReadFile(t *testing.T) {
+	file := make([]byte, 600)
+	for i := range file {
+		file[i] = byte(i)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		file    []byte
+		eofSW   []byte
+		offsets []int
+	}{
+		{"Short response", file, nil, []int{0, 255, 510}},
+		{"End of file", file, []byte{0x62, 0x82}, []int{0, 255, 510}},
+		{"Wrong offset", file[:510], nil, []int{0, 255, 510}},
+		{"Single read", file[:16], nil, []int{0}},
+		{"Empty", nil, nil, []int{0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, offsets := fileCard(tc.file, tc.eofSW)
+
+			got, err := c.ReadFile([2]byte{0x01, 0x1E})
+			if tc.file == nil {
+				if !errors.Is(err, ErrOperationFailed) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tc.file) {
+				t.Fatalf("ReadFile() = %d bytes, want %d", len(got), len(tc.file))
+			}
+			if len(*offsets) != len(tc.offsets) {
+				t.Fatalf("offsets = %v, want %v", *offsets, tc.offsets)
+			}
+			for i := range tc.offsets {
+				if (*offsets)[i] != tc.offsets[i] {
+					t.Fatalf("offsets = %v, want %v", *offsets, tc.offsets)
+				}
+			}
+		})
+	}
+
+	t.Run("Wrong Le", func(t *testing.T) {
+		c := transmitCard(func(cmd []byte) ([]byte, error) {
+			switch {
+			case cmd[1] == 0xA4:
+				return rcOperationSuccess, nil
+			case cmd[3] >= 0x20:
+				return []byte{0x6B, 0x00}, nil
+			case cmd[4] != 0x20:
+				return []byte{0x6C, 0x20}, nil
+			default:
+				return append(bytes.Repeat([]byte{0xAB}, 0x20), rcOperationSuccess...), nil
+			}
+		})
+
+		got, err := c.ReadFile([2]byte{0x01, 0x1E})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := bytes.Repeat([]byte{0xAB}, 0x20); !bytes.Equal(got, want) {
+			t.Fatalf("ReadFile() = %X, want %X", got, want)
+		}
+	})
+
+	t.Run("File not found", func(t *testing.T) {
+		c, _ := fileCard(file, nil)
+
+		if _, err := c.ReadFile([2]byte{0x01, 0x1F}); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package acr122u
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// fileSinkBuffer is the number of card events buffered while writing to disk
+const fileSinkBuffer = 64
+
+// fileSink appends card events as newline delimited JSON to a file,
+// rotating it to path.1, path.2, ... when it would exceed maxBytes
+type fileSink struct {
+	path     string
+	maxBytes int64
+	events   chan cardEvent
+	running  atomic.Bool
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+}
+
+func newFileSink(path string, maxBytes int64) *fileSink {
+	return &fileSink{
+		path:     path,
+		maxBytes: maxBytes,
+		events:   make(chan cardEvent, fileSinkBuffer),
+	}
+}
+
+// publish queues the event, dropping it if the buffer is full
+func (s *fileSink) publish(e cardEvent) bool {
+	select {
+	case s.events <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// run writes queued events, flushing them once the buffer is drained.
+// Events still queued when ctx is done are written before the file is closed.
+// Only one run writes the file, a concurrent run returns immediately.
+func (s *fileSink) run(ctx context.Context, logger zerolog.Logger) {
+	logger = logger.With().Str("Caller", "fileSink").Str("Path", s.path).Logger()
+
+	if !s.running.CompareAndSwap(false, true) {
+		logger.Debug().Msg("Already running")
+		return
+	}
+	defer s.running.Store(false)
+
+	defer func() {
+		if err := s.close(); err != nil {
+			logger.Error().Err(err).Msg("Problem closing file")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.drain(logger)
+			return
+		case e := <-s.events:
+			if !s.writeEvent(e, logger) {
+				continue
+			}
+		}
+
+		if len(s.events) == 0 {
+			if err := s.writer.Flush(); err != nil {
+				logger.Error().Err(err).Msg("Problem flushing card events")
+			}
+		}
+	}
+}
+
+// drain writes the events left in the buffer without waiting for more
+func (s *fileSink) drain(logger zerolog.Logger) {
+	for {
+		select {
+		case e := <-s.events:
+			s.writeEvent(e, logger)
+		default:
+			return
+		}
+	}
+}
+
+// writeEvent encodes and writes the event, reporting whether it was written
+func (s *fileSink) writeEvent(e cardEvent, logger zerolog.Logger) bool {
+	line, err := json.Marshal(e)
+	if err != nil {
+		logger.Error().Err(err).Msg("Problem encoding card event")
+		return false
+	}
+	line = append(line, '\n')
+
+	if err := s.write(line); err != nil {
+		logger.Error().Err(err).Msg("Problem writing card event")
+		return false
+	}
+
+	return true
+}
+
+// write appends the line, opening or rotating the file first if needed
+func (s *fileSink) write(line []byte) error {
+	if s.file != nil && s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.writer.Write(line)
+	s.size += int64(n)
+
+	return err
+}
+
+// open opens the file for appending, creating it if it is absent
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.file, s.writer, s.size = f, bufio.NewWriter(f), fi.Size()
+
+	return nil
+}
+
+// close flushes and closes the file, if open
+func (s *fileSink) close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.writer.Flush()
+	if err2 := s.file.Close(); err == nil {
+		err = err2
+	}
+	s.file, s.writer = nil, nil
+
+	return err
+}
+
+// rotate closes the file and shifts path to path.1, path.1 to path.2, etc.
+// The file is reopened by the next write.
+func (s *fileSink) rotate() error {
+	if err := s.close(); err != nil {
+		return err
+	}
+
+	n := 1
+	for ; ; n++ {
+		if _, err := os.Stat(s.rotated(n)); err != nil {
+			break
+		}
+	}
+
+	for ; n > 1; n-- {
+		if err := os.Rename(s.rotated(n-1), s.rotated(n)); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(s.path, s.rotated(1))
+}
+
+func (s *fileSink) rotated(n int) string {
+	return fmt.Sprintf("%s.%d", s.path, n)
+}
@@ -0,0 +1,231 @@
+package acr122u
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+	"github.com/rs/zerolog"
+)
+
+func TestFileSinkRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.log")
+
+	line, err := json.Marshal(cardEvent{Reader: "Test", UID: "00"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := newFileSink(path, int64(2*(len(line)+1)))
+
+	for _, uid := range []string{"01", "02", "03", "04", "05"} {
+		line, err := json.Marshal(cardEvent{Reader: "Test", UID: uid})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := s.write(append(line, '\n')); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := s.close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		uids []string
+	}{
+		{path + ".2", []string{"01", "02"}},
+		{path + ".1", []string{"03", "04"}},
+		{path, []string{"05"}},
+	} {
+		if got := readFileSinkUIDs(t, tc.path); !stringsEqual(got, tc.uids) {
+			t.Fatalf("%s UIDs = %q, want %q", filepath.Base(tc.path), got, tc.uids)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFileSinkRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.log")
+	s := newFileSink(path, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		s.run(ctx, zerolog.Nop())
+		close(done)
+	}()
+
+	s.publish(cardEvent{Reader: "Test", UID: "83fb582490"})
+
+	for deadline := time.Now().Add(time.Second); ; {
+		if got := readFileSinkUIDs(t, path); len(got) == 1 {
+			if got[0] != "83fb582490" {
+				t.Fatalf("UIDs = %q, want [83fb582490]", got)
+			}
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("card event not written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestFileSinkRunDrain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.log")
+	s := newFileSink(path, 0)
+
+	uids := []string{"01", "02", "03"}
+	for _, uid := range uids {
+		s.publish(cardEvent{Reader: "Test", UID: uid})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.run(ctx, zerolog.Nop())
+
+	if got := readFileSinkUIDs(t, path); !stringsEqual(got, uids) {
+		t.Fatalf("UIDs = %q, want %q", got, uids)
+	}
+}
+
+func TestFileSinkRunOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.log")
+	s := newFileSink(path, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		s.run(ctx, zerolog.Nop())
+		close(done)
+	}()
+
+	for !s.running.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan struct{})
+	go func() {
+		s.run(context.Background(), zerolog.Nop())
+		close(second)
+	}()
+
+	select {
+	case <-second:
+	case <-time.After(time.Second):
+		t.Fatalf("second run did not return")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestContextServeFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.log")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent),
+	}, WithFileSink(path, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.ServeFunc(ctx, func(Card) { cancel() }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := readFileSinkUIDs(t, path), []string{hex.EncodeToString(testUID)}; !stringsEqual(got, want) {
+		t.Fatalf("UIDs = %q, want %q", got, want)
+	}
+}
+
+func TestContextServeWaitsForSinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sink := &slowSink{}
+	actx.sinks = append(actx.sinks, sink)
+
+	if err := actx.ServeFunc(ctx, func(Card) { cancel() }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !sink.stopped.Load() {
+		t.Fatalf("Serve returned before the sink stopped")
+	}
+}
+
+// slowSink is a cardSink taking a while to stop once ctx is done
+type slowSink struct {
+	stopped atomic.Bool
+}
+
+func (s *slowSink) publish(cardEvent) bool {
+	return true
+}
+
+func (s *slowSink) run(ctx context.Context, logger zerolog.Logger) {
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	s.stopped.Store(true)
+}
+
+// readFileSinkUIDs returns the UIDs of the card events in the file
+func readFileSinkUIDs(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	var uids []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e cardEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		uids = append(uids, e.UID)
+	}
+
+	return uids
+}
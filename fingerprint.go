@@ -0,0 +1,60 @@
+package acr122u
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+)
+
+// Fingerprint returns a hex encoded SHA-256 hash of the identifying fields of
+// the card, to tell cards apart more reliably than by UID alone:
+//
+//   - all cards: the UID and the ATR
+//   - ISO14443-A cards (all but FeliCa and Topaz): the SAK
+//   - NTAG21x and MIFARE Ultralight EV1: the originality signature, which
+//     is omitted for Ultralight tags without one
+//
+// Cards presenting a random UID, see UIDIsRandom, get a new fingerprint on
+// every tap, so their fingerprints cannot be compared across reads.
+func (c *card) Fingerprint() (string, error) {
+	s, err := c.Status()
+	if err != nil {
+		return "", err
+	}
+
+	t := cardTypeFromATR(s.Atr)
+	fields := [][]byte{c.uid, s.Atr}
+
+	if t != CardTypeFeliCa && t != CardTypeTopaz {
+		_, sak, err := c.target()
+		if err != nil {
+			return "", wrapError("fingerprint SAK", err)
+		}
+		fields = append(fields, []byte{sak})
+	}
+
+	if t == CardTypeMifareUltralight {
+		sig, err := c.ReadSignature()
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			return "", wrapError("fingerprint signature", err)
+		}
+		fields = append(fields, sig)
+	}
+
+	return fingerprint(fields...), nil
+}
+
+// fingerprint hashes the length prefixed fields, so moving bytes from one
+// field to the next changes the hash
+func fingerprint(fields ...[]byte) string {
+	h := sha256.New()
+	for _, f := range fields {
+		var n [2]byte
+		binary.BigEndian.PutUint16(n[:], uint16(len(f)))
+		h.Write(n[:])
+		h.Write(f)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
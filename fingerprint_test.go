@@ -0,0 +1,91 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestCardFingerprint(t *testing.T) {
+	fingerprintOf := func(t *testing.T, m *mockNTAG) string {
+		t.Helper()
+		c := m.card()
+		c.uid = testUID
+
+		fp, err := c.Fingerprint()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return fp
+	}
+
+	signed := func() *mockNTAG {
+		m := newMockNTAG(ntag213Pages)
+		m.signature = testOriginalitySignature
+		return m
+	}
+
+	want := fingerprintOf(t, signed())
+	if len(want) != 64 {
+		t.Fatalf("fingerprint = %q, want 64 hex digits", want)
+	}
+
+	if got := fingerprintOf(t, signed()); got != want {
+		t.Fatalf("identical cards: fingerprint = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(m *mockNTAG)
+	}{
+		{"Signature", func(m *mockNTAG) { m.signature[0] ^= 0xFF }},
+		{"No signature", func(m *mockNTAG) { m.signature = nil }},
+		{"ATR", func(m *mockNTAG) { m.atr = atrMifareClassic1K }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := signed()
+			m.signature = append([]byte{}, m.signature...)
+			tc.modify(m)
+
+			if got := fingerprintOf(t, m); got == want {
+				t.Fatalf("fingerprint = %q, want different", got)
+			}
+		})
+	}
+
+	t.Run("UID", func(t *testing.T) {
+		c := signed().card()
+		c.uid = []byte{0x08, 0x01, 0x02, 0x03}
+
+		got, err := c.Fingerprint()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got == want {
+			t.Fatalf("fingerprint = %q, want different", got)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		m := signed()
+		c := newCard("Test", &mockCard{
+			transmit: func([]byte) ([]byte, error) { return nil, scard.ErrRemovedCard },
+			status:   atrStatus(m.atr),
+		})
+
+		if _, err := c.Fingerprint(); !errors.Is(err, scard.ErrRemovedCard) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestFingerprintFieldBoundaries(t *testing.T) {
+	if fingerprint([]byte{0x01, 0x02}, []byte{0x03}) == fingerprint([]byte{0x01}, []byte{0x02, 0x03}) {
+		t.Fatal("fingerprints of differently split fields are equal")
+	}
+}
@@ -0,0 +1,46 @@
+package acr122u
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// HaltAndReactivate halts the card (ISO14443-3 HLTA) and activates it again
+// using InListPassiveTarget, which wakes halted cards (WUPA) and runs a new
+// anticollision, starting a new session with the same card without removing
+// it. MIFARE Classic authentication is lost. Returns ErrCardRemoved if the
+// card does not reappear, and ErrOperationFailed if a different card does.
+func (c *card) HaltAndReactivate() error {
+	c.authenticated = false
+
+	resp, err := c.pn532(0x42, 0x50, 0x00)
+	if err != nil {
+		return wrapError("halt", err)
+	}
+
+	// A halted card does not answer, which the PN532 reports as a timeout
+	if len(resp) < 1 || (resp[0]&0x3F != 0x00 && resp[0]&0x3F != 0x01) {
+		return wrapError(fmt.Sprintf("halt response %X", resp), ErrOperationFailed)
+	}
+
+	resp, err = c.pn532(0x4A, 0x01, 0x00)
+	if err != nil {
+		return wrapError("reactivate", err)
+	}
+
+	// NbTg Tg SENS_RES(2) SEL_RES NFCIDLength NFCID ...
+	if len(resp) < 1 || resp[0] == 0x00 {
+		return wrapError("card did not reappear", ErrCardRemoved)
+	}
+
+	if len(resp) < 6 || len(resp) < 6+int(resp[5]) {
+		return wrapError(fmt.Sprintf("reactivate response %X", resp), ErrOperationFailed)
+	}
+
+	uid := resp[6 : 6+int(resp[5])]
+	if !c.UIDIsRandom() && len(c.uid) > 0 && !bytes.Equal(uid, c.uid) {
+		return wrapError(fmt.Sprintf("reactivated card %X", uid), ErrOperationFailed)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCardHaltAndReactivate(t *testing.T) {
+	var (
+		halt     = []byte{0xFF, 0x00, 0x00, 0x00, 0x04, 0xD4, 0x42, 0x50, 0x00}
+		activate = []byte{0xFF, 0x00, 0x00, 0x00, 0x04, 0xD4, 0x4A, 0x01, 0x00}
+		target   = append([]byte{0xD5, 0x4B, 0x01, 0x01, 0x00, 0x44, 0x00, 0x07}, testUID7...)
+	)
+
+	for _, tc := range []struct {
+		name     string
+		halt     []byte
+		activate []byte
+		err      error
+	}{
+		{"Reactivated", []byte{0xD5, 0x43, 0x01}, target, nil},
+		{"Halt answered", []byte{0xD5, 0x43, 0x00}, target, nil},
+		{"Halt failed", []byte{0xD5, 0x43, 0x02}, target, ErrOperationFailed},
+		{"Not reappearing", []byte{0xD5, 0x43, 0x01}, []byte{0xD5, 0x4B, 0x00}, ErrCardRemoved},
+		{"Other card", []byte{0xD5, 0x43, 0x01}, []byte{0xD5, 0x4B, 0x01, 0x01, 0x00, 0x04, 0x08, 0x04, 0x01, 0x02, 0x03, 0x04}, ErrOperationFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var sent [][]byte
+
+			c := transmitCard(func(cmd []byte) ([]byte, error) {
+				sent = append(sent, cmd)
+				if cmd[6] == 0x42 {
+					return append(append([]byte{}, tc.halt...), rcOperationSuccess...), nil
+				}
+				return append(append([]byte{}, tc.activate...), rcOperationSuccess...), nil
+			})
+			c.uid = testUID7
+			c.authenticated = true
+
+			err := c.HaltAndReactivate()
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(sent[0], halt) {
+				t.Fatalf("sent[0] = %X, want %X", sent[0], halt)
+			}
+			if len(sent) > 1 && !bytes.Equal(sent[1], activate) {
+				t.Fatalf("sent[1] = %X, want %X", sent[1], activate)
+			}
+			if c.authenticated {
+				t.Fatalf("c.authenticated = true, want false")
+			}
+		})
+	}
+}
+
+var testUID7 = []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
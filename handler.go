@@ -1,5 +1,7 @@
 package acr122u
 
+import "context"
+
 // Handler is the interface that handles each card when present in the field.
 type Handler interface {
 	ServeCard(Card)
@@ -12,3 +14,67 @@ type HandlerFunc func(Card)
 func (hf HandlerFunc) ServeCard(c Card) {
 	hf(c)
 }
+
+// ContextHandler is a Handler that also accepts a context, which is
+// cancelled when Serve stops or the handler timeout expires.
+// Serve calls ServeCardContext instead of ServeCard when implemented.
+type ContextHandler interface {
+	Handler
+	ServeCardContext(ctx context.Context, c Card) error
+}
+
+// ContextHandlerFunc is the function signature for handling a card with a context
+type ContextHandlerFunc func(context.Context, Card) error
+
+// ServeCard makes ContextHandlerFunc implement the Handler interface
+func (hf ContextHandlerFunc) ServeCard(c Card) {
+	_ = hf(context.Background(), c)
+}
+
+// ServeCardContext makes ContextHandlerFunc implement the ContextHandler interface
+func (hf ContextHandlerFunc) ServeCardContext(ctx context.Context, c Card) error {
+	return hf(ctx, c)
+}
+
+// serveCard calls the handler with ctx if it is a ContextHandler
+func serveCard(ctx context.Context, h Handler, c Card) error {
+	if ch, ok := h.(ContextHandler); ok {
+		return ch.ServeCardContext(ctx, c)
+	}
+
+	h.ServeCard(c)
+
+	return nil
+}
+
+// Middleware wraps a Handler with cross-cutting behavior
+type Middleware func(Handler) Handler
+
+// chainMiddleware wraps h in the middleware, the first middleware being the outermost.
+// Middleware returning a plain Handler hides the ContextHandler it wraps.
+func chainMiddleware(h Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+
+	return h
+}
+
+// readerHandlers routes each card to the handler of its reader, see ServeMap
+type readerHandlers map[string]Handler
+
+// ServeCard makes readerHandlers implement the Handler interface
+func (rh readerHandlers) ServeCard(c Card) {
+	if h, ok := rh[c.Reader()]; ok {
+		h.ServeCard(c)
+	}
+}
+
+// ServeCardContext makes readerHandlers implement the ContextHandler interface
+func (rh readerHandlers) ServeCardContext(ctx context.Context, c Card) error {
+	if h, ok := rh[c.Reader()]; ok {
+		return serveCard(ctx, h, c)
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package acr122u
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+)
 
 func TestHandlerFuncServeCard(t *testing.T) {
 	var handled bool
@@ -15,3 +19,46 @@ func TestHandlerFuncServeCard(t *testing.T) {
 		t.Fatalf("card was not handled")
 	}
 }
+
+func TestServeCard(t *testing.T) {
+	errHandler := errors.New("handler")
+
+	var handled bool
+
+	if err := serveCard(context.Background(), HandlerFunc(func(Card) {
+		handled = true
+	}), nil); err != nil || !handled {
+		t.Fatalf("serveCard() = %v, handled %v", err, handled)
+	}
+
+	if err := serveCard(context.Background(), ContextHandlerFunc(func(context.Context, Card) error {
+		return errHandler
+	}), nil); err != errHandler {
+		t.Fatalf("got = %v, want %v", err, errHandler)
+	}
+}
+
+func TestChainMiddleware(t *testing.T) {
+	var calls []string
+
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(c Card) {
+				calls = append(calls, name+" before")
+				next.ServeCard(c)
+				calls = append(calls, name+" after")
+			})
+		}
+	}
+
+	h := chainMiddleware(HandlerFunc(func(Card) {
+		calls = append(calls, "handler")
+	}), mw("first"), mw("second"))
+
+	h.ServeCard(nil)
+
+	want := []string{"first before", "second before", "handler", "second after", "first after"}
+	if !stringsEqual(calls, want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
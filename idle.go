@@ -0,0 +1,52 @@
+package acr122u
+
+import (
+	"sync"
+	"time"
+)
+
+// idleTracker calls fn when no card has been read for after,
+// and every after thereafter until a card is read.
+// A nil *idleTracker is valid and tracks nothing.
+type idleTracker struct {
+	mu    sync.Mutex
+	after time.Duration
+	fn    func(idle time.Duration)
+	last  time.Time
+	next  time.Time
+}
+
+func newIdleTracker(after time.Duration, fn func(idle time.Duration)) *idleTracker {
+	return &idleTracker{after: after, fn: fn}
+}
+
+// reset marks now as the time of the last card read
+func (it *idleTracker) reset(now time.Time) {
+	if it == nil {
+		return
+	}
+
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.last = now
+	it.next = now.Add(it.after)
+}
+
+// check calls fn if the idle threshold has been reached
+func (it *idleTracker) check(now time.Time) {
+	if it == nil {
+		return
+	}
+
+	it.mu.Lock()
+	if now.Before(it.next) {
+		it.mu.Unlock()
+		return
+	}
+	it.next = now.Add(it.after)
+	idle := now.Sub(it.last)
+	it.mu.Unlock()
+
+	it.fn(idle)
+}
@@ -0,0 +1,59 @@
+package acr122u
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleTracker(t *testing.T) {
+	var idles []time.Duration
+
+	clk := newMockClock()
+	it := newIdleTracker(3*time.Second, func(idle time.Duration) {
+		idles = append(idles, idle)
+	})
+
+	it.reset(clk.Now())
+
+	for i := 0; i < 7; i++ {
+		clk.Advance(time.Second)
+		it.check(clk.Now())
+	}
+
+	if want := []time.Duration{3 * time.Second, 6 * time.Second}; !durationsEqual(idles, want) {
+		t.Fatalf("idles = %v, want %v", idles, want)
+	}
+
+	idles = nil
+	it.reset(clk.Now())
+
+	for i := 0; i < 2; i++ {
+		clk.Advance(time.Second)
+		it.check(clk.Now())
+	}
+
+	if len(idles) != 0 {
+		t.Fatalf("idles = %v, want none after reset", idles)
+	}
+}
+
+func TestIdleTrackerNil(t *testing.T) {
+	var it *idleTracker
+
+	it.reset(time.Now())
+	it.check(time.Now())
+}
+
+func durationsEqual(a, b []time.Duration) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,9 +1,11 @@
 package acr122u
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/ebfe/scard"
 	"github.com/rs/zerolog"
@@ -26,6 +28,76 @@ var (
 	ConsoleLogger           = zerolog.ConsoleWriter{Out: os.Stderr}
 )
 
+// newLogger creates the instance logger using the configured log options.
+// The logger passes all levels to the log output, which filters them so
+// Apply can change the level of loggers in use.
+func (actx *Context) newLogger() zerolog.Logger {
+	actx.logOut = &logOutput{}
+	actx.configureLogOutput()
+
+	return zerolog.New(actx.logOut).
+		Level(zerolog.TraceLevel).
+		With().Timestamp().Logger()
+}
+
+// configureLogOutput applies the log options to the log output
+func (actx *Context) configureLogOutput() {
+	if cw, ok := actx.logWriter.(zerolog.ConsoleWriter); ok {
+		if actx.logTimeFormat != "" {
+			cw.TimeFormat = actx.logTimeFormat
+		}
+		if actx.logNoColor {
+			cw.NoColor = true
+		}
+		actx.logWriter = cw
+	}
+
+	actx.logOut.set(zerolog.Level(actx.logLevel), actx.logWriter)
+}
+
+// logOutput writes the log events at or above its level to its writer
+type logOutput struct {
+	mu    sync.RWMutex
+	level zerolog.Level
+	w     io.Writer
+}
+
+// set replaces the level and the writer
+func (o *logOutput) set(level zerolog.Level, w io.Writer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.level, o.w = level, w
+}
+
+// enabled reports whether events of the level are written
+func (o *logOutput) enabled(level zerolog.Level) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return level >= o.level && o.level != zerolog.Disabled
+}
+
+func (o *logOutput) Write(p []byte) (int, error) {
+	return o.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel makes logOutput a zerolog.LevelWriter
+func (o *logOutput) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if !o.enabled(level) {
+		return len(p), nil
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if lw, ok := o.w.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+
+	return o.w.Write(p)
+}
+
 func formatStateFlag(sf scard.StateFlag) string {
 	var stateStrings []string
 
@@ -67,3 +139,9 @@ func formatStateFlag(sf scard.StateFlag) string {
 	}
 	return strings.Join(stateStrings, " & ")
 }
+
+// formatStateFlagHex formats the raw state value followed by the state names,
+// for example 0x0012 (StateChanged & StateEmpty)
+func formatStateFlagHex(sf scard.StateFlag) string {
+	return fmt.Sprintf("0x%04X (%s)", uint32(sf), formatStateFlag(sf))
+}
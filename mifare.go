@@ -0,0 +1,314 @@
+package acr122u
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// KeyType selects the MIFARE Classic key used for authentication
+type KeyType byte
+
+// Key types
+const (
+	KeyA KeyType = 0x60
+	KeyB KeyType = 0x61
+)
+
+// mifareBlockSize is the size of a MIFARE Classic block in bytes
+const mifareBlockSize = 16
+
+// Authenticate loads the key into the reader and authenticates the sector
+// containing the block, which is required before reading or writing it.
+func (c *card) Authenticate(block byte, key [6]byte, keyType KeyType) error {
+	if _, err := c.transmit(append([]byte{0xFF, 0x82, 0x00, 0x00, 0x06}, key[:]...)); err != nil {
+		return wrapError("load key", err)
+	}
+
+	c.authenticated = false
+
+	if _, err := c.transmit([]byte{0xFF, 0x86, 0x00, 0x00, 0x05, 0x01, 0x00, block, byte(keyType), 0x00}); err != nil {
+		return wrapError(fmt.Sprintf("authenticate block %d", block), c.mifarePlusAuthError(err))
+	}
+
+	c.authenticated = true
+	c.authSector = int(SectorForBlock(block))
+
+	return nil
+}
+
+// ReadBlock reads a 16 byte block from an authenticated sector
+func (c *card) ReadBlock(block byte) ([]byte, error) {
+	resp, err := c.transmit([]byte{0xFF, 0xB0, 0x00, block, mifareBlockSize})
+	if err != nil {
+		return nil, wrapError(fmt.Sprintf("read block %d", block), err)
+	}
+
+	if len(resp) != mifareBlockSize {
+		return nil, wrapError(fmt.Sprintf("read block %d response %X", block, resp), ErrOperationFailed)
+	}
+
+	return resp, nil
+}
+
+// WriteBlock writes a 16 byte block to an authenticated sector
+func (c *card) WriteBlock(block byte, data []byte) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	if len(data) != mifareBlockSize {
+		return wrapError("block data length", ErrInvalidParameter)
+	}
+
+	resp, err := c.transmit(append([]byte{0xFF, 0xD6, 0x00, block, mifareBlockSize}, data...))
+	if err != nil {
+		return wrapError(fmt.Sprintf("write block %d", block), err)
+	}
+
+	if len(resp) != 0 {
+		return wrapError(fmt.Sprintf("write block %d response %X", block, resp), ErrOperationFailed)
+	}
+
+	if c.verifyWrites && block != TrailerBlock(SectorForBlock(block)) {
+		got, err := c.ReadBlock(block)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, data) {
+			return wrapError(fmt.Sprintf("block %d read back %X", block, got), ErrWriteVerifyFailed)
+		}
+	}
+
+	return nil
+}
+
+// StoreData stores the data across the data blocks of a MIFARE Classic card.
+//
+// The data is prefixed with a two byte big endian length header and written
+// to the data blocks starting at sector 1, skipping sector trailers. Sector 0
+// (manufacturer block and MAD) is left untouched. All sectors are
+// authenticated using the same key.
+func (c *card) StoreData(data []byte, key [6]byte, keyType KeyType) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	blocks, err := c.mifareDataBlocks()
+	if err != nil {
+		return err
+	}
+
+	c.authenticated = false
+
+	payload := make([]byte, 2, 2+len(data))
+	binary.BigEndian.PutUint16(payload, uint16(len(data)))
+	payload = append(payload, data...)
+
+	if len(data) > 0xFFFF || len(payload) > len(blocks)*mifareBlockSize {
+		return ErrCapacityExceeded
+	}
+
+	for i := 0; i*mifareBlockSize < len(payload); i++ {
+		block := make([]byte, mifareBlockSize)
+		copy(block, payload[i*mifareBlockSize:])
+
+		if err := c.authenticateSector(blocks[i], key, keyType); err != nil {
+			return err
+		}
+
+		if err := c.WriteBlock(blocks[i], block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadData loads data stored using StoreData
+func (c *card) LoadData(key [6]byte, keyType KeyType) ([]byte, error) {
+	return c.LoadDataWithProgress(nil, key, keyType)
+}
+
+// LoadDataWithProgress loads data like LoadData, calling fn with the number
+// of blocks read so far and in total after each one. The total is known once
+// the first block holding the length has been read. A nil fn is not called.
+func (c *card) LoadDataWithProgress(fn func(done, total int), key [6]byte, keyType KeyType) ([]byte, error) {
+	if fn == nil {
+		fn = func(int, int) {}
+	}
+
+	blocks, err := c.mifareDataBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+
+	c.authenticated = false
+
+	for i := 0; i < len(blocks); i++ {
+		if err := c.authenticateSector(blocks[i], key, keyType); err != nil {
+			return nil, err
+		}
+
+		block, err := c.ReadBlock(blocks[i])
+		if err != nil {
+			return nil, err
+		}
+
+		payload = append(payload, block...)
+
+		length := int(binary.BigEndian.Uint16(payload))
+		if 2+length > len(blocks)*mifareBlockSize {
+			return nil, wrapError("stored length", ErrCapacityExceeded)
+		}
+
+		fn(i+1, (2+length+mifareBlockSize-1)/mifareBlockSize)
+
+		if len(payload) >= 2+length {
+			return payload[2 : 2+length], nil
+		}
+	}
+
+	return nil, ErrCapacityExceeded
+}
+
+// authenticateSector authenticates the sector of the block unless it is
+// the sector authenticated last
+func (c *card) authenticateSector(block byte, key [6]byte, keyType KeyType) error {
+	if c.authenticated && c.authSector == int(SectorForBlock(block)) {
+		return nil
+	}
+
+	return c.Authenticate(block, key, keyType)
+}
+
+// mifareDataBlocks returns the data blocks available to StoreData
+func (c *card) mifareDataBlocks() ([]byte, error) {
+	sectors, err := c.mifareSectors()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []byte
+	for sector := byte(1); int(sector) < sectors; sector++ {
+		for block := firstBlockOfSector(sector); block < TrailerBlock(sector); block++ {
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks, nil
+}
+
+// mifareSectors returns the number of sectors of a MIFARE Classic or SL1
+// MIFARE Plus card, or ErrNotSupported for other cards
+func (c *card) mifareSectors() (int, error) {
+	s, err := c.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	t := cardTypeFromATR(s.Atr)
+	if t == CardTypeMifarePlus {
+		return mifarePlusSectorCount(s.Atr)
+	}
+
+	sectors := mifareSectorCount(t)
+	if sectors == 0 {
+		return 0, wrapError(t.String(), ErrNotSupported)
+	}
+
+	return sectors, nil
+}
+
+// validateSector returns ErrInvalidParameter if the sector does not exist
+// on the card, like ValidateSector, also accepting SL1 MIFARE Plus cards
+func (c *card) validateSector(sector byte) error {
+	sectors, err := c.mifareSectors()
+	if err != nil {
+		return err
+	}
+
+	if int(sector) >= sectors {
+		return wrapError(fmt.Sprintf("sector %d of %d", sector, sectors), ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// mifareSectorCount returns the number of sectors for the MIFARE Classic card type
+func mifareSectorCount(t CardType) int {
+	switch t {
+	case CardTypeMifareMini:
+		return 5
+	case CardTypeMifareClassic1K:
+		return 16
+	case CardTypeMifareClassic4K:
+		return 40
+	default:
+		return 0
+	}
+}
+
+// SectorForBlock returns the MIFARE Classic sector containing the block.
+// Sectors 0-31 have 4 blocks, sectors 32-39 (4K only) have 16 blocks.
+func SectorForBlock(block byte) byte {
+	if block < 128 {
+		return block / 4
+	}
+
+	return 32 + (block-128)/16
+}
+
+// TrailerBlock returns the sector trailer, the last block of the sector
+// holding the keys and access bits. Only sectors 0-39 exist, callers must
+// check the sector with ValidateSector first, as the block of a sector past
+// 39 wraps around to a block of another sector.
+func TrailerBlock(sector byte) byte {
+	return firstBlockOfSector(sector) + BlocksInSector(sector) - 1
+}
+
+// BlocksInSector returns the number of blocks in the sector, including the trailer
+func BlocksInSector(sector byte) byte {
+	if sector < 32 {
+		return 4
+	}
+
+	return 16
+}
+
+// ValidateSector returns ErrInvalidParameter if the sector does not exist
+// on a MIFARE Classic card of the type, or ErrNotSupported for other types
+func ValidateSector(t CardType, sector byte) error {
+	sectors := mifareSectorCount(t)
+	if sectors == 0 {
+		return wrapError(t.String(), ErrNotSupported)
+	}
+
+	if int(sector) >= sectors {
+		return wrapError(fmt.Sprintf("%v sector %d", t, sector), ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// ValidateBlock returns ErrInvalidParameter if the block does not exist
+// on a MIFARE Classic card of the type, or ErrNotSupported for other types
+func ValidateBlock(t CardType, block byte) error {
+	if err := ValidateSector(t, SectorForBlock(block)); err != nil {
+		return wrapError(fmt.Sprintf("block %d", block), err)
+	}
+
+	return nil
+}
+
+// firstBlockOfSector returns the first block of the sector, which like
+// TrailerBlock must be one of the sectors 0-39 checked by ValidateSector
+func firstBlockOfSector(sector byte) byte {
+	if sector < 32 {
+		return sector * 4
+	}
+
+	return 128 + (sector-32)*16
+}
@@ -0,0 +1,377 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestCardAuthenticate(t *testing.T) {
+	var cmds [][]byte
+
+	c := transmitCard(func(cmd []byte) ([]byte, error) {
+		cmds = append(cmds, cmd)
+		return rcOperationSuccess, nil
+	})
+
+	if err := c.Authenticate(0x05, testKey, KeyB); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, want := range [][]byte{
+		{0xFF, 0x82, 0x00, 0x00, 0x06, 0xA0, 0xA1, 0xA2, 0xA3, 0xA4, 0xA5},
+		{0xFF, 0x86, 0x00, 0x00, 0x05, 0x01, 0x00, 0x05, 0x61, 0x00},
+	} {
+		if !bytes.Equal(cmds[i], want) {
+			t.Fatalf("cmds[%d] = %X, want %X", i, cmds[i], want)
+		}
+	}
+}
+
+func TestCardReadWriteBlock(t *testing.T) {
+	m := newMockMifare(atrMifareClassic1K)
+	c := m.card()
+
+	data := bytes.Repeat([]byte{0x42}, 16)
+
+	t.Run("Not authenticated", func(t *testing.T) {
+		if _, err := c.ReadBlock(4); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Invalid length", func(t *testing.T) {
+		if err := c.WriteBlock(4, data[:15]); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := c.Authenticate(4, m.key, KeyA); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := c.WriteBlock(5, data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, err := c.ReadBlock(5)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(got, data) {
+			t.Fatalf("c.ReadBlock(5) = %X, want %X", got, data)
+		}
+	})
+}
+
+func TestCardVerifyWrites(t *testing.T) {
+	data := bytes.Repeat([]byte{0x42}, 16)
+
+	for _, tc := range []struct {
+		name    string
+		block   byte
+		corrupt bool
+		err     error
+	}{
+		{"Match", 5, false, nil},
+		{"Mismatch", 5, true, ErrWriteVerifyFailed},
+		{"Trailer", 7, true, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var auths int
+
+			m := newMockMifare(atrMifareClassic1K)
+			c := newCard("Test", &mockCard{
+				status: atrStatus(m.atr),
+				transmit: func(cmd []byte) ([]byte, error) {
+					resp, err := m.transmit(cmd)
+					switch {
+					case cmd[1] == 0x86:
+						auths++
+					case cmd[1] == 0xD6 && tc.corrupt:
+						m.blocks[cmd[3]][0] ^= 0xFF
+					}
+					return resp, err
+				},
+			})
+			c.verifyWrites = true
+
+			if err := c.Authenticate(tc.block, m.key, KeyA); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := c.WriteBlock(tc.block, data); !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if auths != 1 {
+				t.Fatalf("authentications = %d, want 1", auths)
+			}
+		})
+	}
+}
+
+func TestSectorGeometry(t *testing.T) {
+	for _, tc := range []struct {
+		block, sector, trailer, blocks byte
+	}{
+		{0, 0, 3, 4},
+		{3, 0, 3, 4},
+		{4, 1, 7, 4},
+		{63, 15, 63, 4},
+		{64, 16, 67, 4},
+		{127, 31, 127, 4},
+		{128, 32, 143, 16},
+		{143, 32, 143, 16},
+		{144, 33, 159, 16},
+		{255, 39, 255, 16},
+	} {
+		if got := SectorForBlock(tc.block); got != tc.sector {
+			t.Fatalf("SectorForBlock(%d) = %d, want %d", tc.block, got, tc.sector)
+		}
+
+		if got := TrailerBlock(tc.sector); got != tc.trailer {
+			t.Fatalf("TrailerBlock(%d) = %d, want %d", tc.sector, got, tc.trailer)
+		}
+
+		if got := BlocksInSector(tc.sector); got != tc.blocks {
+			t.Fatalf("BlocksInSector(%d) = %d, want %d", tc.sector, got, tc.blocks)
+		}
+	}
+
+	// Sectors past the 4K layout have no blocks and are rejected by
+	// ValidateSector before TrailerBlock wraps them onto other sectors
+	for _, tc := range []struct {
+		sector, trailer byte
+	}{
+		{40, 15},
+		{41, 31},
+		{255, 127},
+	} {
+		if err := ValidateSector(CardTypeMifareClassic4K, tc.sector); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("ValidateSector(%d) = %v, want %v", tc.sector, err, ErrInvalidParameter)
+		}
+
+		if got := TrailerBlock(tc.sector); got != tc.trailer {
+			t.Fatalf("TrailerBlock(%d) = %d, want %d", tc.sector, got, tc.trailer)
+		}
+	}
+}
+
+func TestValidateBlock(t *testing.T) {
+	for _, tc := range []struct {
+		t     CardType
+		block byte
+		err   error
+	}{
+		{CardTypeMifareMini, 19, nil},
+		{CardTypeMifareMini, 20, ErrInvalidParameter},
+		{CardTypeMifareClassic1K, 63, nil},
+		{CardTypeMifareClassic1K, 64, ErrInvalidParameter},
+		{CardTypeMifareClassic4K, 127, nil},
+		{CardTypeMifareClassic4K, 128, nil},
+		{CardTypeMifareClassic4K, 255, nil},
+		{CardTypeMifareUltralight, 4, ErrNotSupported},
+	} {
+		if err := ValidateBlock(tc.t, tc.block); !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Fatalf("ValidateBlock(%v, %d) unexpected error: %v", tc.t, tc.block, err)
+		}
+	}
+
+	if err := ValidateSector(CardTypeMifareClassic4K, 40); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCardStoreLoadData(t *testing.T) {
+	m := newMockMifare(atrMifareClassic1K)
+	c := m.card()
+
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	if err := c.StoreData(data, m.key, KeyA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for sector := 0; sector < 16; sector++ {
+		trailer := TrailerBlock(byte(sector))
+		if !bytes.Equal(m.blocks[trailer], testTrailer) {
+			t.Fatalf("trailer block %d modified: %X", trailer, m.blocks[trailer])
+		}
+	}
+
+	for block := 0; block < 4; block++ {
+		if !bytes.Equal(m.blocks[block], newMockMifare(atrMifareClassic1K).blocks[block]) {
+			t.Fatalf("sector 0 block %d modified: %X", block, m.blocks[block])
+		}
+	}
+
+	got, err := c.LoadData(m.key, KeyA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Fatalf("c.LoadData() = %X, want %X", got, data)
+	}
+}
+
+func TestCardLoadDataWithProgress(t *testing.T) {
+	m := newMockMifare(atrMifareClassic4K)
+	c := m.card()
+
+	data := make([]byte, 2000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	if err := c.StoreData(data, m.key, KeyA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	total := (2 + len(data) + mifareBlockSize - 1) / mifareBlockSize
+
+	var calls []int
+
+	got, err := c.LoadDataWithProgress(func(done, n int) {
+		if n != total {
+			t.Fatalf("total = %d, want %d", n, total)
+		}
+		calls = append(calls, done)
+	}, m.key, KeyA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Fatalf("c.LoadDataWithProgress() = %X, want %X", got, data)
+	}
+
+	if len(calls) != total {
+		t.Fatalf("progress called %d times, want %d", len(calls), total)
+	}
+
+	for i, done := range calls {
+		if done != i+1 {
+			t.Fatalf("progress %d = %d, want %d", i, done, i+1)
+		}
+	}
+}
+
+func TestCardStoreDataErrors(t *testing.T) {
+	t.Run("Capacity exceeded", func(t *testing.T) {
+		m := newMockMifare(atrMifareClassic1K)
+
+		if err := m.card().StoreData(make([]byte, 719), m.key, KeyA); !errors.Is(err, ErrCapacityExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Full capacity", func(t *testing.T) {
+		m := newMockMifare(atrMifareClassic1K)
+
+		if err := m.card().StoreData(make([]byte, 718), m.key, KeyA); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Not MIFARE Classic", func(t *testing.T) {
+		m := newMockMifare(atrMifareUltralight)
+
+		if err := m.card().StoreData([]byte{0x01}, m.key, KeyA); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		m := newMockMifare(atrMifareClassic1K)
+
+		if err := m.card().StoreData([]byte{0x01}, testKey, KeyA); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+var (
+	testKey     = [6]byte{0xA0, 0xA1, 0xA2, 0xA3, 0xA4, 0xA5}
+	testTrailer = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x07, 0x80, 0x69, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+// mockMifare emulates a MIFARE Classic card behind the reader
+type mockMifare struct {
+	atr        []byte
+	key        [6]byte
+	loadedKey  []byte
+	authSector int
+	blocks     [][]byte
+}
+
+func newMockMifare(atr []byte) *mockMifare {
+	m := &mockMifare{
+		atr:        atr,
+		key:        [6]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		authSector: -1,
+	}
+
+	sectors := mifareSectorCount(cardTypeFromATR(atr))
+	for sector := 0; sector < sectors; sector++ {
+		for i := byte(0); i < BlocksInSector(byte(sector)); i++ {
+			block := make([]byte, mifareBlockSize)
+			if i == BlocksInSector(byte(sector))-1 {
+				copy(block, testTrailer)
+			}
+			m.blocks = append(m.blocks, block)
+		}
+	}
+
+	if len(m.blocks) > 0 {
+		copy(m.blocks[0], []byte{0x83, 0xFB, 0x58, 0x24, 0x04, 0x08, 0x04, 0x00})
+	}
+
+	return m
+}
+
+func (m *mockMifare) card() *card {
+	return newCard("Test", &mockCard{
+		transmit: m.transmit,
+		status:   atrStatus(m.atr),
+	})
+}
+
+func (m *mockMifare) transmit(cmd []byte) ([]byte, error) {
+	switch cmd[1] {
+	case 0x82:
+		m.loadedKey = cmd[5:11]
+		return rcOperationSuccess, nil
+	case 0x86:
+		block := cmd[7]
+		if int(block) >= len(m.blocks) || !bytes.Equal(m.loadedKey, m.key[:]) {
+			m.authSector = -1
+			return rcOperationFailed, nil
+		}
+		m.authSector = int(SectorForBlock(block))
+		return rcOperationSuccess, nil
+	case 0xB0:
+		block := cmd[3]
+		if int(block) >= len(m.blocks) || int(SectorForBlock(block)) != m.authSector {
+			return rcOperationFailed, nil
+		}
+		return append(append([]byte{}, m.blocks[block]...), rcOperationSuccess...), nil
+	case 0xD6:
+		block := cmd[3]
+		if int(block) >= len(m.blocks) || int(SectorForBlock(block)) != m.authSector {
+			return rcOperationFailed, nil
+		}
+		m.blocks[block] = append([]byte{}, cmd[5:]...)
+		return rcOperationSuccess, nil
+	default:
+		return nil, scard.ErrUnknownError
+	}
+}
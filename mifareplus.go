@@ -0,0 +1,124 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Card names of MIFARE Plus cards in the ATR built by the reader (PC/SC part 3)
+const (
+	atrMifarePlusSL1_2K = 0x0036
+	atrMifarePlusSL1_4K = 0x0037
+	atrMifarePlusSL2_2K = 0x0038
+	atrMifarePlusSL2_4K = 0x0039
+)
+
+// mifarePlusHistorical is the start of the ATS historical bytes of MIFARE
+// Plus cards in SL0 and SL3, which are ISO14443-4 cards
+var mifarePlusHistorical = []byte{0xC1, 0x05, 0x2F, 0x2F}
+
+// MIFAREPlusSecurityLevel returns the security level of a MIFARE Plus card.
+// SL1 cards are MIFARE Classic compatible and work with the MIFARE Classic
+// methods, SL2 and SL3 cards require AES authentication which is not
+// supported. Unpersonalized SL0 cards are reported as SL3. Returns
+// ErrNotSupported for other cards.
+func (c *card) MIFAREPlusSecurityLevel() (int, error) {
+	s, err := c.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	if level := mifarePlusLevelFromATR(s.Atr); level != 0 {
+		return level, nil
+	}
+
+	if cardTypeFromATR(s.Atr) != CardTypeISODEP {
+		_, sak, err := c.target()
+		if err != nil {
+			return 0, err
+		}
+
+		if level := mifarePlusLevelFromSAK(sak); level != 0 {
+			return level, nil
+		}
+	}
+
+	return 0, wrapError("not a MIFARE Plus card", ErrNotSupported)
+}
+
+// mifarePlusLevelFromATR returns the security level encoded in the ATR, or 0
+func mifarePlusLevelFromATR(atr []byte) int {
+	if cardTypeFromATR(atr) == CardTypeMifarePlus {
+		switch uint16(atr[13])<<8 | uint16(atr[14]) {
+		case atrMifarePlusSL1_2K, atrMifarePlusSL1_4K:
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	if cardTypeFromATR(atr) == CardTypeISODEP && bytes.HasPrefix(atrHistorical(atr), mifarePlusHistorical) {
+		return 3
+	}
+
+	return 0
+}
+
+// mifarePlusLevelFromSAK returns the security level indicated by the SAK,
+// or 0. SL1 cards answer with the SAK of a MIFARE Classic card.
+func mifarePlusLevelFromSAK(sak byte) int {
+	switch sak {
+	case 0x10, 0x11:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// mifarePlusSectorCount returns the number of sectors of a MIFARE Plus SL1
+// card, or an error for the security levels requiring AES
+func mifarePlusSectorCount(atr []byte) (int, error) {
+	if level := mifarePlusLevelFromATR(atr); level != 1 {
+		return 0, wrapError(fmt.Sprintf("MIFARE Plus SL%d AES authentication", level), ErrNotSupported)
+	}
+
+	if uint16(atr[13])<<8|uint16(atr[14]) == atrMifarePlusSL1_2K {
+		return 32, nil
+	}
+
+	return 40, nil
+}
+
+// mifarePlusAuthError returns ErrNotSupported if authentication failed
+// because the card is a MIFARE Plus card requiring AES authentication
+func (c *card) mifarePlusAuthError(err error) error {
+	if !errors.Is(err, ErrOperationFailed) {
+		return err
+	}
+
+	s, serr := c.Status()
+	if serr != nil {
+		return err
+	}
+
+	if level := mifarePlusLevelFromATR(s.Atr); level > 1 {
+		return wrapError(fmt.Sprintf("MIFARE Plus SL%d AES authentication", level), ErrNotSupported)
+	}
+
+	return err
+}
+
+// atrHistorical returns the historical bytes of the ATR built by the reader
+func atrHistorical(atr []byte) []byte {
+	if len(atr) < 4 {
+		return nil
+	}
+
+	n := int(atr[1] & 0x0F)
+	if len(atr) < 4+n {
+		return nil
+	}
+
+	return atr[4 : 4+n]
+}
@@ -0,0 +1,172 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCardMIFAREPlusSecurityLevel(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		atr  []byte
+		sak  byte
+		want int
+		err  error
+	}{
+		{"SL1 2K", atrMifarePlusSL1, 0, 1, nil},
+		{"SL1 4K", mifarePlusATR(atrMifarePlusSL1_4K), 0, 1, nil},
+		{"SL2 2K", mifarePlusATR(atrMifarePlusSL2_2K), 0, 2, nil},
+		{"SL2 4K", mifarePlusATR(atrMifarePlusSL2_4K), 0, 2, nil},
+		{"SL3", atrMifarePlusSL3, 0, 3, nil},
+		{"SL2 SAK", atrMifareClassic1K, 0x10, 2, nil},
+		{"SL2 4K SAK", atrMifareClassic4K, 0x11, 2, nil},
+		{"Classic", atrMifareClassic1K, 0x08, 0, ErrNotSupported},
+		{"ISO-DEP", atrISODEP, 0, 0, ErrNotSupported},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCard("", &mockCard{
+				status: atrStatus(tc.atr),
+				transmit: func(cmd []byte) ([]byte, error) {
+					// InListPassiveTarget: NbTg Tg SENS_RES(2) SEL_RES NFCIDLength NFCID
+					return []byte{0xD5, 0x4B, 0x01, 0x01, 0x00, 0x04, tc.sak, 0x04, 0x01, 0x02, 0x03, 0x04, 0x90, 0x00}, nil
+				},
+			})
+
+			got, err := c.MIFAREPlusSecurityLevel()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("MIFAREPlusSecurityLevel() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCardMIFAREPlusSL1Sectors(t *testing.T) {
+	newSL1 := func(atr []byte) *mockMifare {
+		m := newMockMifare(atrMifareClassic4K)
+		m.atr = atr
+		return m
+	}
+
+	t.Run("Access bits", func(t *testing.T) {
+		m := newSL1(atrMifarePlusSL1)
+		copy(m.blocks[TrailerBlock(31)][6:9], []byte{0x7F, 0x07, 0x88})
+
+		ac, err := m.card().ReadAccessBits(31, m.key, KeyA)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := ac.Bits[3], byte(0b011); got != want {
+			t.Fatalf("ac.Bits[3] = %03b, want %03b", got, want)
+		}
+
+		// The 2K card has 32 sectors
+		if _, err := m.card().ReadAccessBits(32, m.key, KeyA); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := newSL1(mifarePlusATR(atrMifarePlusSL2_2K)).card().ReadAccessBits(0, m.key, KeyA); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Dump", func(t *testing.T) {
+		for _, tc := range []struct {
+			name    string
+			atr     []byte
+			sectors int
+		}{
+			{"2K", atrMifarePlusSL1, 32},
+			{"4K", mifarePlusATR(atrMifarePlusSL1_4K), 40},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				m := newSL1(tc.atr)
+
+				r, err := m.card().Dump()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if len(r.Sectors) != tc.sectors {
+					t.Fatalf("len(r.Sectors) = %d, want %d", len(r.Sectors), tc.sectors)
+				}
+
+				for _, s := range r.Sectors {
+					if s.Err != nil || s.Key == nil {
+						t.Fatalf("sector %d = %v, want read", s.Sector, s)
+					}
+				}
+			})
+		}
+
+		r, err := newSL1(mifarePlusATR(atrMifarePlusSL2_2K)).card().Dump()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := r.Errors["sectors"]; !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCardMIFAREPlusData(t *testing.T) {
+	t.Run("SL1", func(t *testing.T) {
+		m := newMockMifare(atrMifareClassic1K)
+		m.atr = atrMifarePlusSL1
+		c := m.card()
+
+		data := []byte("MIFARE Plus SL1")
+		if err := c.StoreData(data, m.key, KeyA); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, err := c.LoadData(m.key, KeyA)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(got) != string(data) {
+			t.Fatalf("LoadData() = %q, want %q", got, data)
+		}
+	})
+
+	t.Run("SL3", func(t *testing.T) {
+		c := statusCard(atrStatus(atrMifarePlusSL3))
+
+		if _, err := c.LoadData(testKey, KeyA); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("SL2 authenticate", func(t *testing.T) {
+		c := newCard("", &mockCard{
+			status: atrStatus(mifarePlusATR(atrMifarePlusSL2_4K)),
+			transmit: func(cmd []byte) ([]byte, error) {
+				if cmd[1] == 0x86 {
+					return rcOperationFailed, nil
+				}
+				return rcOperationSuccess, nil
+			},
+		})
+
+		if err := c.Authenticate(4, testKey, KeyA); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+// mifarePlusATR returns the ATR built by the reader for the card name
+func mifarePlusATR(name uint16) []byte {
+	atr := append([]byte{}, atrMifareClassic1K...)
+	atr[13], atr[14] = byte(name>>8), byte(name)
+
+	return atr
+}
+
+var (
+	atrMifarePlusSL1 = mifarePlusATR(atrMifarePlusSL1_2K)
+	atrMifarePlusSL3 = []byte{0x3B, 0x8A, 0x80, 0x01, 0xC1, 0x05, 0x2F, 0x2F, 0x01, 0xBC, 0xD6, 0x00, 0x00, 0x00, 0x00}
+)
@@ -0,0 +1,290 @@
+package acr122u
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// NDEF record type name formats
+const (
+	TNFEmpty       byte = 0x00
+	TNFWellKnown   byte = 0x01
+	TNFMedia       byte = 0x02
+	TNFAbsoluteURI byte = 0x03
+	TNFExternal    byte = 0x04
+	TNFUnknown     byte = 0x05
+	TNFUnchanged   byte = 0x06
+)
+
+// NDEF record header flags
+const (
+	ndefMB  = 0x80
+	ndefME  = 0x40
+	ndefCF  = 0x20
+	ndefSR  = 0x10
+	ndefIL  = 0x08
+	ndefTNF = 0x07
+)
+
+// NDEFRecord is a record of an NFC Forum NDEF message
+type NDEFRecord struct {
+	TNF     byte
+	Type    []byte
+	ID      []byte
+	Payload []byte
+}
+
+// ReadNDEF reads the NDEF message of an NFC Forum Type 2
+// (MIFARE Ultralight/NTAG) or Type 4 (ISO-DEP) tag
+func (c *card) ReadNDEF() ([]*NDEFRecord, error) {
+	return c.ReadNDEFWithProgress(nil)
+}
+
+// ReadNDEFWithProgress reads the NDEF message like ReadNDEF, calling fn with
+// the number of bytes read so far and in total after each read command. Type 2
+// tags report the bytes of the data area, Type 4 tags the bytes of the NDEF
+// message. A nil fn is not called.
+func (c *card) ReadNDEFWithProgress(fn func(done, total int)) ([]*NDEFRecord, error) {
+	if fn == nil {
+		fn = func(int, int) {}
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg []byte
+
+	switch t {
+	case CardTypeMifareUltralight:
+		msg, _, err = c.readNDEFType2(fn)
+	case CardTypeISODEP:
+		msg, err = c.readNDEFType4(fn)
+	default:
+		return nil, wrapError(t.String(), ErrNotSupported)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseNDEF(msg)
+}
+
+// WriteNDEF writes the NDEF message to an NFC Forum Type 2
+// (MIFARE Ultralight/NTAG) or Type 4 (ISO-DEP) tag
+func (c *card) WriteNDEF(records []*NDEFRecord) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return err
+	}
+
+	msg, err := MarshalNDEF(records)
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case CardTypeMifareUltralight:
+		return c.writeNDEFType2(msg)
+	case CardTypeISODEP:
+		return c.writeNDEFType4(msg)
+	default:
+		return wrapError(t.String(), ErrNotSupported)
+	}
+}
+
+// UpdateNDEFRecord replaces the record at index in the NDEF message of an NFC
+// Forum Type 2 or Type 4 tag. If the message keeps its length only the pages
+// or bytes that changed are written, otherwise the message is rewritten.
+func (c *card) UpdateNDEFRecord(index int, rec *NDEFRecord) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return err
+	}
+
+	var (
+		old    []byte
+		offset int
+	)
+
+	switch t {
+	case CardTypeMifareUltralight:
+		old, offset, err = c.readNDEFType2(func(int, int) {})
+	case CardTypeISODEP:
+		old, err = c.readNDEFType4(func(int, int) {})
+	default:
+		return wrapError(t.String(), ErrNotSupported)
+	}
+	if err != nil {
+		return err
+	}
+
+	records, err := ParseNDEF(old)
+	if err != nil {
+		return err
+	}
+
+	if index < 0 || index >= len(records) {
+		return wrapError(fmt.Sprintf("NDEF record %d of %d", index, len(records)), ErrInvalidParameter)
+	}
+
+	records[index] = rec
+
+	msg, err := MarshalNDEF(records)
+	if err != nil {
+		return err
+	}
+
+	if len(msg) != len(old) {
+		if t == CardTypeMifareUltralight {
+			return c.writeNDEFType2(msg)
+		}
+		return c.writeNDEFType4(msg)
+	}
+
+	// Only write the bytes from the first to the last one changed
+	first, end := 0, len(msg)
+	for first < end && msg[first] == old[first] {
+		first++
+	}
+	for end > first && msg[end-1] == old[end-1] {
+		end--
+	}
+
+	if first == end {
+		return nil
+	}
+
+	if t == CardTypeMifareUltralight {
+		return c.writeType2Data(offset+first, msg[first:end])
+	}
+
+	return c.updateNDEFType4(first, msg[first:end])
+}
+
+// MarshalNDEF encodes the records as an NDEF message.
+// No records encode as an empty message.
+func MarshalNDEF(records []*NDEFRecord) ([]byte, error) {
+	if len(records) == 0 {
+		return []byte{ndefMB | ndefME | ndefSR | TNFEmpty, 0x00, 0x00}, nil
+	}
+
+	var msg []byte
+
+	for i, r := range records {
+		if r.TNF > TNFUnchanged || len(r.Type) > 0xFF || len(r.ID) > 0xFF {
+			return nil, wrapError(fmt.Sprintf("NDEF record %d", i), ErrInvalidParameter)
+		}
+
+		header := r.TNF
+		if i == 0 {
+			header |= ndefMB
+		}
+		if i == len(records)-1 {
+			header |= ndefME
+		}
+		if len(r.ID) > 0 {
+			header |= ndefIL
+		}
+
+		if len(r.Payload) <= 0xFF {
+			msg = append(msg, header|ndefSR, byte(len(r.Type)), byte(len(r.Payload)))
+		} else {
+			msg = append(msg, header, byte(len(r.Type)))
+			msg = binary.BigEndian.AppendUint32(msg, uint32(len(r.Payload)))
+		}
+
+		if len(r.ID) > 0 {
+			msg = append(msg, byte(len(r.ID)))
+		}
+
+		msg = append(msg, r.Type...)
+		msg = append(msg, r.ID...)
+		msg = append(msg, r.Payload...)
+	}
+
+	return msg, nil
+}
+
+// ParseNDEF decodes an NDEF message. Chunked records are not supported.
+// An empty message returns no records.
+func ParseNDEF(msg []byte) ([]*NDEFRecord, error) {
+	var records []*NDEFRecord
+
+	for i := 0; i < len(msg); {
+		header := msg[i]
+		i++
+
+		if header&ndefCF != 0 {
+			return nil, wrapError("chunked record", ErrInvalidNDEF)
+		}
+
+		n := 2
+		if header&ndefSR == 0 {
+			n = 5
+		}
+		if header&ndefIL != 0 {
+			n++
+		}
+
+		if i+n > len(msg) {
+			return nil, wrapError("record header", ErrInvalidNDEF)
+		}
+
+		typeLen := int(msg[i])
+		i++
+
+		var payloadLen int
+		if header&ndefSR != 0 {
+			payloadLen = int(msg[i])
+			i++
+		} else {
+			payloadLen = int(binary.BigEndian.Uint32(msg[i:]))
+			i += 4
+		}
+
+		var idLen int
+		if header&ndefIL != 0 {
+			idLen = int(msg[i])
+			i++
+		}
+
+		if payloadLen < 0 || i+typeLen+idLen+payloadLen > len(msg) {
+			return nil, wrapError("record length", ErrInvalidNDEF)
+		}
+
+		r := &NDEFRecord{TNF: header & ndefTNF}
+		r.Type, i = append([]byte{}, msg[i:i+typeLen]...), i+typeLen
+		if idLen > 0 {
+			r.ID, i = append([]byte{}, msg[i:i+idLen]...), i+idLen
+		}
+		r.Payload, i = append([]byte{}, msg[i:i+payloadLen]...), i+payloadLen
+
+		// A single empty record is an empty message
+		if r.TNF != TNFEmpty || header&(ndefMB|ndefME) != ndefMB|ndefME {
+			records = append(records, r)
+		}
+
+		if header&ndefME != 0 {
+			if i != len(msg) {
+				return nil, wrapError("data after last record", ErrInvalidNDEF)
+			}
+			break
+		}
+
+		if i == len(msg) {
+			return nil, wrapError("missing last record", ErrInvalidNDEF)
+		}
+	}
+
+	return records, nil
+}
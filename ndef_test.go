@@ -0,0 +1,240 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testNDEFRecords is a URI record for https://example.com followed by a
+// media record with an ID and a payload requiring a long record
+var testNDEFRecords = []*NDEFRecord{
+	{TNF: TNFWellKnown, Type: []byte("U"), Payload: append([]byte{0x04}, "example.com"...)},
+	{TNF: TNFMedia, Type: []byte("text/plain"), ID: []byte("1"), Payload: bytes.Repeat([]byte{0x42}, 300)},
+}
+
+func TestMarshalNDEF(t *testing.T) {
+	msg, err := MarshalNDEF(testNDEFRecords[:1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := append([]byte{0xD1, 0x01, 0x0C, 'U', 0x04}, "example.com"...)
+	if !bytes.Equal(msg, want) {
+		t.Fatalf("MarshalNDEF() = %X, want %X", msg, want)
+	}
+
+	msg, err = MarshalNDEF(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []byte{0xD0, 0x00, 0x00}; !bytes.Equal(msg, want) {
+		t.Fatalf("MarshalNDEF(nil) = %X, want %X", msg, want)
+	}
+
+	if _, err := MarshalNDEF([]*NDEFRecord{{TNF: 0x07}}); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseNDEF(t *testing.T) {
+	msg, err := MarshalNDEF(testNDEFRecords)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := ParseNDEF(msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ndefRecordsEqual(records, testNDEFRecords) {
+		t.Fatalf("ParseNDEF() = %v, want %v", records, testNDEFRecords)
+	}
+
+	for _, msg := range [][]byte{nil, {0xD0, 0x00, 0x00}} {
+		if records, err := ParseNDEF(msg); err != nil || len(records) != 0 {
+			t.Fatalf("ParseNDEF(%X) = %v, %v, want no records", msg, records, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		msg  []byte
+	}{
+		{"Chunked", []byte{0xB1, 0x01, 0x00, 'U'}},
+		{"Short header", []byte{0xD1, 0x01}},
+		{"Short payload", []byte{0xD1, 0x01, 0x05, 'U', 0x04}},
+		{"Missing last record", []byte{0x91, 0x01, 0x00, 'U'}},
+		{"Trailing data", []byte{0xD1, 0x01, 0x00, 'U', 0x00}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseNDEF(tc.msg); !errors.Is(err, ErrInvalidNDEF) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func ndefRecordsEqual(a, b []*NDEFRecord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].TNF != b[i].TNF || !bytes.Equal(a[i].Type, b[i].Type) ||
+			!bytes.Equal(a[i].ID, b[i].ID) || !bytes.Equal(a[i].Payload, b[i].Payload) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestCardUpdateNDEFRecord(t *testing.T) {
+	uri := func(host string) *NDEFRecord {
+		return &NDEFRecord{TNF: TNFWellKnown, Type: []byte("U"), Payload: append([]byte{0x04}, host...)}
+	}
+
+	records := []*NDEFRecord{uri("example.com"), uri("example.org")}
+
+	// recordWrites records the pages or offsets written to the tag
+	recordWrites := func(m *mockCard, writes *[]int) {
+		transmit := m.transmit
+		m.transmit = func(cmd []byte) ([]byte, error) {
+			if cmd[1] == 0xD6 {
+				*writes = append(*writes, int(cmd[2])<<8|int(cmd[3]))
+			}
+			return transmit(cmd)
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		rec    *NDEFRecord
+		type2  []int
+		type4  []int
+		result []*NDEFRecord
+	}{
+		// The last three bytes of the second record change, pages 11-12 of
+		// the Type 2 tag and bytes 31-33 of the Type 4 NDEF file
+		{"Same length", uri("example.net"), []int{11, 12}, []int{31}, []*NDEFRecord{records[0], uri("example.net")}},
+		{"Unchanged", uri("example.org"), nil, nil, records},
+		{"Different length", uri("example.community"), []int{4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, []int{0, 2, 0}, []*NDEFRecord{records[0], uri("example.community")}},
+	} {
+		t.Run(tc.name+" Type 2", func(t *testing.T) {
+			var writes []int
+
+			m := newMockNTAG(ntag213Pages)
+			c := m.card()
+			if err := c.WriteNDEF(records); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			recordWrites(c.scard.(*mockCard), &writes)
+
+			if err := c.UpdateNDEFRecord(1, tc.rec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !intsEqual(writes, tc.type2) {
+				t.Fatalf("pages written = %v, want %v", writes, tc.type2)
+			}
+
+			got, err := c.ReadNDEF()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ndefRecordsEqual(got, tc.result) {
+				t.Fatalf("c.ReadNDEF() = %v, want %v", got, tc.result)
+			}
+		})
+
+		t.Run(tc.name+" Type 2 Lock Control", func(t *testing.T) {
+			m := newMockNTAG(ntag213Pages)
+			copy(m.pages[4], testLockControlTLV)
+			copy(m.pages[5], testLockControlTLV[4:])
+			c := m.card()
+			if err := c.WriteNDEF(records); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := c.UpdateNDEFRecord(1, tc.rec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := append(append([]byte{}, m.pages[4]...), m.pages[5][0]); !bytes.Equal(got, testLockControlTLV) {
+				t.Fatalf("Lock Control TLV = %X, want %X", got, testLockControlTLV)
+			}
+
+			got, err := c.ReadNDEF()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ndefRecordsEqual(got, tc.result) {
+				t.Fatalf("c.ReadNDEF() = %v, want %v", got, tc.result)
+			}
+		})
+
+		t.Run(tc.name+" Type 4", func(t *testing.T) {
+			var writes []int
+
+			m := newMockType4()
+			c := m.card()
+			if err := c.WriteNDEF(records); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			recordWrites(c.scard.(*mockCard), &writes)
+
+			if err := c.UpdateNDEFRecord(1, tc.rec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !intsEqual(writes, tc.type4) {
+				t.Fatalf("offsets written = %v, want %v", writes, tc.type4)
+			}
+
+			got, err := c.ReadNDEF()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ndefRecordsEqual(got, tc.result) {
+				t.Fatalf("c.ReadNDEF() = %v, want %v", got, tc.result)
+			}
+		})
+	}
+
+	t.Run("Index out of range", func(t *testing.T) {
+		c := newMockNTAG(ntag213Pages).card()
+		if err := c.WriteNDEF(records); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := c.UpdateNDEFRecord(2, uri("example.net")); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Read only", func(t *testing.T) {
+		c := newMockNTAG(ntag213Pages).card()
+		c.readOnly = true
+
+		if err := c.UpdateNDEFRecord(0, uri("example.net")); !errors.Is(err, ErrReadOnlyMode) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
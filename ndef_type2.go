@@ -0,0 +1,201 @@
+package acr122u
+
+import "fmt"
+
+// NFC Forum Type 2 tag layout
+const (
+	type2CCPage    = 0x03
+	type2DataPage  = 0x04
+	type2CCMagic   = 0xE1
+	type2ReadPages = 4
+)
+
+// Type 2 TLV tags
+const (
+	tlvNull          = 0x00
+	tlvLockControl   = 0x01
+	tlvMemoryControl = 0x02
+	tlvNDEF          = 0x03
+	tlvProprietary   = 0xFD
+	tlvTerminator    = 0xFE
+)
+
+// readNDEFType2 reads the NDEF message TLV from the data area of a Type 2
+// tag, returning the message and its offset in the data area
+func (c *card) readNDEFType2(progress func(done, total int)) ([]byte, int, error) {
+	data, err := c.readType2Data(progress)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	loc, err := locateType2NDEF(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return data[loc.offset : loc.offset+loc.length], loc.offset, nil
+}
+
+// readType2Data reads the data area of a Type 2 tag
+func (c *card) readType2Data(progress func(done, total int)) ([]byte, error) {
+	size, err := c.type2DataSize()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	for page := type2DataPage; len(data) < size; page += type2ReadPages {
+		resp, err := c.transmit([]byte{0xFF, 0xB0, 0x00, byte(page), type2ReadPages * ntagPageSize})
+		if err != nil {
+			return nil, wrapError(fmt.Sprintf("read page %d", page), err)
+		}
+		data = append(data, resp...)
+		if len(data) > size {
+			data = data[:size]
+		}
+		progress(len(data), size)
+	}
+
+	return data, nil
+}
+
+// type2NDEFLocation is the location of the NDEF message TLV in the data area
+// of a Type 2 tag
+type type2NDEFLocation struct {
+	// tlv is the offset of the TLV, or where it is written if there is none:
+	// after the control TLVs leading the data area
+	tlv int
+	// offset and length of the message
+	offset int
+	length int
+}
+
+// locateType2NDEF finds the NDEF message TLV in the data area of a Type 2
+// tag. If there is none an error is returned with the location to write it.
+func locateType2NDEF(data []byte) (type2NDEFLocation, error) {
+	var (
+		loc     type2NDEFLocation
+		control = true
+	)
+
+	for i := 0; i < len(data); {
+		start := i
+		tag := data[i]
+		i++
+
+		switch tag {
+		case tlvNull:
+			continue
+		case tlvTerminator:
+			return loc, wrapError("no NDEF message TLV", ErrInvalidNDEF)
+		}
+
+		if i >= len(data) {
+			break
+		}
+
+		length := int(data[i])
+		i++
+
+		if length == 0xFF {
+			if i+2 > len(data) {
+				break
+			}
+			length = int(data[i])<<8 | int(data[i+1])
+			i += 2
+		}
+
+		if i+length > len(data) {
+			break
+		}
+
+		if tag == tlvNDEF {
+			return type2NDEFLocation{tlv: start, offset: i, length: length}, nil
+		}
+
+		i += length
+
+		// Only the control TLVs leading the data area are kept when writing
+		control = control && (tag == tlvLockControl || tag == tlvMemoryControl || tag == tlvProprietary)
+		if control {
+			loc.tlv = i
+		}
+	}
+
+	return loc, wrapError("truncated TLV", ErrInvalidNDEF)
+}
+
+// writeNDEFType2 writes the message as an NDEF message TLV followed by a
+// terminator TLV to the data area of a Type 2 tag. The message TLV replaces
+// the current one, or follows the control TLVs leading the data area, e.g.
+// a Lock Control TLV, which are preserved.
+func (c *card) writeNDEFType2(msg []byte) error {
+	data, err := c.readType2Data(func(int, int) {})
+	if err != nil {
+		return err
+	}
+
+	loc, _ := locateType2NDEF(data)
+
+	tlv := []byte{tlvNDEF}
+	if len(msg) < 0xFF {
+		tlv = append(tlv, byte(len(msg)))
+	} else {
+		tlv = append(tlv, 0xFF, byte(len(msg)>>8), byte(len(msg)))
+	}
+	tlv = append(append(tlv, msg...), tlvTerminator)
+
+	if len(msg) > 0xFFFE || loc.tlv+len(tlv) > len(data) {
+		return ErrCapacityExceeded
+	}
+
+	return c.writeType2Data(loc.tlv, tlv)
+}
+
+// writeType2Data writes the data at the offset in the data area of a Type 2
+// tag, writing only the pages it covers. Partially covered pages are merged
+// with their current contents, so the bytes around the data are preserved.
+func (c *card) writeType2Data(offset int, data []byte) error {
+	start := type2DataPage*ntagPageSize + offset
+	end := start + len(data)
+
+	for page := start / ntagPageSize; page*ntagPageSize < end; page++ {
+		first := page * ntagPageSize
+		buf := make([]byte, ntagPageSize)
+
+		if first < start || first+ntagPageSize > end {
+			current, err := c.ReadPage(byte(page))
+			if err != nil {
+				return err
+			}
+			copy(buf, current)
+		}
+
+		for i := range buf {
+			if at := first + i; at >= start && at < end {
+				buf[i] = data[at-start]
+			}
+		}
+
+		if err := c.WritePage(byte(page), buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// type2DataSize returns the size of the data area from the capability container
+func (c *card) type2DataSize() (int, error) {
+	cc, err := c.ReadPage(type2CCPage)
+	if err != nil {
+		return 0, err
+	}
+
+	if cc[0] != type2CCMagic {
+		return 0, wrapError(fmt.Sprintf("capability container %X", cc), ErrInvalidNDEF)
+	}
+
+	return int(cc[2]) * 8, nil
+}
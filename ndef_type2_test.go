@@ -0,0 +1,150 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testLockControlTLV is the Lock Control TLV of an NTAG213 leading its data area
+var testLockControlTLV = []byte{tlvLockControl, 0x03, 0xA0, 0x0C, 0x34}
+
+func TestCardNDEFType2(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		c := m.card()
+
+		if err := c.WriteNDEF(testNDEFRecords[:1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []byte{0x03, 0x10, 0xD1, 0x01, 0x0C, 'U'}
+		if got := append(append([]byte{}, m.pages[4]...), m.pages[5][:2]...); !bytes.Equal(got, want) {
+			t.Fatalf("pages 4-5 = %X, want %X", got, want)
+		}
+
+		records, err := c.ReadNDEF()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !ndefRecordsEqual(records, testNDEFRecords[:1]) {
+			t.Fatalf("c.ReadNDEF() = %v, want %v", records, testNDEFRecords[:1])
+		}
+	})
+
+	t.Run("Partial last page", func(t *testing.T) {
+		records := []*NDEFRecord{{TNF: TNFWellKnown, Type: []byte("T"), Payload: []byte("\x02enHello, world")}}
+
+		msg, err := MarshalNDEF(records)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// 03 <length> <message> FE
+		n := 2 + len(msg) + 1
+		if n%ntagPageSize == 0 {
+			t.Fatalf("TLV length %d is a multiple of the page size", n)
+		}
+
+		m := newMockNTAG(ntag213Pages)
+		for _, p := range m.pages[type2DataPage:] {
+			copy(p, []byte{0xAA, 0xBB, 0xCC, 0xDD})
+		}
+		c := m.card()
+
+		if err := c.WriteNDEF(records); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		last := m.pages[type2DataPage+n/ntagPageSize]
+		if want := []byte{0xAA, 0xBB, 0xCC, 0xDD}[n%ntagPageSize:]; !bytes.Equal(last[n%ntagPageSize:], want) {
+			t.Fatalf("last page = %X, want trailing %X preserved", last, want)
+		}
+
+		if next := m.pages[type2DataPage+n/ntagPageSize+1]; !bytes.Equal(next, []byte{0xAA, 0xBB, 0xCC, 0xDD}) {
+			t.Fatalf("page after message = %X, want AABBCCDD", next)
+		}
+
+		got, err := c.ReadNDEF()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !ndefRecordsEqual(got, records) {
+			t.Fatalf("c.ReadNDEF() = %v, want %v", got, records)
+		}
+	})
+
+	t.Run("Skips other TLVs", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		copy(m.pages[4], []byte{0x00, 0x01, 0x03, 0xA0})
+		copy(m.pages[5], []byte{0x0C, 0x34, 0x03, 0x03})
+		copy(m.pages[6], []byte{0xD0, 0x00, 0x00, 0xFE})
+
+		records, err := m.card().ReadNDEF()
+		if err != nil || len(records) != 0 {
+			t.Fatalf("c.ReadNDEF() = %v, %v, want no records", records, err)
+		}
+	})
+
+	for _, tc := range []struct {
+		name  string
+		after []byte
+	}{
+		{"Lock Control TLV", []byte{tlvTerminator}},
+		{"Lock Control and NDEF TLVs", []byte{tlvNDEF, 0x00, tlvTerminator}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockNTAG(ntag213Pages)
+			data := append(append([]byte{}, testLockControlTLV...), tc.after...)
+			copy(m.pages[4], data)
+			copy(m.pages[5], data[4:])
+			c := m.card()
+
+			if err := c.WriteNDEF(testNDEFRecords[:1]); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := append(append([]byte{}, testLockControlTLV...), 0x03, 0x10, 0xD1)
+			if got := append(append([]byte{}, m.pages[4]...), m.pages[5][:4]...); !bytes.Equal(got, want) {
+				t.Fatalf("pages 4-5 = %X, want %X", got, want)
+			}
+
+			records, err := c.ReadNDEF()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !ndefRecordsEqual(records, testNDEFRecords[:1]) {
+				t.Fatalf("c.ReadNDEF() = %v, want %v", records, testNDEFRecords[:1])
+			}
+		})
+	}
+
+	t.Run("No NDEF TLV", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		copy(m.pages[4], []byte{0xFE})
+
+		if _, err := m.card().ReadNDEF(); !errors.Is(err, ErrInvalidNDEF) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Capacity exceeded", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+
+		if err := m.card().WriteNDEF(testNDEFRecords); !errors.Is(err, ErrCapacityExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Not supported", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		m.atr = atrMifareClassic1K
+
+		if _, err := m.card().ReadNDEF(); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package acr122u
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// NFC Forum Type 4 tag NDEF application and capability container file
+var (
+	type4NDEFAID = []byte{0xD2, 0x76, 0x00, 0x00, 0x85, 0x01, 0x01}
+	type4CCFile  = [2]byte{0xE1, 0x03}
+)
+
+// type4CC is the part of a Type 4 capability container used to access the NDEF file
+type type4CC struct {
+	mle      int
+	mlc      int
+	fileID   [2]byte
+	maxSize  int
+	writable bool
+}
+
+// readNDEFType4 reads the NDEF message from the NDEF file of a Type 4 tag
+func (c *card) readNDEFType4(progress func(done, total int)) ([]byte, error) {
+	cc, err := c.selectNDEFType4()
+	if err != nil {
+		return nil, err
+	}
+
+	nlen, err := c.readBinary(0, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(binary.BigEndian.Uint16(nlen))
+	if length > cc.maxSize-2 {
+		return nil, wrapError(fmt.Sprintf("NLEN %d", length), ErrInvalidNDEF)
+	}
+
+	var msg []byte
+
+	for len(msg) < length {
+		n := length - len(msg)
+		if n > cc.mle {
+			n = cc.mle
+		}
+
+		data, err := c.readBinary(2+len(msg), n)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) == 0 {
+			return nil, wrapError("empty READ BINARY response", ErrInvalidNDEF)
+		}
+
+		msg = append(msg, data...)
+		if len(msg) > length {
+			msg = msg[:length]
+		}
+		progress(len(msg), length)
+	}
+
+	return msg, nil
+}
+
+// writeNDEFType4 writes the message to the NDEF file of a Type 4 tag.
+// NLEN is cleared while writing so a torn write leaves an empty message.
+func (c *card) writeNDEFType4(msg []byte) error {
+	cc, err := c.selectNDEFType4()
+	if err != nil {
+		return err
+	}
+
+	if !cc.writable {
+		return wrapError("NDEF file is read-only", ErrNotSupported)
+	}
+
+	if len(msg) > cc.maxSize-2 {
+		return ErrCapacityExceeded
+	}
+
+	if err := c.updateBinary(0, []byte{0x00, 0x00}); err != nil {
+		return err
+	}
+
+	if err := c.updateBinaryChunks(2, msg, cc.mlc); err != nil {
+		return err
+	}
+
+	return c.updateBinary(0, binary.BigEndian.AppendUint16(nil, uint16(len(msg))))
+}
+
+// updateNDEFType4 overwrites part of the NDEF message of a Type 4 tag,
+// starting at the offset in the message, without changing its length
+func (c *card) updateNDEFType4(offset int, data []byte) error {
+	cc, err := c.selectNDEFType4()
+	if err != nil {
+		return err
+	}
+
+	if !cc.writable {
+		return wrapError("NDEF file is read-only", ErrNotSupported)
+	}
+
+	return c.updateBinaryChunks(2+offset, data, cc.mlc)
+}
+
+// updateBinaryChunks writes the data to the selected file at the offset,
+// using UPDATE BINARY commands of at most mlc bytes
+func (c *card) updateBinaryChunks(offset int, data []byte, mlc int) error {
+	for i := 0; i < len(data); i += mlc {
+		end := i + mlc
+		if end > len(data) {
+			end = len(data)
+		}
+
+		if err := c.updateBinary(offset+i, data[i:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectNDEFType4 selects the NDEF application, reads the capability
+// container and selects the NDEF file
+func (c *card) selectNDEFType4() (*type4CC, error) {
+	if _, err := c.transmit(append(append([]byte{0x00, 0xA4, 0x04, 0x00, byte(len(type4NDEFAID))}, type4NDEFAID...), 0x00)); err != nil {
+		return nil, wrapError("select NDEF application", err)
+	}
+
+	if err := c.selectFile(type4CCFile); err != nil {
+		return nil, err
+	}
+
+	data, err := c.readBinary(0, 15)
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := parseType4CC(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.selectFile(cc.fileID); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// parseType4CC parses the capability container, which holds the NDEF
+// file control TLV (04 06 <file ID> <max size> <read access> <write access>)
+func parseType4CC(data []byte) (*type4CC, error) {
+	if len(data) < 15 || data[7] != 0x04 || data[8] != 0x06 {
+		return nil, wrapError(fmt.Sprintf("capability container %X", data), ErrInvalidNDEF)
+	}
+
+	cc := &type4CC{
+		mle:      int(binary.BigEndian.Uint16(data[3:])),
+		mlc:      int(binary.BigEndian.Uint16(data[5:])),
+		fileID:   [2]byte{data[9], data[10]},
+		maxSize:  int(binary.BigEndian.Uint16(data[11:])),
+		writable: data[14] == 0x00,
+	}
+
+	// The reader limits responses to a short APDU
+	if cc.mle > 0xFF {
+		cc.mle = 0xFF
+	}
+
+	if cc.mlc > 0xFF {
+		cc.mlc = 0xFF
+	}
+
+	if cc.mle == 0 || cc.mlc == 0 || cc.maxSize < 2 {
+		return nil, wrapError(fmt.Sprintf("capability container %X", data), ErrInvalidNDEF)
+	}
+
+	return cc, nil
+}
+
+// selectFile selects the elementary file by file ID (SELECT 00 A4 00 0C)
+func (c *card) selectFile(fid [2]byte) error {
+	if _, err := c.transmit([]byte{0x00, 0xA4, 0x00, 0x0C, 0x02, fid[0], fid[1]}); err != nil {
+		return wrapError(fmt.Sprintf("select file %X", fid), err)
+	}
+
+	return nil
+}
+
+// readBinary reads n bytes of the selected file at the offset (READ BINARY)
+func (c *card) readBinary(offset, n int) ([]byte, error) {
+	resp, err := c.transmit([]byte{0x00, 0xB0, byte(offset >> 8), byte(offset), byte(n)})
+	if err != nil {
+		return nil, wrapError(fmt.Sprintf("read binary at %d", offset), err)
+	}
+
+	return resp, nil
+}
+
+// updateBinary writes the data to the selected file at the offset (UPDATE BINARY)
+func (c *card) updateBinary(offset int, data []byte) error {
+	apdu := append([]byte{0x00, 0xD6, byte(offset >> 8), byte(offset), byte(len(data))}, data...)
+	if _, err := c.transmit(apdu); err != nil {
+		return wrapError(fmt.Sprintf("update binary at %d", offset), err)
+	}
+
+	return nil
+}
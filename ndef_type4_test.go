@@ -0,0 +1,163 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestCardNDEFType4(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		m := newMockType4()
+		c := m.card()
+
+		if err := c.WriteNDEF(testNDEFRecords); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		msg, _ := MarshalNDEF(testNDEFRecords)
+		if got := m.files[[2]byte{0xE1, 0x04}][:2+len(msg)]; !bytes.Equal(got[2:], msg) || int(got[0])<<8|int(got[1]) != len(msg) {
+			t.Fatalf("NDEF file = %X, want NLEN %d and %X", got, len(msg), msg)
+		}
+
+		records, err := c.ReadNDEF()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !ndefRecordsEqual(records, testNDEFRecords) {
+			t.Fatalf("c.ReadNDEF() = %v, want %v", records, testNDEFRecords)
+		}
+	})
+
+	t.Run("Read-only", func(t *testing.T) {
+		m := newMockType4()
+		m.files[type4CCFile][14] = 0xFF
+
+		if err := m.card().WriteNDEF(testNDEFRecords); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Capacity exceeded", func(t *testing.T) {
+		m := newMockType4()
+		m.files[type4CCFile][11], m.files[type4CCFile][12] = 0x00, 0x80
+
+		if err := m.card().WriteNDEF(testNDEFRecords); !errors.Is(err, ErrCapacityExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("No NDEF application", func(t *testing.T) {
+		m := newMockType4()
+		m.aid = nil
+
+		if _, err := m.card().ReadNDEF(); !errors.Is(err, ErrOperationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCardReadNDEFWithProgress(t *testing.T) {
+	m := newMockType4()
+	c := m.card()
+
+	if err := c.WriteNDEF(testNDEFRecords); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, _ := MarshalNDEF(testNDEFRecords)
+
+	var calls []int
+
+	records, err := c.ReadNDEFWithProgress(func(done, total int) {
+		if total != len(msg) {
+			t.Fatalf("total = %d, want %d", total, len(msg))
+		}
+		calls = append(calls, done)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ndefRecordsEqual(records, testNDEFRecords) {
+		t.Fatalf("c.ReadNDEFWithProgress() = %v, want %v", records, testNDEFRecords)
+	}
+
+	if want := (len(msg) + 0x3B - 1) / 0x3B; len(calls) != want {
+		t.Fatalf("progress called %d times, want %d", len(calls), want)
+	}
+
+	for i, done := range calls {
+		want := (i + 1) * 0x3B
+		if want > len(msg) {
+			want = len(msg)
+		}
+		if done != want {
+			t.Fatalf("progress %d = %d, want %d", i, done, want)
+		}
+	}
+}
+
+// mockType4 emulates an NFC Forum Type 4 tag with an NDEF file E104 of
+// 512 bytes, limiting reads (MLe) to 59 and writes (MLc) to 52 bytes
+type mockType4 struct {
+	aid      []byte
+	selected bool
+	file     []byte
+	files    map[[2]byte][]byte
+}
+
+func newMockType4() *mockType4 {
+	return &mockType4{
+		aid: type4NDEFAID,
+		files: map[[2]byte][]byte{
+			type4CCFile:  {0x00, 0x0F, 0x20, 0x00, 0x3B, 0x00, 0x34, 0x04, 0x06, 0xE1, 0x04, 0x02, 0x00, 0x00, 0x00},
+			{0xE1, 0x04}: make([]byte, 512),
+		},
+	}
+}
+
+func (m *mockType4) card() *card {
+	return newCard("Test", &mockCard{
+		transmit: m.transmit,
+		status:   atrStatus(atrISODEP),
+	})
+}
+
+func (m *mockType4) transmit(cmd []byte) ([]byte, error) {
+	rcNotFound := []byte{0x6A, 0x82}
+
+	switch {
+	case cmd[1] == 0xA4 && cmd[2] == 0x04:
+		m.selected = m.aid != nil && bytes.Equal(cmd[5:5+cmd[4]], m.aid)
+		if !m.selected {
+			return rcNotFound, nil
+		}
+		return rcOperationSuccess, nil
+	case cmd[1] == 0xA4 && cmd[2] == 0x00:
+		file, ok := m.files[[2]byte{cmd[5], cmd[6]}]
+		if !m.selected || !ok {
+			return rcNotFound, nil
+		}
+		m.file = file
+		return rcOperationSuccess, nil
+	case cmd[1] == 0xB0:
+		offset, n := int(cmd[2])<<8|int(cmd[3]), int(cmd[4])
+		if m.file == nil || n > 0x3B || offset+n > len(m.file) {
+			return []byte{0x67, 0x00}, nil
+		}
+		return append(append([]byte{}, m.file[offset:offset+n]...), rcOperationSuccess...), nil
+	case cmd[1] == 0xD6:
+		offset, data := int(cmd[2])<<8|int(cmd[3]), cmd[5:5+cmd[4]]
+		if m.file == nil || len(data) > 0x34 || offset+len(data) > len(m.file) {
+			return []byte{0x67, 0x00}, nil
+		}
+		copy(m.file[offset:], data)
+		return rcOperationSuccess, nil
+	default:
+		return nil, scard.ErrUnknownError
+	}
+}
@@ -0,0 +1,101 @@
+package acr122u
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// networkSinkBuffer is the number of card events buffered while the consumer is unavailable
+const networkSinkBuffer = 64
+
+// networkSink writes card events as newline delimited JSON to a network connection
+type networkSink struct {
+	network      string
+	address      string
+	dial         func(network, address string) (net.Conn, error)
+	events       chan cardEvent
+	retryDelay   time.Duration
+	writeTimeout time.Duration
+}
+
+func newNetworkSink(network, address string) *networkSink {
+	return &networkSink{
+		network:      network,
+		address:      address,
+		dial:         net.Dial,
+		events:       make(chan cardEvent, networkSinkBuffer),
+		retryDelay:   time.Second,
+		writeTimeout: 5 * time.Second,
+	}
+}
+
+// publish queues the event, dropping it if the buffer is full
+func (s *networkSink) publish(e cardEvent) bool {
+	select {
+	case s.events <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// run writes queued events, reconnecting when the connection fails
+func (s *networkSink) run(ctx context.Context, logger zerolog.Logger) {
+	var conn net.Conn
+
+	logger = logger.With().Str("Caller", "networkSink").Str("Address", s.address).Logger()
+
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		var e cardEvent
+
+		select {
+		case <-ctx.Done():
+			return
+		case e = <-s.events:
+		}
+
+		line, err := json.Marshal(e)
+		if err != nil {
+			logger.Error().Err(err).Msg("Problem encoding card event")
+			continue
+		}
+		line = append(line, '\n')
+
+		for {
+			if conn == nil {
+				if conn, err = s.dial(s.network, s.address); err != nil {
+					conn = nil
+					logger.Warn().Err(err).Msg("Problem connecting")
+
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(s.retryDelay):
+					}
+					continue
+				}
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+
+			if _, err = conn.Write(line); err != nil {
+				logger.Warn().Err(err).Msg("Problem writing card event, reconnecting")
+				conn.Close()
+				conn = nil
+				continue
+			}
+
+			break
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package acr122u
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestNetworkSink(t *testing.T) {
+	var dials int
+
+	conns := make(chan net.Conn, 2)
+
+	s := newNetworkSink("pipe", "test")
+	s.retryDelay = time.Millisecond
+	s.dial = func(network, address string) (net.Conn, error) {
+		if dials++; dials == 1 {
+			return nil, errors.New("connection refused")
+		}
+
+		client, server := net.Pipe()
+		conns <- server
+
+		return client, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		s.run(ctx, zerolog.Nop())
+		close(done)
+	}()
+
+	readEvent := func(conn net.Conn) cardEvent {
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if strings.Count(line, "\n") != 1 {
+			t.Fatalf("line = %q, want a single newline terminated line", line)
+		}
+
+		var e cardEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return e
+	}
+
+	s.publish(cardEvent{Reader: "Test", UID: "83fb582490"})
+
+	server := <-conns
+	if got, want := readEvent(server).UID, "83fb582490"; got != want {
+		t.Fatalf("UID = %q, want %q", got, want)
+	}
+
+	server.Close()
+	s.publish(cardEvent{Reader: "Test", UID: "0102030405"})
+
+	server = <-conns
+	if got, want := readEvent(server).UID, "0102030405"; got != want {
+		t.Fatalf("UID = %q, want %q", got, want)
+	}
+
+	cancel()
+	<-done
+
+	if got, want := dials, 3; got != want {
+		t.Fatalf("dials = %d, want %d", got, want)
+	}
+}
+
+func TestNetworkSinkPublishFull(t *testing.T) {
+	s := newNetworkSink("pipe", "test")
+
+	for i := 0; i < networkSinkBuffer; i++ {
+		if !s.publish(cardEvent{}) {
+			t.Fatalf("event %d dropped", i)
+		}
+	}
+
+	if s.publish(cardEvent{}) {
+		t.Fatalf("event not dropped with a full buffer")
+	}
+}
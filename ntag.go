@@ -0,0 +1,170 @@
+package acr122u
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ntagPageSize is the size of a MIFARE Ultralight/NTAG page in bytes
+const ntagPageSize = 4
+
+// Static lock byte layout of MIFARE Ultralight/NTAG tags (page 2, bytes 2-3)
+const (
+	ntagLockPage      = 0x02
+	ntagLockCC        = 0x08
+	ntagFirstLockable = 4
+	ntagLastLockable  = 15
+)
+
+// ReadPage reads a 4 byte MIFARE Ultralight/NTAG page
+func (c *card) ReadPage(page byte) ([]byte, error) {
+	resp, err := c.transmit([]byte{0xFF, 0xB0, 0x00, page, 4 * ntagPageSize})
+	if err != nil {
+		return nil, wrapError(fmt.Sprintf("read page %d", page), err)
+	}
+
+	if len(resp) < ntagPageSize {
+		return nil, wrapError(fmt.Sprintf("read page %d response %X", page, resp), ErrOperationFailed)
+	}
+
+	return resp[:ntagPageSize], nil
+}
+
+// WritePage writes a 4 byte MIFARE Ultralight/NTAG page
+func (c *card) WritePage(page byte, data []byte) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	if len(data) != ntagPageSize {
+		return wrapError("page data length", ErrInvalidParameter)
+	}
+
+	if _, err := c.transmit(append([]byte{0xFF, 0xD6, 0x00, page, ntagPageSize}, data...)); err != nil {
+		return wrapError(fmt.Sprintf("write page %d", page), err)
+	}
+
+	if c.verifyWrites {
+		got, err := c.ReadPage(page)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, data) {
+			return wrapError(fmt.Sprintf("page %d read back %X", page, got), ErrWriteVerifyFailed)
+		}
+	}
+
+	return nil
+}
+
+// LockPages sets the static lock bits making pages from-to (4-15) read-only.
+// Locking is irreversible, so confirm must be true.
+func (c *card) LockPages(from, to byte, confirm bool) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	lock0, lock1, err := staticLockBits(from, to)
+	if err != nil {
+		return err
+	}
+
+	return c.setStaticLockBits(lock0, lock1, confirm)
+}
+
+// SetCCLocked sets the static lock bit making the capability container (page 3) read-only.
+// Locking is irreversible, so confirm must be true.
+func (c *card) SetCCLocked(confirm bool) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	return c.setStaticLockBits(ntagLockCC, 0x00, confirm)
+}
+
+// setStaticLockBits ORs the lock bits into the static lock bytes
+func (c *card) setStaticLockBits(lock0, lock1 byte, confirm bool) error {
+	if !confirm {
+		return ErrNotConfirmed
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return err
+	}
+
+	if t != CardTypeMifareUltralight {
+		return wrapError(t.String(), ErrNotSupported)
+	}
+
+	page, err := c.ReadPage(ntagLockPage)
+	if err != nil {
+		return err
+	}
+
+	page[2] |= lock0
+	page[3] |= lock1
+
+	return c.WritePage(ntagLockPage, page)
+}
+
+// staticLockBits returns the static lock bytes locking pages from-to.
+//
+// Lock byte 0 holds the lock bits for pages 4-7 in bits 4-7 (bits 0-3 are the
+// block-locking bits and the CC lock bit), lock byte 1 holds the lock bits
+// for pages 8-15 in bits 0-7.
+func staticLockBits(from, to byte) (byte, byte, error) {
+	if from > to || from < ntagFirstLockable || to > ntagLastLockable {
+		return 0, 0, wrapError(fmt.Sprintf("lock pages %d-%d", from, to), ErrInvalidParameter)
+	}
+
+	var lock0, lock1 byte
+
+	for page := from; page <= to; page++ {
+		if page < 8 {
+			lock0 |= 1 << page
+		} else {
+			lock1 |= 1 << (page - 8)
+		}
+	}
+
+	return lock0, lock1, nil
+}
+
+// ntagVersion sends the NTAG GET_VERSION command (60) returning the 8 byte version
+func (c *card) ntagVersion() ([]byte, error) {
+	resp, err := c.pn532(0x42, 0x60)
+	if err != nil {
+		return nil, wrapError("get version", err)
+	}
+
+	if len(resp) != 9 || resp[0]&0x3F != 0x00 {
+		return nil, wrapError(fmt.Sprintf("get version response %X", resp), ErrOperationFailed)
+	}
+
+	return resp[1:], nil
+}
+
+// ntagStorageSize returns the user memory size in bytes for the storage
+// size byte of the version. Known NTAG21x and Ultralight EV1 sizes are exact,
+// otherwise the lower bound 2^(n/2) encoded by the byte is returned.
+func ntagStorageSize(version []byte) int {
+	if len(version) < 7 {
+		return 0
+	}
+
+	switch version[6] {
+	case 0x0B:
+		return 48
+	case 0x0E:
+		return 128
+	case 0x0F:
+		return 144
+	case 0x11:
+		return 504
+	case 0x13:
+		return 888
+	default:
+		return 1 << (version[6] >> 1)
+	}
+}
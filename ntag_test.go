@@ -0,0 +1,231 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestStaticLockBits(t *testing.T) {
+	for _, tc := range []struct {
+		from, to     byte
+		lock0, lock1 byte
+	}{
+		{4, 4, 0x10, 0x00},
+		{4, 7, 0xF0, 0x00},
+		{5, 6, 0x60, 0x00},
+		{8, 8, 0x00, 0x01},
+		{7, 8, 0x80, 0x01},
+		{10, 15, 0x00, 0xFC},
+		{4, 15, 0xF0, 0xFF},
+	} {
+		lock0, lock1, err := staticLockBits(tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if lock0 != tc.lock0 || lock1 != tc.lock1 {
+			t.Fatalf("staticLockBits(%d, %d) = %02X %02X, want %02X %02X",
+				tc.from, tc.to, lock0, lock1, tc.lock0, tc.lock1)
+		}
+	}
+
+	for _, tc := range []struct{ from, to byte }{
+		{3, 4},
+		{4, 16},
+		{8, 7},
+	} {
+		if _, _, err := staticLockBits(tc.from, tc.to); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("staticLockBits(%d, %d) unexpected error: %v", tc.from, tc.to, err)
+		}
+	}
+}
+
+func TestCardReadWritePage(t *testing.T) {
+	m := newMockNTAG(ntag213Pages)
+	c := m.card()
+
+	if err := c.WritePage(4, []byte{0x01, 0x02, 0x03, 0x04}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := c.ReadPage(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []byte{0x01, 0x02, 0x03, 0x04}; !bytes.Equal(got, want) {
+		t.Fatalf("c.ReadPage(4) = %X, want %X", got, want)
+	}
+
+	if err := c.WritePage(4, []byte{0x01}); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCardVerifyWritesPage(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03, 0x04}
+
+	for _, tc := range []struct {
+		name    string
+		corrupt bool
+		err     error
+	}{
+		{"Match", false, nil},
+		{"Mismatch", true, ErrWriteVerifyFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockNTAG(45)
+			c := newCard("Test", &mockCard{
+				status: atrStatus(m.atr),
+				transmit: func(cmd []byte) ([]byte, error) {
+					resp, err := m.transmit(cmd)
+					if cmd[1] == 0xD6 && tc.corrupt {
+						m.pages[cmd[3]][3] = 0x00
+					}
+					return resp, err
+				},
+			})
+			c.verifyWrites = true
+
+			if err := c.WritePage(4, data); !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCardLockPages(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		c := m.card()
+
+		if err := c.LockPages(4, 9, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := c.SetCCLocked(true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := m.pages[2], []byte{0x5A, 0x48, 0xF8, 0x03}; !bytes.Equal(got, want) {
+			t.Fatalf("page 2 = %X, want %X", got, want)
+		}
+	})
+
+	t.Run("Not confirmed", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+
+		if err := m.card().LockPages(4, 9, false); !errors.Is(err, ErrNotConfirmed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := m.pages[2][2:]; !bytes.Equal(got, []byte{0x00, 0x00}) {
+			t.Fatalf("lock bytes = %X, want 0000", got)
+		}
+	})
+
+	t.Run("Not NTAG", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		m.atr = atrMifareClassic1K
+
+		if err := m.card().LockPages(4, 9, true); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+// ntag213Pages is the number of pages of an NTAG213
+const ntag213Pages = 45
+
+// mockNTAG emulates a MIFARE Ultralight/NTAG tag behind the reader
+type mockNTAG struct {
+	atr       []byte
+	version   []byte
+	pages     [][]byte
+	signature []byte
+}
+
+func newMockNTAG(pages int) *mockNTAG {
+	m := &mockNTAG{
+		atr:     atrMifareUltralight,
+		version: []byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x0F, 0x03},
+	}
+
+	for i := 0; i < pages; i++ {
+		m.pages = append(m.pages, make([]byte, ntagPageSize))
+	}
+
+	copy(m.pages[0], []byte{0x04, 0x11, 0x22, 0xBF})
+	copy(m.pages[1], []byte{0x33, 0x44, 0x55, 0x66})
+	copy(m.pages[2], []byte{0x5A, 0x48, 0x00, 0x00})
+	copy(m.pages[3], []byte{0xE1, 0x10, 0x12, 0x00})
+
+	return m
+}
+
+func (m *mockNTAG) card() *card {
+	return newCard("Test", &mockCard{
+		transmit: m.transmit,
+		status:   atrStatus(m.atr),
+	})
+}
+
+func (m *mockNTAG) transmit(cmd []byte) ([]byte, error) {
+	switch cmd[1] {
+	case 0x00:
+		return m.pn532(cmd[6:])
+	case 0xB0:
+		page := int(cmd[3])
+		if page >= len(m.pages) {
+			return rcOperationFailed, nil
+		}
+
+		var resp []byte
+		for i := 0; i < int(cmd[4])/ntagPageSize; i++ {
+			resp = append(resp, m.pages[(page+i)%len(m.pages)]...)
+		}
+
+		return append(resp, rcOperationSuccess...), nil
+	case 0xD6:
+		page := int(cmd[3])
+		if page >= len(m.pages) || cmd[4] != ntagPageSize {
+			return rcOperationFailed, nil
+		}
+
+		data := cmd[5:]
+		if page == ntagLockPage {
+			m.pages[page][2] |= data[2]
+			m.pages[page][3] |= data[3]
+		} else {
+			copy(m.pages[page], data)
+		}
+
+		return rcOperationSuccess, nil
+	default:
+		return nil, scard.ErrUnknownError
+	}
+}
+
+// pn532 answers the PN532 commands sent using Direct Transmit
+func (m *mockNTAG) pn532(cmd []byte) ([]byte, error) {
+	var resp []byte
+
+	switch {
+	case bytes.Equal(cmd, []byte{0x42, 0x60}):
+		resp = append([]byte{0xD5, 0x43, 0x00}, m.version...)
+	case bytes.Equal(cmd, []byte{0x42, 0x3C, 0x00}) && m.signature != nil:
+		resp = append([]byte{0xD5, 0x43, 0x00}, m.signature...)
+	case bytes.Equal(cmd, []byte{0x42, 0x3C, 0x00}):
+		resp = []byte{0xD5, 0x43, 0x01}
+	case bytes.Equal(cmd, []byte{0x4A, 0x01, 0x00}):
+		uid := append(append([]byte{}, m.pages[0][:3]...), m.pages[1]...)
+		resp = append([]byte{0xD5, 0x4B, 0x01, 0x01, 0x00, 0x44, 0x00, byte(len(uid))}, uid...)
+	default:
+		return rcOperationFailed, nil
+	}
+
+	return append(resp, rcOperationSuccess...), nil
+}
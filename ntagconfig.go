@@ -0,0 +1,217 @@
+package acr122u
+
+import "fmt"
+
+// NTAG21x configuration page bits (CFG0: MIRROR RFUI MIRROR_PAGE AUTH0,
+// CFG1: ACCESS RFUI RFUI RFUI)
+const (
+	ntagMirrorUID     = 0x40
+	ntagMirrorCounter = 0x80
+	ntagMirrorByte    = 0x30
+	ntagStrongMod     = 0x04
+	ntagAccessProt    = 0x80
+	ntagAccessCfgLck  = 0x40
+	ntagAccessCntEn   = 0x10
+	ntagAccessCntProt = 0x08
+	ntagAccessAuthLim = 0x07
+)
+
+// Lengths of the ASCII mirrors in bytes
+const (
+	ntagMirrorUIDLength     = 14
+	ntagMirrorCounterLength = 6
+)
+
+// MirrorConfig configures the NTAG21x ASCII mirror of the UID and/or NFC
+// counter into the user memory
+type MirrorConfig struct {
+	// UID mirrors the UID as 14 hex characters
+	UID bool
+
+	// Counter mirrors the NFC counter as 6 hex characters, following the
+	// UID and an "x" separator if both are mirrored
+	Counter bool
+
+	// Page is the page the mirror starts at
+	Page byte
+
+	// Byte is the byte within the page the mirror starts at, 0-3
+	Byte byte
+}
+
+// NTAGConfig is the configuration of an NTAG21x tag
+type NTAGConfig struct {
+	// Auth0 is the first page protected by the password, pages past the
+	// end of the memory disable the protection
+	Auth0 byte
+
+	// Protected protects reads as well as writes with the password (PROT)
+	Protected bool
+
+	// ConfigLocked permanently locks the configuration pages (CFGLCK)
+	ConfigLocked bool
+
+	// CounterEnabled enables the NFC counter (NFC_CNT_EN)
+	CounterEnabled bool
+
+	// CounterProtected protects reading the NFC counter with the password
+	// (NFC_CNT_PWD_PROT)
+	CounterProtected bool
+
+	// AuthLimit is the number of failed password attempts allowed, 0
+	// disables the limit (AUTHLIM)
+	AuthLimit byte
+
+	// StrongModulation enables the strong modulation mode (STRG_MOD_EN)
+	StrongModulation bool
+
+	// Mirror is the ASCII mirror configuration
+	Mirror MirrorConfig
+}
+
+// ReadConfig reads the configuration pages of an NTAG21x tag
+func (c *card) ReadConfig() (*NTAGConfig, error) {
+	page, _, err := c.ntagConfigPage()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg0, err := c.ReadPage(page)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg1, err := c.ReadPage(page + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeNTAGConfig(cfg0, cfg1), nil
+}
+
+// ntagConfigPage returns the CFG0 page and the version of the NTAG21x tag,
+// or ErrNotSupported for other tags
+func (c *card) ntagConfigPage() (byte, []byte, error) {
+	t, err := c.Type()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if t != CardTypeMifareUltralight {
+		return 0, nil, wrapError(t.String(), ErrNotSupported)
+	}
+
+	version, err := c.ntagVersion()
+	if err != nil {
+		return 0, nil, wrapError("not an NTAG21x tag", ErrNotSupported)
+	}
+
+	page, err := ntagConfigPageForVersion(version)
+
+	return page, version, err
+}
+
+// SetMirror configures the ASCII mirror of the UID and/or NFC counter of an
+// NTAG21x tag. The mirror must fit into the user memory. The NFC counter is
+// only mirrored while it is enabled. Mirroring neither disables the mirror.
+func (c *card) SetMirror(cfg MirrorConfig) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	page, version, err := c.ntagConfigPage()
+	if err != nil {
+		return err
+	}
+
+	mirror, err := ntagMirrorBits(cfg, ntagStorageSize(version))
+	if err != nil {
+		return err
+	}
+
+	cfg0, err := c.ReadPage(page)
+	if err != nil {
+		return err
+	}
+
+	cfg0[0] = cfg0[0]&^(ntagMirrorUID|ntagMirrorCounter|ntagMirrorByte) | mirror
+	cfg0[2] = cfg.Page
+
+	return c.WritePage(page, cfg0)
+}
+
+// ntagMirrorBits returns the MIRROR_CONF and MIRROR_BYTE bits of the
+// MIRROR byte, validating that the mirror fits into the user memory of size
+// bytes starting at page 4
+func ntagMirrorBits(cfg MirrorConfig, size int) (byte, error) {
+	var (
+		mirror byte
+		length int
+	)
+
+	switch {
+	case cfg.UID && cfg.Counter:
+		mirror, length = ntagMirrorUID|ntagMirrorCounter, ntagMirrorUIDLength+1+ntagMirrorCounterLength
+	case cfg.UID:
+		mirror, length = ntagMirrorUID, ntagMirrorUIDLength
+	case cfg.Counter:
+		mirror, length = ntagMirrorCounter, ntagMirrorCounterLength
+	default:
+		return 0, nil
+	}
+
+	if cfg.Byte > 3 {
+		return 0, wrapError(fmt.Sprintf("mirror byte %d", cfg.Byte), ErrInvalidParameter)
+	}
+
+	start := int(cfg.Page)*ntagPageSize + int(cfg.Byte)
+	if cfg.Page < type2DataPage || start+length > type2DataPage*ntagPageSize+size {
+		return 0, wrapError(fmt.Sprintf("%d byte mirror at page %d byte %d", length, cfg.Page, cfg.Byte), ErrInvalidParameter)
+	}
+
+	return mirror | cfg.Byte<<4, nil
+}
+
+// ntagConfigPageForVersion returns the CFG0 page for the NTAG21x version
+func ntagConfigPageForVersion(version []byte) (byte, error) {
+	// Product type 0x04 is NTAG
+	if len(version) < 7 || version[1] != 0x04 || version[2] != 0x04 {
+		return 0, wrapError(fmt.Sprintf("version %X is not an NTAG21x tag", version), ErrNotSupported)
+	}
+
+	switch version[6] {
+	case 0x0B: // NTAG210
+		return 0x10, nil
+	case 0x0E: // NTAG212
+		return 0x25, nil
+	case 0x0F: // NTAG213
+		return 0x29, nil
+	case 0x11: // NTAG215
+		return 0x83, nil
+	case 0x13: // NTAG216
+		return 0xE3, nil
+	default:
+		return 0, wrapError(fmt.Sprintf("NTAG storage size %02X", version[6]), ErrNotSupported)
+	}
+}
+
+// decodeNTAGConfig decodes the CFG0 and CFG1 configuration pages
+func decodeNTAGConfig(cfg0, cfg1 []byte) *NTAGConfig {
+	mirror, access := cfg0[0], cfg1[0]
+
+	return &NTAGConfig{
+		Auth0:            cfg0[3],
+		Protected:        access&ntagAccessProt != 0,
+		ConfigLocked:     access&ntagAccessCfgLck != 0,
+		CounterEnabled:   access&ntagAccessCntEn != 0,
+		CounterProtected: access&ntagAccessCntProt != 0,
+		AuthLimit:        access & ntagAccessAuthLim,
+		StrongModulation: mirror&ntagStrongMod != 0,
+		Mirror: MirrorConfig{
+			UID:     mirror&ntagMirrorUID != 0,
+			Counter: mirror&ntagMirrorCounter != 0,
+			Page:    cfg0[2],
+			Byte:    (mirror & ntagMirrorByte) >> 4,
+		},
+	}
+}
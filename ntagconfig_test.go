@@ -0,0 +1,176 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecodeNTAGConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		cfg0, cfg1 []byte
+		want       NTAGConfig
+	}{
+		{
+			// Factory defaults: mirror off, protection disabled
+			"Defaults",
+			[]byte{0x04, 0x00, 0x00, 0xFF},
+			[]byte{0x00, 0x05, 0x00, 0x00},
+			NTAGConfig{Auth0: 0xFF, StrongModulation: true},
+		},
+		{
+			"UID mirror",
+			[]byte{0x54, 0x00, 0x0A, 0xFF},
+			[]byte{0x00, 0x05, 0x00, 0x00},
+			NTAGConfig{Auth0: 0xFF, StrongModulation: true, Mirror: MirrorConfig{UID: true, Page: 0x0A, Byte: 1}},
+		},
+		{
+			"UID and counter mirror, counter enabled",
+			[]byte{0xE4, 0x00, 0x06, 0xFF},
+			[]byte{0x10, 0x05, 0x00, 0x00},
+			NTAGConfig{Auth0: 0xFF, StrongModulation: true, CounterEnabled: true, Mirror: MirrorConfig{UID: true, Counter: true, Page: 0x06, Byte: 2}},
+		},
+		{
+			"Password protected",
+			[]byte{0x00, 0x00, 0x00, 0x04},
+			[]byte{0xDB, 0x05, 0x00, 0x00},
+			NTAGConfig{Auth0: 0x04, Protected: true, ConfigLocked: true, CounterEnabled: true, CounterProtected: true, AuthLimit: 3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := decodeNTAGConfig(tc.cfg0, tc.cfg1); *got != tc.want {
+				t.Fatalf("decodeNTAGConfig() = %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNTAGConfigPageForVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		version []byte
+		want    byte
+		err     error
+	}{
+		{"NTAG210", []byte{0x00, 0x04, 0x04, 0x01, 0x01, 0x00, 0x0B, 0x03}, 0x10, nil},
+		{"NTAG212", []byte{0x00, 0x04, 0x04, 0x01, 0x01, 0x00, 0x0E, 0x03}, 0x25, nil},
+		{"NTAG213", []byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x0F, 0x03}, 0x29, nil},
+		{"NTAG215", []byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x11, 0x03}, 0x83, nil},
+		{"NTAG216", []byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x13, 0x03}, 0xE3, nil},
+		{"Ultralight EV1", []byte{0x00, 0x04, 0x03, 0x01, 0x01, 0x00, 0x0B, 0x03}, 0, ErrNotSupported},
+		{"Unknown size", []byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x15, 0x03}, 0, ErrNotSupported},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ntagConfigPageForVersion(tc.version)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("ntagConfigPageForVersion(%X) = %02X, want %02X", tc.version, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCardReadConfig(t *testing.T) {
+	t.Run("NTAG213", func(t *testing.T) {
+		m := newMockNTAG(45)
+		copy(m.pages[0x29], []byte{0x54, 0x00, 0x0A, 0x10})
+		copy(m.pages[0x2A], []byte{0x82, 0x05, 0x00, 0x00})
+
+		got, err := m.card().ReadConfig()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := NTAGConfig{Auth0: 0x10, Protected: true, AuthLimit: 2, StrongModulation: true, Mirror: MirrorConfig{UID: true, Page: 0x0A, Byte: 1}}
+		if *got != want {
+			t.Fatalf("ReadConfig() = %+v, want %+v", *got, want)
+		}
+	})
+
+	t.Run("Not NTAG", func(t *testing.T) {
+		c := statusCard(atrStatus(atrISODEP))
+
+		if _, err := c.ReadConfig(); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Ultralight", func(t *testing.T) {
+		m := newMockNTAG(16)
+		m.version = []byte{0x00, 0x04, 0x03, 0x01, 0x01, 0x00, 0x0B, 0x03}
+
+		if _, err := m.card().ReadConfig(); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestNTAGMirrorBits(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  MirrorConfig
+		want byte
+		err  error
+	}{
+		{"Disabled", MirrorConfig{}, 0x00, nil},
+		{"UID", MirrorConfig{UID: true, Page: 4}, 0x40, nil},
+		{"Counter", MirrorConfig{Counter: true, Page: 0x10, Byte: 2}, 0xA0, nil},
+		{"UID and counter", MirrorConfig{UID: true, Counter: true, Page: 6, Byte: 3}, 0xF0, nil},
+		{"UID at end of memory", MirrorConfig{UID: true, Page: 0x24, Byte: 2}, 0x60, nil},
+		{"UID overruns memory", MirrorConfig{UID: true, Page: 0x24, Byte: 3}, 0, ErrInvalidParameter},
+		{"UID and counter overrun memory", MirrorConfig{UID: true, Counter: true, Page: 0x23}, 0, ErrInvalidParameter},
+		{"Before user memory", MirrorConfig{UID: true, Page: 3}, 0, ErrInvalidParameter},
+		{"Invalid byte", MirrorConfig{Counter: true, Page: 4, Byte: 4}, 0, ErrInvalidParameter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// NTAG213, 144 bytes of user memory in pages 4-39
+			got, err := ntagMirrorBits(tc.cfg, 144)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("ntagMirrorBits(%+v) = %02X, want %02X", tc.cfg, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCardSetMirror(t *testing.T) {
+	t.Run("NTAG213", func(t *testing.T) {
+		m := newMockNTAG(45)
+		copy(m.pages[0x29], []byte{0x04, 0x00, 0x00, 0xFF})
+
+		if err := m.card().SetMirror(MirrorConfig{UID: true, Counter: true, Page: 0x0A, Byte: 1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := []byte{0xD4, 0x00, 0x0A, 0xFF}; !bytes.Equal(m.pages[0x29], want) {
+			t.Fatalf("CFG0 = %X, want %X", m.pages[0x29], want)
+		}
+	})
+
+	t.Run("Overrun", func(t *testing.T) {
+		m := newMockNTAG(45)
+		copy(m.pages[0x29], []byte{0x04, 0x00, 0x00, 0xFF})
+
+		if err := m.card().SetMirror(MirrorConfig{UID: true, Page: 0x27}); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := []byte{0x04, 0x00, 0x00, 0xFF}; !bytes.Equal(m.pages[0x29], want) {
+			t.Fatalf("CFG0 = %X, want %X", m.pages[0x29], want)
+		}
+	})
+
+	t.Run("Read only", func(t *testing.T) {
+		c := newMockNTAG(45).card()
+		c.readOnly = true
+
+		if err := c.SetMirror(MirrorConfig{UID: true, Page: 4}); !errors.Is(err, ErrReadOnlyMode) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package acr122u
+
+import (
+	"fmt"
+	"path"
+)
+
+// pn532IC is the IC byte of the PN532 in the GetFirmwareVersion response
+const pn532IC = 0x32
+
+// Open establishes a context for the single ACR122U reader matching the
+// pattern, the common setup of applications using one reader. The pattern is
+// matched against the reader names as by path.Match, e.g. "ACS ACR122U*",
+// and an empty pattern matches all readers.
+//
+// The first matching reader that is named and responds like an ACR122U, i.e.
+// its PN532 answers GetFirmwareVersion, is selected and the context is
+// limited to it. Returns ErrUnknownReader if no reader matches and
+// ErrNotSupported if no matching reader is an ACR122U, naming the readers.
+func Open(readerPattern string, options ...Option) (*Context, error) {
+	actx, err := establishContext(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := actx.selectACR122U(readerPattern)
+	if err != nil {
+		_ = actx.Release()
+		return nil, err
+	}
+
+	actx.SetReaders([]string{reader})
+
+	return actx, nil
+}
+
+// Returns the first reader matching the pattern which is an ACR122U
+func (actx *Context) selectACR122U(pattern string) (string, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	var matched []string
+	for _, r := range actx.Readers() {
+		ok, err := path.Match(pattern, r)
+		if err != nil {
+			return "", wrapError(fmt.Sprintf("reader pattern %q", pattern), ErrInvalidParameter)
+		}
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+
+	if len(matched) == 0 {
+		return "", wrapError(fmt.Sprintf("no reader matching %q among %q", pattern, actx.Readers()), ErrUnknownReader)
+	}
+
+	var first error
+	for _, r := range matched {
+		err := actx.checkACR122U(r)
+		if err == nil {
+			return r, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+
+	return "", wrapError(fmt.Sprintf("no ACR122U among %q", matched), first)
+}
+
+// Checks that the reader is named like and responds as an ACR122U
+func (actx *Context) checkACR122U(reader string) error {
+	if !isACR122UReader(reader) {
+		return wrapError(fmt.Sprintf("%s is not an ACR122U", reader), ErrNotSupported)
+	}
+
+	resp, err := actx.pn532(reader, 0x02)
+	if err != nil {
+		return wrapError(fmt.Sprintf("%s firmware query: %v", reader, err), ErrNotSupported)
+	}
+
+	// IC Ver Rev Support
+	if len(resp) != 4 || resp[0] != pn532IC {
+		return wrapError(fmt.Sprintf("%s firmware %X is not a PN532", reader, resp), ErrNotSupported)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestOpen(t *testing.T) {
+	defer func(f func(Scope) (PCSCContext, error)) {
+		establishPCSCContext = f
+	}(establishPCSCContext)
+
+	readers := []string{"Generic Reader 00 00", "ACS ACR122U PICC Interface 00 00", "ACS ACR122U PICC Interface 01 00"}
+	firmware := []byte{0xD5, 0x03, 0x32, 0x01, 0x06, 0x07, 0x90, 0x00}
+
+	for _, tc := range []struct {
+		name     string
+		pattern  string
+		firmware []byte
+		want     string
+		err      error
+	}{
+		{"First ACR122U", "", firmware, readers[1], nil},
+		{"Pattern", "ACS ACR122U * 01 00", firmware, readers[2], nil},
+		{"No match", "ACS ACR1252U*", firmware, "", ErrUnknownReader},
+		{"Not named ACR122U", "Generic*", firmware, "", ErrNotSupported},
+		{"Not a PN532", "ACS*", []byte{0xD5, 0x03, 0x07, 0x01, 0x06, 0x07, 0x90, 0x00}, "", ErrNotSupported},
+		{"No response", "ACS*", []byte{0x63, 0x00}, "", ErrNotSupported},
+		{"Invalid pattern", "[", firmware, "", ErrInvalidParameter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var released bool
+
+			establishPCSCContext = func(Scope) (PCSCContext, error) {
+				return &mockContext{
+					listReaders: func() ([]string, error) {
+						return readers, nil
+					},
+					connect: escapeConnect(t, func([]byte) []byte { return tc.firmware }),
+					release: func() error {
+						released = true
+						return nil
+					},
+				}, nil
+			}
+
+			actx, err := Open(tc.pattern, WithLogLevel(LogError))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.err != nil {
+				if !released {
+					t.Fatalf("context not released")
+				}
+				return
+			}
+
+			if got := actx.Readers(); !stringsEqual(got, []string{tc.want}) {
+				t.Fatalf("actx.Readers() = %q, want [%q]", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("No PC/SC service", func(t *testing.T) {
+		establishPCSCContext = func(Scope) (PCSCContext, error) {
+			return nil, scard.ErrNoService
+		}
+
+		if _, err := Open(""); !errors.Is(err, ErrPCSCUnavailable) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
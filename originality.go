@@ -0,0 +1,94 @@
+package acr122u
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// ntagSignatureSize is the size of the NTAG21x originality signature (r || s)
+const ntagSignatureSize = 32
+
+// ntagOriginalityKey is the NXP public key of the NTAG21x and MIFARE
+// Ultralight EV1 originality signature, an uncompressed secp128r1 point
+var ntagOriginalityKey = []byte{
+	0x04,
+	0x49, 0x4E, 0x1A, 0x38, 0x6D, 0x3D, 0x3C, 0xFE, 0x3D, 0xC1, 0x0E, 0x5D, 0xE6, 0x8A, 0x49, 0x9B,
+	0x1C, 0x20, 0x2D, 0xB5, 0xB1, 0x32, 0x39, 0x3E, 0x89, 0xED, 0x19, 0xFE, 0x5B, 0xE8, 0xBC, 0x61,
+}
+
+var (
+	secp128r1     *elliptic.CurveParams
+	secp128r1Once sync.Once
+)
+
+// curveSecp128r1 returns the SEC 2 secp128r1 curve used by NXP originality signatures
+func curveSecp128r1() *elliptic.CurveParams {
+	secp128r1Once.Do(func() {
+		secp128r1 = &elliptic.CurveParams{Name: "secp128r1", BitSize: 128}
+		secp128r1.P, _ = new(big.Int).SetString("FFFFFFFDFFFFFFFFFFFFFFFFFFFFFFFF", 16)
+		secp128r1.N, _ = new(big.Int).SetString("FFFFFFFE0000000075A30D1B9038A115", 16)
+		secp128r1.B, _ = new(big.Int).SetString("E87579C11079F43DD824993C2CEE5ED3", 16)
+		secp128r1.Gx, _ = new(big.Int).SetString("161FF7528B899B2D0C28607CA52C5B86", 16)
+		secp128r1.Gy, _ = new(big.Int).SetString("CF5AC8395BAFEB13C02DA292DDED7A83", 16)
+	})
+
+	return secp128r1
+}
+
+// ReadSignature reads the 32 byte NTAG21x originality signature (READ_SIG)
+func (c *card) ReadSignature() ([]byte, error) {
+	t, err := c.Type()
+	if err != nil {
+		return nil, err
+	}
+
+	if t != CardTypeMifareUltralight {
+		return nil, wrapError(t.String(), ErrNotSupported)
+	}
+
+	resp, err := c.pn532(0x42, 0x3C, 0x00)
+	if err != nil {
+		return nil, wrapError("read signature", err)
+	}
+
+	if len(resp) != 1+ntagSignatureSize || resp[0]&0x3F != 0x00 {
+		return nil, wrapError(fmt.Sprintf("read signature response %X", resp), ErrNotSupported)
+	}
+
+	return resp[1:], nil
+}
+
+// VerifyOriginality verifies the NTAG21x originality signature of the UID
+// against the NXP public key. A signature that does not verify returns false
+// without an error, which indicates a clone.
+func (c *card) VerifyOriginality() (bool, error) {
+	sig, err := c.ReadSignature()
+	if err != nil {
+		return false, err
+	}
+
+	return verifyOriginality(ntagOriginalityKey, c.uid, sig)
+}
+
+// verifyOriginality verifies the secp128r1 ECDSA signature (r || s) of the
+// UID, which is signed as is without hashing
+func verifyOriginality(key, uid, sig []byte) (bool, error) {
+	curve := curveSecp128r1()
+
+	x, y := elliptic.Unmarshal(curve, key)
+	if x == nil {
+		return false, wrapError("originality key", ErrInvalidParameter)
+	}
+
+	if len(sig) != ntagSignatureSize {
+		return false, wrapError("signature length", ErrInvalidParameter)
+	}
+
+	r := new(big.Int).SetBytes(sig[:ntagSignatureSize/2])
+	s := new(big.Int).SetBytes(sig[ntagSignatureSize/2:])
+
+	return ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, uid, r, s), nil
+}
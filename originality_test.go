@@ -0,0 +1,145 @@
+package acr122u
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+)
+
+// Originality signature vector signed with a test key on secp128r1, as the
+// NXP private key is not available
+var (
+	testOriginalityKey = []byte{
+		0x04,
+		0x1B, 0xB9, 0x27, 0x3D, 0x32, 0xCF, 0xCD, 0x5B, 0xB0, 0x97, 0x50, 0xDD, 0x50, 0xAF, 0x91, 0xB3,
+		0xCB, 0xC8, 0xEC, 0x11, 0x84, 0x2E, 0xCA, 0x82, 0x18, 0x34, 0x75, 0xB8, 0x45, 0x44, 0x41, 0xA5,
+	}
+	testOriginalityUID       = []byte{0x04, 0x51, 0x62, 0x52, 0xC3, 0x45, 0x80}
+	testOriginalitySignature = []byte{
+		0x1D, 0x6D, 0xD3, 0x82, 0xD8, 0xA6, 0xE7, 0xC1, 0xDD, 0xAC, 0x76, 0x83, 0x67, 0x83, 0xFA, 0xB5,
+		0x35, 0x48, 0x7C, 0xBB, 0x70, 0xA7, 0x72, 0x2D, 0x67, 0x54, 0x12, 0x3D, 0x8C, 0x80, 0x50, 0x4A,
+	}
+)
+
+func TestVerifyOriginality(t *testing.T) {
+	tampered := append([]byte{}, testOriginalityUID...)
+	tampered[6] ^= 0x01
+
+	for _, tc := range []struct {
+		name string
+		key  []byte
+		uid  []byte
+		sig  []byte
+		want bool
+		err  error
+	}{
+		{"Valid", testOriginalityKey, testOriginalityUID, testOriginalitySignature, true, nil},
+		{"Other UID", testOriginalityKey, tampered, testOriginalitySignature, false, nil},
+		{"NXP key", ntagOriginalityKey, testOriginalityUID, testOriginalitySignature, false, nil},
+		{"Short signature", testOriginalityKey, testOriginalityUID, testOriginalitySignature[:31], false, ErrInvalidParameter},
+		{"Invalid key", ntagOriginalityKey[:16], testOriginalityUID, testOriginalitySignature, false, ErrInvalidParameter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := verifyOriginality(tc.key, tc.uid, tc.sig)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("verifyOriginality() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCurveSecp128r1(t *testing.T) {
+	curve := curveSecp128r1()
+
+	if !curve.IsOnCurve(curve.Gx, curve.Gy) {
+		t.Fatalf("generator is not a point of the curve")
+	}
+
+	// The generator has order N, so N*G is the point at infinity, (0, 0)
+	if x, y := curve.ScalarBaseMult(curve.N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatalf("N*G = (%X, %X), want the point at infinity", x, y)
+	}
+
+	// (N-1)*G is -G
+	n1 := new(big.Int).Sub(curve.N, big.NewInt(1))
+	if x, y := curve.ScalarBaseMult(n1.Bytes()); x.Cmp(curve.Gx) != 0 || new(big.Int).Add(y, curve.Gy).Cmp(curve.P) != 0 {
+		t.Fatalf("(N-1)*G = (%X, %X), want -G", x, y)
+	}
+}
+
+// TestVerifyOriginalityGenuine verifies the signature of a genuine NTAG21x
+// against the NXP key, given as hex by ACR122U_ORIGINALITY_UID and
+// ACR122U_ORIGINALITY_SIGNATURE, e.g. as read by UID and ReadSignature
+func TestVerifyOriginalityGenuine(t *testing.T) {
+	uidHex, sigHex := os.Getenv("ACR122U_ORIGINALITY_UID"), os.Getenv("ACR122U_ORIGINALITY_SIGNATURE")
+	if uidHex == "" || sigHex == "" {
+		t.Skip("ACR122U_ORIGINALITY_UID and ACR122U_ORIGINALITY_SIGNATURE not set")
+	}
+
+	uid, err := hex.DecodeString(uidHex)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := verifyOriginality(ntagOriginalityKey, uid, sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !got {
+		t.Fatalf("verifyOriginality() = false, want true")
+	}
+}
+
+func TestNTAGOriginalityKey(t *testing.T) {
+	// A mistyped key would not be a point of the curve
+	if x, _ := elliptic.Unmarshal(curveSecp128r1(), ntagOriginalityKey); x == nil {
+		t.Fatalf("ntagOriginalityKey is not a secp128r1 point")
+	}
+}
+
+func TestCardVerifyOriginality(t *testing.T) {
+	t.Run("Clone", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		m.signature = testOriginalitySignature
+
+		c := m.card()
+		c.uid = testOriginalityUID
+
+		ok, err := c.VerifyOriginality()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ok {
+			t.Fatalf("c.VerifyOriginality() = true, want false")
+		}
+	})
+
+	t.Run("No signature", func(t *testing.T) {
+		if _, err := newMockNTAG(ntag213Pages).card().VerifyOriginality(); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Not Ultralight", func(t *testing.T) {
+		m := newMockNTAG(ntag213Pages)
+		m.atr = atrMifareClassic1K
+
+		if _, err := m.card().VerifyOriginality(); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package acr122u
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ioctlEscape is the IOCTL used to send pseudo APDUs to the reader
+// over a direct connection, which does not require a card to be present
+var ioctlEscape = escapeIoctl(runtime.GOOS)
+
+// escapeIoctl returns the IOCTL of the CCID escape command on the OS. The
+// pcsc-lite CCID driver on Linux expects SCARD_CTL_CODE(1), Windows, macOS
+// and the BSDs expect the Windows code 3500. The codes are computed here as
+// scard.CtlCode uses the encoding of the OS the package is built for.
+func escapeIoctl(goos string) uint32 {
+	if goos == "linux" {
+		return 0x42000000 + 1
+	}
+
+	return 0x31<<16 | 3500<<2
+}
+
+// direct calls fn with a direct connection to the reader, which does not
+// require a card to be present
+func (actx *Context) direct(reader string, fn func(sc PCSCCard) error) error {
+	c, err := actx.connectWith(reader, ShareDirect, ProtocolUndefined)
+	if err != nil {
+		return err
+	}
+	c.disposition = LeaveCard
+
+	defer func() {
+		if err := c.disconnect(); err != nil {
+			actx.logger.Error().Err(err).Str("Reader", reader).Msg("Problem disconnecting from reader")
+		}
+	}()
+
+	return fn(c.scard)
+}
+
+// escape sends the pseudo APDU to the reader over a direct connection
+func (actx *Context) escape(reader string, apdu []byte) ([]byte, error) {
+	var resp []byte
+
+	err := actx.direct(reader, func(sc PCSCCard) (err error) {
+		resp, err = sc.Control(actx.escapeCode, apdu)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, sw, err := splitResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if sw != swSuccess {
+		return nil, wrapError(statusText(sw), ErrOperationFailed)
+	}
+
+	return data, nil
+}
+
+// pn532 sends the command to the PN532 of the reader over a direct connection
+func (actx *Context) pn532(reader string, cmd ...byte) ([]byte, error) {
+	apdu, err := pn532Command(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := actx.escape(reader, apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	return pn532Response(cmd, resp)
+}
+
+// pn532 sends the command to the PN532 of the reader the card is connected to
+func (c *card) pn532(cmd ...byte) ([]byte, error) {
+	apdu, err := pn532Command(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.transmit(apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	return pn532Response(cmd, resp)
+}
+
+// pn532Command wraps the PN532 command in the reader's Direct Transmit
+// pseudo APDU (FF 00 00 00 Lc D4 <cmd>)
+func pn532Command(cmd []byte) ([]byte, error) {
+	if len(cmd) == 0 || len(cmd) > 0xFE {
+		return nil, wrapError("PN532 command length", ErrInvalidParameter)
+	}
+
+	return append([]byte{0xFF, 0x00, 0x00, 0x00, byte(len(cmd) + 1), 0xD4}, cmd...), nil
+}
+
+// pn532Response returns the response data following the D5 <cmd+1> response header
+func pn532Response(cmd, resp []byte) ([]byte, error) {
+	if len(resp) < 2 || resp[0] != 0xD5 || resp[1] != cmd[0]+1 {
+		return nil, wrapError(fmt.Sprintf("PN532 response %X", resp), ErrOperationFailed)
+	}
+
+	return resp[2:], nil
+}
+
+// SetRFTimeout sets the PN532 ATR_RES timeout and the timeout used while
+// communicating with a tag (RFConfiguration item 0x02).
+//
+// The PN532 encodes a timeout as n, waiting 100µs * 2^(n-1), so each value
+// is rounded up to the next available timeout: 0 disables the timeout, 1ms
+// becomes 1.6ms, 100ms becomes 102.4ms and so on up to 3276ms (3.2768s),
+// the longest timeout available. The reader defaults are 102.4ms (ATR_RES)
+// and 51.2ms (communication).
+func (actx *Context) SetRFTimeout(reader string, atrResMS, retryMS uint16) error {
+	atrRes, err := rfTimeoutCode(atrResMS)
+	if err != nil {
+		return err
+	}
+
+	retry, err := rfTimeoutCode(retryMS)
+	if err != nil {
+		return err
+	}
+
+	_, err = actx.pn532(reader, 0x32, 0x02, 0x00, atrRes, retry)
+
+	return err
+}
+
+// rfTimeoutMaxCode is the longest PN532 timeout (3.2768s)
+const rfTimeoutMaxCode = 0x10
+
+// rfTimeoutCode returns the PN532 timeout code for the smallest timeout
+// of at least ms milliseconds
+func rfTimeoutCode(ms uint16) (byte, error) {
+	if ms == 0 {
+		return 0x00, nil
+	}
+
+	us := uint32(ms) * 1000
+	for n := byte(1); n <= rfTimeoutMaxCode; n++ {
+		if uint32(100)<<(n-1) >= us {
+			return n, nil
+		}
+	}
+
+	return 0, wrapError(fmt.Sprintf("timeout %dms", ms), ErrInvalidParameter)
+}
+
+// ReadRegister reads a PN532 register (ReadRegister command).
+//
+// This is an advanced API: register addresses and meanings are specific to
+// the chip and firmware, see the PN532 user manual.
+func (actx *Context) ReadRegister(reader string, addr uint16) (byte, error) {
+	resp, err := actx.pn532(reader, 0x06, byte(addr>>8), byte(addr))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp) != 1 {
+		return 0, wrapError(fmt.Sprintf("read register %04X response %X", addr, resp), ErrOperationFailed)
+	}
+
+	return resp[0], nil
+}
+
+// WriteRegister writes a PN532 register (WriteRegister command).
+//
+// This is an unsafe API: writing the wrong register can leave the reader
+// unusable until it is power cycled.
+func (actx *Context) WriteRegister(reader string, addr uint16, val byte) error {
+	_, err := actx.pn532(reader, 0x08, byte(addr>>8), byte(addr), val)
+
+	return err
+}
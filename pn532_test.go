@@ -0,0 +1,184 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestRFTimeoutCode(t *testing.T) {
+	for _, tc := range []struct {
+		ms   uint16
+		want byte
+	}{
+		{0, 0x00},
+		{1, 0x05},
+		{2, 0x06},
+		{51, 0x0A},
+		{100, 0x0B},
+		{103, 0x0C},
+		{3276, 0x10},
+	} {
+		got, err := rfTimeoutCode(tc.ms)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("rfTimeoutCode(%d) = %02X, want %02X", tc.ms, got, tc.want)
+		}
+	}
+
+	if _, err := rfTimeoutCode(3277); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEscapeIoctl(t *testing.T) {
+	for _, tc := range []struct {
+		goos string
+		want uint32
+	}{
+		{"linux", 0x42000001},
+		{"windows", 0x003136B0},
+		{"darwin", 0x003136B0},
+		{"freebsd", 0x003136B0},
+	} {
+		if got := escapeIoctl(tc.goos); got != tc.want {
+			t.Fatalf("escapeIoctl(%q) = %X, want %X", tc.goos, got, tc.want)
+		}
+	}
+}
+
+func TestWithEscapeIoctl(t *testing.T) {
+	var got uint32
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return &mockCard{control: func(ioctl uint32, in []byte) ([]byte, error) {
+				got = ioctl
+				return []byte{0xD5, 0x33, 0x90, 0x00}, nil
+			}}, nil
+		},
+	}, WithEscapeIoctl(0x42000DAC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.SetRFTimeout("Test", 100, 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := uint32(0x42000DAC); got != want {
+		t.Fatalf("ioctl = %X, want %X", got, want)
+	}
+}
+
+func TestContextSetRFTimeout(t *testing.T) {
+	var got []byte
+
+	actx, err := newContext(&mockContext{
+		connect: escapeConnect(t, func(in []byte) []byte {
+			got = in
+			return []byte{0xD5, 0x33, 0x90, 0x00}
+		}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.SetRFTimeout("Test", 100, 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []byte{0xFF, 0x00, 0x00, 0x00, 0x06, 0xD4, 0x32, 0x02, 0x00, 0x0B, 0x0F}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("escape = %X, want %X", got, want)
+	}
+}
+
+func TestContextReadWriteRegister(t *testing.T) {
+	var got []byte
+
+	actx, err := newContext(&mockContext{
+		connect: escapeConnect(t, func(in []byte) []byte {
+			got = in
+			if in[6] == 0x06 {
+				return []byte{0xD5, 0x07, 0x42, 0x90, 0x00}
+			}
+			return []byte{0xD5, 0x09, 0x90, 0x00}
+		}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	val, err := actx.ReadRegister("Test", 0x6302)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if val != 0x42 {
+		t.Fatalf("val = %02X, want 42", val)
+	}
+
+	if want := []byte{0xFF, 0x00, 0x00, 0x00, 0x04, 0xD4, 0x06, 0x63, 0x02}; !bytes.Equal(got, want) {
+		t.Fatalf("escape = %X, want %X", got, want)
+	}
+
+	if err := actx.WriteRegister("Test", 0x6302, 0x80); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []byte{0xFF, 0x00, 0x00, 0x00, 0x05, 0xD4, 0x08, 0x63, 0x02, 0x80}; !bytes.Equal(got, want) {
+		t.Fatalf("escape = %X, want %X", got, want)
+	}
+}
+
+func TestContextPN532(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		resp []byte
+		want error
+	}{
+		{"OK", []byte{0xD5, 0x33, 0x90, 0x00}, nil},
+		{"Failed", []byte{0x63, 0x00}, ErrOperationFailed},
+		{"Wrong response", []byte{0xD5, 0x07, 0x90, 0x00}, ErrOperationFailed},
+		{"Short", []byte{0x90}, ErrShortResponse},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actx, err := newContext(&mockContext{
+				connect: escapeConnect(t, func([]byte) []byte { return tc.resp }),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := actx.pn532("Test", 0x32); !errors.Is(err, tc.want) {
+				t.Fatalf("got = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// escapeConnect returns a connect func for a direct connection
+// answering escape commands using resp
+func escapeConnect(t *testing.T, resp func([]byte) []byte) func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+	return func(reader string, shareMode scard.ShareMode, protocol scard.Protocol) (PCSCCard, error) {
+		if shareMode != scard.ShareDirect || protocol != scard.ProtocolUndefined {
+			t.Fatalf("connect(%v, %v), want direct connection", shareMode, protocol)
+		}
+
+		return &mockCard{
+			control: func(ioctl uint32, in []byte) ([]byte, error) {
+				if ioctl != ioctlEscape {
+					t.Fatalf("ioctl = %X, want %X", ioctl, ioctlEscape)
+				}
+
+				return resp(in), nil
+			},
+		}, nil
+	}
+}
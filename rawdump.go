@@ -0,0 +1,204 @@
+package acr122u
+
+import "fmt"
+
+// DumpRaw reads the entire accessible memory of the card in the layout of
+// the usual NFC dump files, to archive it or to inspect it with other tools.
+//
+// MIFARE Classic cards are dumped like libnfc/mfoc .mfd files, all blocks of
+// all sectors in order. Each sector is authenticated with the first of the
+// keys (as key A) that works, defaulting to well-known keys, and the key is
+// stored in the sector trailer, where cards read key A as zeros.
+//
+// NTAG21x tags are dumped as raw pages, all pages from the UID up to and
+// including the configuration pages. PWD and PACK read as zeros.
+func (c *card) DumpRaw(keys ...[6]byte) ([]byte, error) {
+	return c.DumpRawWithProgress(nil, keys...)
+}
+
+// DumpRawWithProgress dumps the card like DumpRaw, calling fn with the number
+// of blocks or pages read so far and in total after each one, e.g. to show a
+// progress bar. A nil fn is not called.
+func (c *card) DumpRawWithProgress(fn func(done, total int), keys ...[6]byte) ([]byte, error) {
+	if fn == nil {
+		fn = func(int, int) {}
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case CardTypeMifareMini, CardTypeMifareClassic1K, CardTypeMifareClassic4K:
+		if len(keys) == 0 {
+			keys = dumpKeys
+		}
+		return c.dumpRawMifare(mifareSectorCount(t), keys, fn)
+	case CardTypeMifareUltralight:
+		return c.dumpRawNTAG(fn)
+	default:
+		return nil, wrapError(t.String(), ErrNotSupported)
+	}
+}
+
+// RestoreRaw writes a dump of DumpRaw back to a card of the same type.
+//
+// For MIFARE Classic cards the data blocks are written, authenticating each
+// sector with the key A of the dumped sector trailer or else with the keys.
+// The manufacturer block and the sector trailers are not written, so the
+// UID, keys and access conditions of the card are kept.
+//
+// For NTAG21x tags the user pages are written. The UID, lock, capability
+// container and configuration pages are not written.
+func (c *card) RestoreRaw(data []byte, keys ...[6]byte) error {
+	if c.readOnly {
+		return ErrReadOnlyMode
+	}
+
+	t, err := c.Type()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case CardTypeMifareMini, CardTypeMifareClassic1K, CardTypeMifareClassic4K:
+		return c.restoreRawMifare(mifareSectorCount(t), data, keys)
+	case CardTypeMifareUltralight:
+		return c.restoreRawNTAG(data)
+	default:
+		return wrapError(t.String(), ErrNotSupported)
+	}
+}
+
+// mifareDumpSize returns the size of the dump of the sectors in bytes
+func mifareDumpSize(sectors int) int {
+	last := byte(sectors - 1)
+	return (int(TrailerBlock(last)) + 1) * mifareBlockSize
+}
+
+// dumpRawMifare reads all blocks of the sectors
+func (c *card) dumpRawMifare(sectors int, keys [][6]byte, progress func(done, total int)) ([]byte, error) {
+	dump := make([]byte, 0, mifareDumpSize(sectors))
+	total := mifareDumpSize(sectors) / mifareBlockSize
+
+	for sector := byte(0); int(sector) < sectors; sector++ {
+		first := firstBlockOfSector(sector)
+
+		key, err := c.authenticateAny(first, keys)
+		if err != nil {
+			return nil, wrapError(fmt.Sprintf("dump sector %d", sector), err)
+		}
+
+		// The last trailer of a 4K card is block 255, so count past it
+		for block := int(first); block <= int(TrailerBlock(sector)); block++ {
+			data, err := c.ReadBlock(byte(block))
+			if err != nil {
+				return nil, err
+			}
+			if block == int(TrailerBlock(sector)) {
+				data = append(key[:], data[6:]...)
+			}
+			dump = append(dump, data...)
+			progress(len(dump)/mifareBlockSize, total)
+		}
+	}
+
+	return dump, nil
+}
+
+// restoreRawMifare writes the data blocks of the dump
+func (c *card) restoreRawMifare(sectors int, data []byte, keys [][6]byte) error {
+	if len(data) != mifareDumpSize(sectors) {
+		return wrapError(fmt.Sprintf("dump size %d, want %d", len(data), mifareDumpSize(sectors)), ErrInvalidParameter)
+	}
+
+	for sector := byte(0); int(sector) < sectors; sector++ {
+		first, trailer := firstBlockOfSector(sector), TrailerBlock(sector)
+
+		var dumped [6]byte
+		copy(dumped[:], data[int(trailer)*mifareBlockSize:])
+
+		if _, err := c.authenticateAny(first, append([][6]byte{dumped}, keys...)); err != nil {
+			return wrapError(fmt.Sprintf("restore sector %d", sector), err)
+		}
+
+		for block := first; block < trailer; block++ {
+			if block == 0 {
+				continue
+			}
+
+			offset := int(block) * mifareBlockSize
+			if err := c.WriteBlock(block, data[offset:offset+mifareBlockSize]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// authenticateAny authenticates the sector of the block with the first of
+// the keys that works, as key A
+func (c *card) authenticateAny(block byte, keys [][6]byte) ([6]byte, error) {
+	for _, key := range keys {
+		if err := c.Authenticate(block, key, KeyA); err == nil {
+			return key, nil
+		}
+	}
+
+	return [6]byte{}, wrapError("no key authenticated", ErrOperationFailed)
+}
+
+// ntagLayout returns the number of pages and user pages of the NTAG21x tag
+func (c *card) ntagLayout() (int, int, error) {
+	cfg0, version, err := c.ntagConfigPage()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// CFG0 is followed by CFG1, PWD and PACK
+	return int(cfg0) + 4, ntagStorageSize(version) / ntagPageSize, nil
+}
+
+// dumpRawNTAG reads all pages of the tag
+func (c *card) dumpRawNTAG(progress func(done, total int)) ([]byte, error) {
+	pages, _, err := c.ntagLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	dump := make([]byte, 0, pages*ntagPageSize)
+
+	for page := 0; page < pages; page++ {
+		data, err := c.ReadPage(byte(page))
+		if err != nil {
+			return nil, err
+		}
+		dump = append(dump, data...)
+		progress(page+1, pages)
+	}
+
+	return dump, nil
+}
+
+// restoreRawNTAG writes the user pages of the dump
+func (c *card) restoreRawNTAG(data []byte) error {
+	pages, userPages, err := c.ntagLayout()
+	if err != nil {
+		return err
+	}
+
+	if len(data) != pages*ntagPageSize {
+		return wrapError(fmt.Sprintf("dump size %d, want %d", len(data), pages*ntagPageSize), ErrInvalidParameter)
+	}
+
+	for page := type2DataPage; page < type2DataPage+userPages; page++ {
+		offset := page * ntagPageSize
+		if err := c.WritePage(byte(page), data[offset:offset+ntagPageSize]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
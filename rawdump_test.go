@@ -0,0 +1,138 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCardDumpRestoreRawNTAG(t *testing.T) {
+	m := newMockNTAG(ntag213Pages)
+	for page := 4; page < 40; page++ {
+		copy(m.pages[page], []byte{byte(page), 0xA0, 0xB0, 0xC0})
+	}
+	want := bytes.Join(m.pages, nil)
+
+	dump, err := m.card().DumpRaw()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(dump, want) {
+		t.Fatalf("dump = %X, want %X", dump, want)
+	}
+
+	restored := newMockNTAG(ntag213Pages)
+	if err := restored.card().RestoreRaw(dump); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := bytes.Join(restored.pages, nil); !bytes.Equal(got, want) {
+		t.Fatalf("restored = %X, want %X", got, want)
+	}
+
+	if err := restored.card().RestoreRaw(dump[:len(dump)-4]); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCardDumpRestoreRawMifare(t *testing.T) {
+	key := [6]byte{0x4D, 0x3A, 0x99, 0xC3, 0x51, 0xDD}
+
+	for _, tc := range []struct {
+		name string
+		atr  []byte
+		size int
+	}{
+		{"MIFARE Mini", atrMifareMini, 320},
+		{"MIFARE Classic 1K", atrMifareClassic1K, 1024},
+		{"MIFARE Classic 4K", atrMifareClassic4K, 4096},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockMifare(tc.atr)
+			m.key = key
+			for block := 1; block < len(m.blocks); block++ {
+				if !isTrailerBlock(byte(block)) {
+					m.blocks[block] = bytes.Repeat([]byte{byte(block)}, mifareBlockSize)
+				}
+			}
+			want := bytes.Join(m.blocks, nil)
+			// Key A of the trailers is stored in the dump
+			for block := range m.blocks {
+				if isTrailerBlock(byte(block)) {
+					copy(want[block*mifareBlockSize:], key[:])
+				}
+			}
+
+			// The well-known keys do not authenticate
+			if _, err := m.card().DumpRaw(); !errors.Is(err, ErrOperationFailed) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			dump, err := m.card().DumpRaw(key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(dump) != tc.size || !bytes.Equal(dump, want) {
+				t.Fatalf("dump = %X, want %X", dump, want)
+			}
+
+			restored := newMockMifare(tc.atr)
+			restored.key = key
+			if err := restored.card().RestoreRaw(dump); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for block := range m.blocks {
+				if !bytes.Equal(restored.blocks[block], m.blocks[block]) {
+					t.Fatalf("block %d = %X, want %X", block, restored.blocks[block], m.blocks[block])
+				}
+			}
+
+			if err := restored.card().RestoreRaw(dump[:tc.size-mifareBlockSize]); !errors.Is(err, ErrInvalidParameter) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// isTrailerBlock reports whether the block is the sector trailer of its sector
+func isTrailerBlock(block byte) bool {
+	return block == TrailerBlock(SectorForBlock(block))
+}
+
+func TestCardDumpRawWithProgress(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		card  func() *card
+		total int
+	}{
+		{"NTAG213", func() *card { return newMockNTAG(ntag213Pages).card() }, ntag213Pages},
+		{"MIFARE Classic 4K", func() *card { return newMockMifare(atrMifareClassic4K).card() }, 256},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []int
+
+			dump, err := tc.card().DumpRawWithProgress(func(done, total int) {
+				if total != tc.total {
+					t.Fatalf("total = %d, want %d", total, tc.total)
+				}
+				calls = append(calls, done)
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(calls) != tc.total || len(dump) == 0 {
+				t.Fatalf("progress called %d times, want %d", len(calls), tc.total)
+			}
+
+			for i, done := range calls {
+				if done != i+1 {
+					t.Fatalf("progress %d = %d, want %d", i, done, i+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package acr122u
+
+import (
+	"fmt"
+	"strings"
+)
+
+// normalizeReaderName normalizes a PC/SC reader name for comparison.
+//
+// The name is lower cased, the "PICC"/"SAM"/"Interface" words are removed and
+// the trailing slot number that pcsc-lite appends after the reader index is
+// dropped, so that
+//
+//	ACS ACR122U PICC Interface 00 00
+//	ACS ACR122U SAM Interface 00 01
+//
+// both normalize to "acs acr122u 00". The reader index is kept, so two
+// physical readers remain distinct, including Windows names such as
+// "ACS ACR122 0" and "ACS ACR122 1" which only carry the index.
+func normalizeReaderName(name string) string {
+	fields := strings.Fields(strings.ToLower(name))
+
+	if n := len(fields); n > 2 && isReaderNumber(fields[n-2]) && isReaderNumber(fields[n-1]) {
+		fields = fields[:n-1]
+	}
+
+	normalized := fields[:0]
+	for _, f := range fields {
+		switch f {
+		case "picc", "sam", "interface":
+		default:
+			normalized = append(normalized, f)
+		}
+	}
+
+	return strings.Join(normalized, " ")
+}
+
+// isPICCReader reports if the reader name is a PICC (contactless) interface
+func isPICCReader(name string) bool {
+	return strings.Contains(strings.ToUpper(name), "PICC")
+}
+
+// dedupeReaders collapses readers with identical normalized names,
+// preferring the PICC interface over any other interface of the same reader.
+func dedupeReaders(readers []string) []string {
+	var (
+		deduped []string
+		index   = map[string]int{}
+	)
+
+	for _, r := range readers {
+		n := normalizeReaderName(r)
+
+		i, ok := index[n]
+		if !ok {
+			index[n] = len(deduped)
+			deduped = append(deduped, r)
+			continue
+		}
+
+		if !isPICCReader(deduped[i]) && isPICCReader(r) {
+			deduped[i] = r
+		}
+	}
+
+	return deduped
+}
+
+// orderReaders moves the readers listed in order to the front, in that order,
+// followed by the unlisted readers in their original order
+func orderReaders(readers, order []string) ([]string, error) {
+	var (
+		ordered []string
+		listed  = map[string]bool{}
+	)
+
+	for _, r := range order {
+		if listed[r] || !containsReader(readers, r) {
+			return nil, wrapError(fmt.Sprintf("reader order %q", r), ErrInvalidParameter)
+		}
+		listed[r] = true
+		ordered = append(ordered, r)
+	}
+
+	for _, r := range readers {
+		if !listed[r] {
+			ordered = append(ordered, r)
+		}
+	}
+
+	return ordered, nil
+}
+
+func containsReader(readers []string, reader string) bool {
+	for _, r := range readers {
+		if r == reader {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isReaderNumber(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
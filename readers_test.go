@@ -0,0 +1,95 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeReaderName(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want string
+	}{
+		{"ACS ACR122U PICC Interface 00 00", "acs acr122u 00"},
+		{"ACS ACR122U SAM Interface 00 01", "acs acr122u 00"},
+		{"ACS  ACR122U 01 00", "acs acr122u 01"},
+		{"ACS ACR122 0", "acs acr122 0"},
+		{"ACS ACR122 1", "acs acr122 1"},
+		{"ACS ACR122U PICC Interface 0", "acs acr122u 0"},
+	} {
+		if got := normalizeReaderName(tc.name); got != tc.want {
+			t.Fatalf("normalizeReaderName(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDedupeReaders(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		readers []string
+		want    []string
+	}{
+		{
+			"Duplicate",
+			[]string{"ACS ACR122U PICC Interface 00 00", "ACS ACR122U PICC Interface 00 00"},
+			[]string{"ACS ACR122U PICC Interface 00 00"},
+		},
+		{
+			"SAM before PICC",
+			[]string{"ACS ACR122U SAM Interface 00 01", "ACS ACR122U PICC Interface 00 00"},
+			[]string{"ACS ACR122U PICC Interface 00 00"},
+		},
+		{
+			"PICC before SAM",
+			[]string{"ACS ACR122U PICC Interface 00 00", "ACS ACR122U SAM Interface 00 01"},
+			[]string{"ACS ACR122U PICC Interface 00 00"},
+		},
+		{
+			"Distinct readers",
+			[]string{"ACS ACR122U PICC Interface 00 00", "ACS ACR122U PICC Interface 01 00"},
+			[]string{"ACS ACR122U PICC Interface 00 00", "ACS ACR122U PICC Interface 01 00"},
+		},
+		{
+			"Distinct Windows readers",
+			[]string{"ACS ACR122 0", "ACS ACR122 1"},
+			[]string{"ACS ACR122 0", "ACS ACR122 1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dedupeReaders(tc.readers); !stringsEqual(got, tc.want) {
+				t.Fatalf("dedupeReaders(%q) = %q, want %q", tc.readers, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOrderReaders(t *testing.T) {
+	readers := []string{"r1", "r2", "r3"}
+
+	for _, tc := range []struct {
+		name  string
+		order []string
+		want  []string
+	}{
+		{"Empty", nil, []string{"r1", "r2", "r3"}},
+		{"All", []string{"r3", "r1", "r2"}, []string{"r3", "r1", "r2"}},
+		{"Partial", []string{"r3"}, []string{"r3", "r1", "r2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := orderReaders(readers, tc.order)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !stringsEqual(got, tc.want) {
+				t.Fatalf("orderReaders(%q) = %q, want %q", tc.order, got, tc.want)
+			}
+		})
+	}
+
+	for _, order := range [][]string{{"r4"}, {"r1", "r1"}} {
+		if _, err := orderReaders(readers, order); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("orderReaders(%q) unexpected error: %v", order, err)
+		}
+	}
+}
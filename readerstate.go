@@ -0,0 +1,63 @@
+package acr122u
+
+import "github.com/ebfe/scard"
+
+// ReaderState is the state of a reader as reported by the PC/SC layer,
+// see WithStateHandler
+type ReaderState struct {
+	// Reader is the name of the reader
+	Reader string
+
+	// Flags are the raw PC/SC state flags (SCARD_STATE_*)
+	Flags uint32
+
+	atr []byte
+}
+
+// newReaderState returns the event state of the PC/SC reader state
+func newReaderState(rs scard.ReaderState) ReaderState {
+	return ReaderState{
+		Reader: rs.Reader,
+		Flags:  uint32(rs.EventState),
+		atr:    append([]byte(nil), rs.Atr...),
+	}
+}
+
+// IsPresent reports whether a card is present
+func (s ReaderState) IsPresent() bool {
+	return s.has(scard.StatePresent)
+}
+
+// IsEmpty reports whether no card is present
+func (s ReaderState) IsEmpty() bool {
+	return s.has(scard.StateEmpty)
+}
+
+// IsMute reports whether the card does not answer to reset
+func (s ReaderState) IsMute() bool {
+	return s.has(scard.StateMute)
+}
+
+// IsUnpowered reports whether the card is not powered
+func (s ReaderState) IsUnpowered() bool {
+	return s.has(scard.StateUnpowered)
+}
+
+// IsInUse reports whether the card is in use by another application
+func (s ReaderState) IsInUse() bool {
+	return s.has(scard.StateInuse)
+}
+
+// ATR returns the answer to reset of the card, or nil if no card is present
+func (s ReaderState) ATR() []byte {
+	return s.atr
+}
+
+// String returns the names of the state flags, for example StateChanged & StatePresent
+func (s ReaderState) String() string {
+	return formatStateFlag(scard.StateFlag(s.Flags))
+}
+
+func (s ReaderState) has(flag scard.StateFlag) bool {
+	return scard.StateFlag(s.Flags)&flag != 0
+}
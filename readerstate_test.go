@@ -0,0 +1,95 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ebfe/scard"
+)
+
+func TestReaderState(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		flags scard.StateFlag
+		check func(ReaderState) bool
+		want  bool
+	}{
+		{"Present", scard.StateChanged | scard.StatePresent, ReaderState.IsPresent, true},
+		{"Not present", scard.StateEmpty, ReaderState.IsPresent, false},
+		{"Empty", scard.StateChanged | scard.StateEmpty, ReaderState.IsEmpty, true},
+		{"Not empty", scard.StatePresent, ReaderState.IsEmpty, false},
+		{"Mute", scard.StatePresent | scard.StateMute, ReaderState.IsMute, true},
+		{"Not mute", scard.StatePresent, ReaderState.IsMute, false},
+		{"Unpowered", scard.StatePresent | scard.StateUnpowered, ReaderState.IsUnpowered, true},
+		{"Not unpowered", scard.StatePresent, ReaderState.IsUnpowered, false},
+		{"In use", scard.StatePresent | scard.StateInuse, ReaderState.IsInUse, true},
+		{"Exclusive", scard.StatePresent | scard.StateExclusive, ReaderState.IsInUse, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newReaderState(scard.ReaderState{Reader: "Test", EventState: tc.flags})
+
+			if got := tc.check(s); got != tc.want {
+				t.Fatalf("%s: got %v, want %v", s, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReaderStateFields(t *testing.T) {
+	rs := scard.ReaderState{
+		Reader:       "Test",
+		CurrentState: scard.StateEmpty,
+		EventState:   scard.StateChanged | scard.StatePresent,
+		Atr:          atrMifareUltralight,
+	}
+
+	s := newReaderState(rs)
+
+	if s.Reader != "Test" {
+		t.Fatalf("s.Reader = %q, want %q", s.Reader, "Test")
+	}
+	if want := uint32(0x22); s.Flags != want {
+		t.Fatalf("s.Flags = %#x, want %#x", s.Flags, want)
+	}
+	if !bytes.Equal(s.ATR(), atrMifareUltralight) {
+		t.Fatalf("s.ATR() = %X, want %X", s.ATR(), atrMifareUltralight)
+	}
+	if want := "StateChanged & StatePresent"; s.String() != want {
+		t.Fatalf("s.String() = %q, want %q", s.String(), want)
+	}
+}
+
+func TestContextServeStateHandler(t *testing.T) {
+	var states []ReaderState
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent, scard.StatePresent|scard.StateMute, scard.StateEmpty),
+	}, WithInlineDispatch(), WithStateHandler(func(s ReaderState) {
+		states = append(states, s)
+		if s.IsEmpty() {
+			cancel()
+		}
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.ServeFunc(ctx, func(Card) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(states) != 3 {
+		t.Fatalf("states = %v, want 3", states)
+	}
+
+	for i, check := range []func(ReaderState) bool{ReaderState.IsPresent, ReaderState.IsMute, ReaderState.IsEmpty} {
+		if states[i].Reader != "Test" || !check(states[i]) {
+			t.Fatalf("states[%d] = %s of %q", i, states[i], states[i].Reader)
+		}
+	}
+}
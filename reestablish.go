@@ -0,0 +1,106 @@
+package acr122u
+
+import (
+	"context"
+	"time"
+)
+
+// reestablishBackoff is the default delay between read loop errors while
+// WithAutoReestablish is waiting for the error limit
+var reestablishBackoff = ExponentialBackoff{Initial: 100 * time.Millisecond, Max: 5 * time.Second}
+
+// Returns the backoff between read loop errors
+func (actx *Context) loopBackoff() Backoff {
+	if actx.backoff == nil {
+		return reestablishBackoff
+	}
+
+	return actx.backoff
+}
+
+// Returns the current PC/SC context, which changes when it is re-established
+func (actx *Context) pcsc() PCSCContext {
+	actx.contextMu.RLock()
+	defer actx.contextMu.RUnlock()
+
+	return actx.context
+}
+
+// Returns the generation of the PC/SC context, incremented when it is re-established
+func (actx *Context) contextGeneration() int {
+	actx.contextMu.RLock()
+	defer actx.contextMu.RUnlock()
+
+	return actx.generation
+}
+
+// Releases and re-establishes the PC/SC context unless it was already
+// re-established since generation gen, returning the current generation
+func (actx *Context) reestablish(gen int) (int, error) {
+	actx.contextMu.Lock()
+	defer actx.contextMu.Unlock()
+
+	if actx.generation != gen {
+		return actx.generation, nil
+	}
+
+	if actx.establish == nil {
+		return gen, wrapError("context was not established by EstablishContext", ErrNotSupported)
+	}
+
+	actx.connections.close()
+	if err := actx.context.Release(); err != nil {
+		actx.logger.Warn().Err(err).Msg("Problem releasing context")
+	}
+
+	sctx, err := actx.establish()
+	if err != nil {
+		return gen, pcscError(err)
+	}
+
+	actx.context = actx.recordContext(sctx)
+	actx.generation++
+
+	return actx.generation, nil
+}
+
+// loopErrors counts the consecutive errors of a read loop for WithAutoReestablish
+type loopErrors struct {
+	count      int
+	generation int
+}
+
+// Records a read loop error, waiting before the loop retries and
+// re-establishing the PC/SC context once the error limit is reached.
+// Without an error limit the loop only retries if it is resilient.
+// Reports whether the loop should retry, and whether the context changed.
+func (actx *Context) loopError(ctx context.Context, errs *loopErrors) (retry, changed bool) {
+	if actx.maxErrors == 0 && !actx.resilient {
+		return false, false
+	}
+
+	// Next is 0 based, so the first error waits Initial
+	attempt := errs.count
+	errs.count++
+	if actx.maxErrors > 0 && errs.count >= actx.maxErrors {
+		gen, err := actx.reestablish(errs.generation)
+		if err != nil {
+			actx.logger.Error().Err(err).Msg("Problem re-establishing context")
+		} else {
+			actx.logger.Info().Int("Errors", errs.count).Msg("Re-established context")
+			changed = gen != errs.generation
+			errs.count, errs.generation = 0, gen
+			attempt = 0
+		}
+	}
+
+	timer := time.NewTimer(actx.loopBackoff().Next(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false, changed
+	case <-timer.C:
+		return true, changed
+	}
+}
@@ -0,0 +1,186 @@
+package acr122u
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextServeAutoReestablish(t *testing.T) {
+	defer func(b BackoffConfig) {
+		reestablishBackoff = b
+	}(reestablishBackoff)
+	reestablishBackoff = BackoffConfig{Initial: time.Millisecond, Max: time.Millisecond}
+
+	var (
+		failures    int
+		released    bool
+		established int
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		release: func() error {
+			released = true
+			return nil
+		},
+		getStatusChange: func([]scard.ReaderState, time.Duration) error {
+			failures++
+			return scard.ErrInvalidHandle
+		},
+	}, WithAutoReestablish(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actx.establish = func() (PCSCContext, error) {
+		established++
+		return &mockContext{
+			connect:         uidConnect,
+			getStatusChange: statusSequence(scard.StatePresent),
+		}, nil
+	}
+
+	if err := actx.ServeFunc(ctx, func(Card) { cancel() }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if failures != 3 || !released || established != 1 {
+		t.Fatalf("failures = %d, released = %v, established = %d, want 3, true, 1", failures, released, established)
+	}
+
+	if got := actx.contextGeneration(); got != 1 {
+		t.Fatalf("actx.contextGeneration() = %d, want 1", got)
+	}
+}
+
+func TestContextServeBackoff(t *testing.T) {
+	var (
+		backoff  recordingBackoff
+		failures int
+		states   = statusSequence(scard.StatePresent)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect: uidConnect,
+		getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+			if failures < 2 {
+				failures++
+				return scard.ErrReaderUnavailable
+			}
+			return states(rs, timeout)
+		},
+	}, WithAutoReestablish(5), WithBackoff(&backoff), WithInlineDispatch())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.ServeFunc(ctx, func(Card) { cancel() }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []int{0, 1}; len(backoff.attempts) != 2 || backoff.attempts[0] != want[0] || backoff.attempts[1] != want[1] {
+		t.Fatalf("attempts = %v, want %v", backoff.attempts, want)
+	}
+
+	if backoff.resets != 1 {
+		t.Fatalf("resets = %d, want 1", backoff.resets)
+	}
+}
+
+func TestContextServeResilientLoop(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		options   []Option
+		handled   bool
+		cardError bool
+		wantErr   error
+	}{
+		{"Strict", nil, false, false, ErrReaderUnavailable},
+		{"Resilient", []Option{WithResilientLoop(), WithBackoff(ConstantBackoff(time.Millisecond))}, true, true, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				handled   bool
+				failures  int
+				cardError bool
+				states    = statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent)
+				connects  int
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			actx, err := newContext(&mockContext{
+				connect: func(reader string, m scard.ShareMode, p scard.Protocol) (PCSCCard, error) {
+					if connects++; connects == 1 {
+						return nil, scard.ErrUnresponsiveCard
+					}
+					return uidConnect(reader, m, p)
+				},
+				getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+					if failures < 3 {
+						failures++
+						return scard.ErrReaderUnavailable
+					}
+					return states(rs, timeout)
+				},
+			}, append(tc.options, WithCardErrorHandler(func(string, error) { cardError = true }))...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := actx.ServeFunc(ctx, func(Card) {
+				handled = true
+				cancel()
+			}); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("actx.ServeFunc() = %v, want %v", err, tc.wantErr)
+			}
+
+			if handled != tc.handled || cardError != tc.cardError {
+				t.Fatalf("handled = %v, card error = %v, want %v, %v", handled, cardError, tc.handled, tc.cardError)
+			}
+			if !tc.handled && ctx.Err() != nil {
+				t.Fatalf("Serve returned after %v, want on the first error", ctx.Err())
+			}
+		})
+	}
+}
+
+func TestContextReestablish(t *testing.T) {
+	t.Run("Already re-established", func(t *testing.T) {
+		actx, err := newContext(&mockContext{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		actx.generation = 2
+
+		if gen, err := actx.reestablish(1); gen != 2 || err != nil {
+			t.Fatalf("actx.reestablish(1) = %d, %v, want 2, nil", gen, err)
+		}
+	})
+
+	t.Run("Not established", func(t *testing.T) {
+		actx, err := newContext(&mockContext{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := actx.reestablish(0); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		if _, err := newContext(&mockContext{}, WithAutoReestablish(-1)); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package acr122u
+
+import "sync"
+
+// cardRemoval is the UserData of the reader state of a removed card
+type cardRemoval struct {
+	reader string
+	last   *CardSnapshot
+}
+
+// removalTracker counts the removals per reader and, with a removal handler,
+// keeps a snapshot of the last card read per reader until the card is removed.
+// A nil *removalTracker is valid and tracks nothing.
+type removalTracker struct {
+	mu       sync.Mutex
+	fn       func(reader string, last *CardSnapshot)
+	last     map[string]*CardSnapshot
+	removals map[string]int
+}
+
+func newRemovalTracker(fn func(reader string, last *CardSnapshot)) *removalTracker {
+	return &removalTracker{fn: fn, last: map[string]*CardSnapshot{}, removals: map[string]int{}}
+}
+
+// read records the card read, which must still be connected
+func (rt *removalTracker) read(c Card) {
+	if rt == nil || rt.fn == nil {
+		return
+	}
+
+	s := newCardSnapshot(c)
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.last[s.Reader] = &s
+}
+
+// removed counts the removal of the card from the reader and returns its
+// UserData, forgetting the last card read, or nil without a removal handler
+func (rt *removalTracker) removed(reader string) any {
+	if rt == nil {
+		return nil
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.removals[reader]++
+	if rt.fn == nil {
+		return nil
+	}
+
+	last := rt.last[reader]
+	delete(rt.last, reader)
+
+	return cardRemoval{reader: reader, last: last}
+}
+
+// count returns the number of removals from the reader so far
+func (rt *removalTracker) count(reader string) int {
+	if rt == nil {
+		return 0
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	return rt.removals[reader]
+}
+
+// removalsBefore returns the number of removals from the reader before the
+// card was presented
+func (c *card) removalsBefore() int {
+	return c.removals
+}
+
+// Returns the number of removals from the reader before the card was
+// presented, or so far if a middleware hides the card read
+func (actx *Context) removalsBefore(c Card) int {
+	if rc, ok := c.(interface{ removalsBefore() int }); ok {
+		return rc.removalsBefore()
+	}
+
+	return actx.removals.count(c.Reader())
+}
+
+// Reports whether a card was removed from the reader after its first n
+// removals, before c was presented
+func (actx *Context) removedSince(reader string, n int, c Card) bool {
+	if c.Reader() == reader {
+		return actx.removalsBefore(c) > n
+	}
+
+	return actx.removals.count(reader) > n
+}
+
+// Calls the removal handler
+func (actx *Context) handleRemoval(r cardRemoval) {
+	actx.removals.fn(r.reader, r.last)
+}
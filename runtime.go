@@ -0,0 +1,81 @@
+package acr122u
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runtimeLimit stops a Serve loop once the maximum runtime has passed,
+// either on the context's clock or in real time.
+// A nil *runtimeLimit is valid and never expires.
+type runtimeLimit struct {
+	mu       sync.Mutex
+	deadline time.Time
+	stop     context.CancelFunc
+	timer    *time.Timer
+	expired  bool
+}
+
+// Returns ctx cancelled once the maximum runtime of a Serve loop starting now
+// has passed, and the limit to release when the loop returns
+func (actx *Context) limitRuntime(ctx context.Context) (context.Context, *runtimeLimit) {
+	if actx.maxRuntime <= 0 {
+		actx.runtime = nil
+		return ctx, nil
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	rl := &runtimeLimit{deadline: actx.clock.Now().Add(actx.maxRuntime), stop: stop}
+	rl.timer = time.AfterFunc(actx.maxRuntime, rl.expire)
+	actx.runtime = rl
+
+	return ctx, rl
+}
+
+// check expires the limit if now is past the deadline
+func (rl *runtimeLimit) check(now time.Time) {
+	if rl == nil || now.Before(rl.deadline) {
+		return
+	}
+
+	rl.expire()
+}
+
+// expire stops the Serve loop unless it was already stopped
+func (rl *runtimeLimit) expire() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.stop == nil {
+		return
+	}
+
+	rl.expired = true
+	rl.stop()
+	rl.stop = nil
+}
+
+// release stops the timer and the context, returning ErrMaxRuntimeReached if
+// the limit expired
+func (rl *runtimeLimit) release() error {
+	if rl == nil {
+		return nil
+	}
+
+	rl.timer.Stop()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.stop != nil {
+		rl.stop()
+		rl.stop = nil
+	}
+
+	if rl.expired {
+		return ErrMaxRuntimeReached
+	}
+
+	return nil
+}
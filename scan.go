@@ -0,0 +1,179 @@
+package acr122u
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ebfe/scard"
+)
+
+// ReaderError is an error of a reader
+type ReaderError struct {
+	Reader string
+	Err    error
+}
+
+func (e *ReaderError) Error() string {
+	return e.Reader + ": " + e.Err.Error()
+}
+
+func (e *ReaderError) Unwrap() error {
+	return e.Err
+}
+
+// ReaderErrors combines the errors of several readers, see ServeMap
+type ReaderErrors []*ReaderError
+
+func (e ReaderErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether the error of any reader matches target
+func (e ReaderErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of a reader that matches target
+func (e ReaderErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanErrorBuffer is the number of card read errors ScanAll buffers
+// in addition to an error per reader
+const scanErrorBuffer = 16
+
+// ScanAll polls each reader in its own goroutine, merging the cards read into
+// one channel. Errors are sent as *ReaderError. A failing card read is
+// skipped, a failing reader stops without affecting the other readers.
+// Errors never block the readers: the error channel keeps room for the error
+// stopping each reader, and buffers up to 16 card read errors besides, which
+// are dropped while the buffer is full. Both channels are closed once ctx is
+// done or all readers have failed.
+//
+// The cards are disconnected before they are sent, use Serve to communicate
+// with cards. Cards blocked by WithUIDBlocklist are not sent.
+func (actx *Context) ScanAll(ctx context.Context) (<-chan Card, <-chan error) {
+	var (
+		wg    sync.WaitGroup
+		cards = make(chan Card)
+		errs  = newScanErrors(len(actx.readers))
+	)
+	for _, r := range actx.readers {
+		wg.Add(1)
+		go func(reader string) {
+			defer wg.Done()
+			errs.stop(reader, actx.scanReader(ctx, reader, cards, errs))
+		}(r)
+	}
+	go func() {
+		wg.Wait()
+		close(cards)
+		close(errs.ch)
+	}()
+	return cards, errs.ch
+}
+
+// scanErrors sends the errors of the readers of ScanAll without blocking.
+// A slot is kept for the error stopping each running reader, so only card
+// read errors are dropped.
+type scanErrors struct {
+	mu      sync.Mutex
+	ch      chan error
+	running int
+}
+
+func newScanErrors(readers int) *scanErrors {
+	return &scanErrors{ch: make(chan error, readers+scanErrorBuffer), running: readers}
+}
+
+// cardError sends a card read error of the reader, reporting false if it
+// was dropped as the buffer is full
+func (e *scanErrors) cardError(reader string, err error) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.ch)+e.running >= cap(e.ch) {
+		return false
+	}
+	e.ch <- &ReaderError{Reader: reader, Err: err}
+	return true
+}
+
+// stop sends the error stopping the reader, if any, into its slot
+func (e *scanErrors) stop(reader string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.running--
+	if err != nil {
+		e.ch <- &ReaderError{Reader: reader, Err: err}
+	}
+}
+
+// Polls the reader, sending cards read and card read errors until ctx is
+// done or polling fails, returning the polling error
+func (actx *Context) scanReader(ctx context.Context, reader string, cards chan<- Card, errs *scanErrors) error {
+	var (
+		logger = actx.logger.With().Str("Caller", "scanReader").Str("Reader", reader).Logger()
+		rs     = initializeReaderState([]string{reader})
+	)
+	for {
+		err := actx.waitForStatusChange(ctx, rs, pollInterval)
+		if err == ErrShutdown {
+			return nil
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Problem waiting for status change")
+			return err
+		}
+		if rs[0].EventState != rs[0].CurrentState && rs[0].EventState&scard.StatePresent != 0 {
+			if !actx.awaitReadInterval(ctx, reader) {
+				return nil
+			}
+			c, err := actx.readCardData(rs[0])
+			switch {
+			case err != nil:
+				logger.Error().Err(err).Msg("Problem reading card data")
+				if !errs.cardError(reader, err) {
+					logger.Warn().Err(err).Msg("Dropped card read error, errors not received")
+				}
+			case c != nil:
+				denied := actx.denyBlocked(c, logger)
+				if err := actx.disconnect(c); err != nil {
+					logger.Error().Err(err).Msg("Problem disconnecting")
+				}
+				if denied {
+					break
+				}
+				select {
+				case cards <- c:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		if rs[0].EventState&scard.StatePresent == 0 {
+			if actx.readCache != nil {
+				actx.readCache.clear(reader)
+			}
+			actx.connections.drop(reader)
+		}
+		rs[0].CurrentState = rs[0].EventState
+	}
+}
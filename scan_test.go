@@ -0,0 +1,237 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextScanAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	good := statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent)
+
+	actx, err := newContext(&mockContext{
+		listReaders: func() ([]string, error) {
+			return []string{"bad", "good"}, nil
+		},
+		connect: uidConnect,
+		getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+			if rs[0].Reader == "bad" {
+				return scard.ErrReaderUnavailable
+			}
+			return good(rs, timeout)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var (
+		cards, errs = actx.ScanAll(ctx)
+		read        int
+		readerErr   *ReaderError
+	)
+
+	for read < 2 || readerErr == nil {
+		select {
+		case c := <-cards:
+			if c.Reader() != "good" {
+				t.Fatalf("c.Reader() = %q, want %q", c.Reader(), "good")
+			}
+			read++
+		case err := <-errs:
+			if !errors.As(err, &readerErr) || readerErr.Reader != "bad" || !errors.Is(err, ErrReaderUnavailable) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("read %d cards, reader error %v", read, readerErr)
+		}
+	}
+
+	cancel()
+
+	for range cards {
+	}
+
+	if _, ok := <-errs; ok {
+		t.Fatalf("errs not closed")
+	}
+}
+
+func TestContextScanAllBlocklist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	denied := make(chan []byte, 2)
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent),
+	}, WithUIDBlocklist([][]byte{testUID}), WithDeniedCallback(func(c Card) {
+		denied <- c.UID()
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cards, _ := actx.ScanAll(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case c := <-cards:
+			t.Fatalf("blocked card %X sent", c.UID())
+		case uid := <-denied:
+			if !bytes.Equal(uid, testUID) {
+				t.Fatalf("denied UID = %X, want %X", uid, testUID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("denied %d cards, want 2", i)
+		}
+	}
+
+	cancel()
+
+	for c := range cards {
+		t.Fatalf("blocked card %X sent", c.UID())
+	}
+}
+
+func TestContextScanAllUndrainedErrors(t *testing.T) {
+	actx, err := newContext(&mockContext{
+		getStatusChange: func([]scard.ReaderState, time.Duration) error {
+			return scard.ErrReaderUnavailable
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cards, errs := actx.ScanAll(context.Background())
+
+	// The failing reader stops without its error being received
+	select {
+	case _, ok := <-cards:
+		if ok {
+			t.Fatalf("unexpected card")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("cards not closed")
+	}
+
+	if err := <-errs; !errors.Is(err, ErrReaderUnavailable) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestContextScanAllCardErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		failures int32
+		good     = statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent)
+		bad      scard.StateFlag
+	)
+
+	actx, err := newContext(&mockContext{
+		listReaders: func() ([]string, error) {
+			return []string{"bad", "good"}, nil
+		},
+		connect: func(reader string, mode scard.ShareMode, protocol scard.Protocol) (PCSCCard, error) {
+			if reader == "bad" {
+				atomic.AddInt32(&failures, 1)
+				return nil, scard.ErrUnresponsiveCard
+			}
+			return uidConnect(reader, mode, protocol)
+		},
+		getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+			if rs[0].Reader == "bad" {
+				// A card read failing over and over
+				bad ^= scard.StatePresent
+				rs[0].EventState = bad
+				return nil
+			}
+			// The good reader reads cards once many errors are pending
+			if atomic.LoadInt32(&failures) <= 2*scanErrorBuffer {
+				time.Sleep(time.Millisecond)
+				return scard.ErrTimeout
+			}
+			return good(rs, timeout)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cards, errs := actx.ScanAll(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case c := <-cards:
+			if c.Reader() != "good" {
+				t.Fatalf("c.Reader() = %q, want %q", c.Reader(), "good")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("read %d cards, want 2", i)
+		}
+	}
+
+	cancel()
+
+	for range cards {
+	}
+
+	var received int
+	for err := range errs {
+		if !errors.Is(err, scard.ErrUnresponsiveCard) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		received++
+	}
+
+	if received != scanErrorBuffer {
+		t.Fatalf("received %d errors, want %d", received, scanErrorBuffer)
+	}
+}
+
+func TestContextScanAllReadCache(t *testing.T) {
+	var identities int
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect:         uidConnect,
+		getStatusChange: statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent),
+	}, WithReadCache(time.Minute), WithIdentityFunc(func(Card) (string, error) {
+		identities++
+		return "identity", nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cards, _ := actx.ScanAll(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-cards:
+		case <-time.After(time.Second):
+			t.Fatalf("read %d cards, want 2", i)
+		}
+	}
+
+	cancel()
+
+	// The removal between the reads cleared the cache
+	if got, want := identities, 2; got != want {
+		t.Fatalf("identities = %d, want %d", got, want)
+	}
+}
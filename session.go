@@ -0,0 +1,230 @@
+package acr122u
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+// Session event operations
+const (
+	SessionReaders      = "readers"
+	SessionStatusChange = "status-change"
+	SessionConnect      = "connect"
+	SessionTransmit     = "transmit"
+	SessionCardStatus   = "card-status"
+	SessionDisconnect   = "disconnect"
+)
+
+// SessionEvent is a PC/SC call recorded by WithSessionRecorder, written as
+// a line of JSON. Byte strings are hex encoded.
+type SessionEvent struct {
+	// At is the time since the recording started
+	At time.Duration `json:"at"`
+
+	// Op is the recorded operation
+	Op string `json:"op"`
+
+	// Card numbers the connected cards, identifying the card of transmits
+	Card int `json:"card,omitempty"`
+
+	// Reader is the reader connected to
+	Reader string `json:"reader,omitempty"`
+
+	// Readers are the readers listed by SessionReaders
+	Readers []string `json:"readers,omitempty"`
+
+	// States are the reader states returned by SessionStatusChange
+	States []SessionReaderState `json:"states,omitempty"`
+
+	// Command is the APDU sent by SessionTransmit
+	Command string `json:"command,omitempty"`
+
+	// Response is the response of SessionTransmit
+	Response string `json:"response,omitempty"`
+
+	// State, Protocol and ATR are the card status of SessionCardStatus
+	State    uint32 `json:"state,omitempty"`
+	Protocol uint32 `json:"protocol,omitempty"`
+	ATR      string `json:"atr,omitempty"`
+
+	// Code is the scard error code of a failed operation, which is
+	// scard.ErrUnknownError for other errors
+	Code uint32 `json:"code,omitempty"`
+
+	// Error is the message of a failed operation
+	Error string `json:"error,omitempty"`
+}
+
+// SessionReaderState is the state of a reader recorded by SessionStatusChange
+type SessionReaderState struct {
+	Reader       string `json:"reader"`
+	CurrentState uint32 `json:"current"`
+	EventState   uint32 `json:"event"`
+	ATR          string `json:"atr,omitempty"`
+}
+
+// Err returns the error of a failed operation, or nil
+func (e SessionEvent) Err() error {
+	if e.Code == 0 {
+		return nil
+	}
+
+	return scard.Error(e.Code)
+}
+
+// WithSessionRecorder writes the reader states and APDU exchanges of the
+// context to w as SessionEvents, one JSON object per line, so a session can
+// be replayed to reproduce a problem, see acr122utest.ReplaySession.
+func WithSessionRecorder(w io.Writer) Option {
+	return func(actx *Context) {
+		actx.recorder = &sessionRecorder{enc: json.NewEncoder(w)}
+	}
+}
+
+// sessionRecorder writes SessionEvents
+type sessionRecorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	clock Clock
+	start time.Time
+	cards int
+}
+
+// Starts the recording of the context with the readers
+func (r *sessionRecorder) begin(readers []string, clock Clock) {
+	r.clock, r.start = clock, clock.Now()
+	r.write(SessionEvent{Op: SessionReaders, Readers: readers})
+}
+
+// Returns the PC/SC context recording its calls, or pc if not recording
+func (actx *Context) recordContext(pc PCSCContext) PCSCContext {
+	if actx.recorder == nil {
+		return pc
+	}
+
+	return &recordingContext{PCSCContext: pc, r: actx.recorder}
+}
+
+// Writes the event, setting its time
+func (r *sessionRecorder) write(e SessionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.At = r.clock.Now().Sub(r.start)
+	// Errors writing the recording must not affect the session
+	_ = r.enc.Encode(e)
+}
+
+// Returns the next card number
+func (r *sessionRecorder) nextCard() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cards++
+
+	return r.cards
+}
+
+// Sets the error of the event
+func recordError(e *SessionEvent, err error) {
+	if err == nil {
+		return
+	}
+
+	var code scard.Error
+	if !errors.As(err, &code) {
+		code = scard.ErrUnknownError
+	}
+	e.Code, e.Error = uint32(code), err.Error()
+}
+
+// recordingContext records the calls to a PC/SC context
+type recordingContext struct {
+	PCSCContext
+	r *sessionRecorder
+}
+
+func (rc *recordingContext) GetStatusChange(rs []scard.ReaderState, timeout time.Duration) error {
+	err := rc.PCSCContext.GetStatusChange(rs, timeout)
+
+	e := SessionEvent{Op: SessionStatusChange}
+	for _, s := range rs {
+		e.States = append(e.States, SessionReaderState{
+			Reader:       s.Reader,
+			CurrentState: uint32(s.CurrentState),
+			EventState:   uint32(s.EventState),
+			ATR:          hex.EncodeToString(s.Atr),
+		})
+	}
+	recordError(&e, err)
+	rc.r.write(e)
+
+	return err
+}
+
+func (rc *recordingContext) Connect(reader string, mode scard.ShareMode, proto scard.Protocol) (PCSCCard, error) {
+	sc, err := rc.PCSCContext.Connect(reader, mode, proto)
+
+	e := SessionEvent{Op: SessionConnect, Reader: reader}
+	if err == nil {
+		e.Card = rc.r.nextCard()
+		sc = &recordingCard{PCSCCard: sc, r: rc.r, reader: reader, card: e.Card}
+	}
+	recordError(&e, err)
+	rc.r.write(e)
+
+	return sc, err
+}
+
+// recordingCard records the calls to a PC/SC card
+type recordingCard struct {
+	PCSCCard
+	r      *sessionRecorder
+	reader string
+	card   int
+}
+
+func (rc *recordingCard) Transmit(cmd []byte) ([]byte, error) {
+	resp, err := rc.PCSCCard.Transmit(cmd)
+
+	e := SessionEvent{
+		Op:       SessionTransmit,
+		Card:     rc.card,
+		Reader:   rc.reader,
+		Command:  hex.EncodeToString(cmd),
+		Response: hex.EncodeToString(resp),
+	}
+	recordError(&e, err)
+	rc.r.write(e)
+
+	return resp, err
+}
+
+func (rc *recordingCard) Status() (*scard.CardStatus, error) {
+	s, err := rc.PCSCCard.Status()
+
+	e := SessionEvent{Op: SessionCardStatus, Card: rc.card, Reader: rc.reader}
+	if s != nil {
+		e.State, e.Protocol, e.ATR = uint32(s.State), uint32(s.ActiveProtocol), hex.EncodeToString(s.Atr)
+	}
+	recordError(&e, err)
+	rc.r.write(e)
+
+	return s, err
+}
+
+func (rc *recordingCard) Disconnect(d scard.Disposition) error {
+	err := rc.PCSCCard.Disconnect(d)
+
+	e := SessionEvent{Op: SessionDisconnect, Card: rc.card, Reader: rc.reader}
+	recordError(&e, err)
+	rc.r.write(e)
+
+	return err
+}
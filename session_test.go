@@ -0,0 +1,87 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextSessionRecorder(t *testing.T) {
+	var session bytes.Buffer
+
+	clock := newMockClock()
+
+	actx, err := newContext(&mockContext{
+		getStatusChange: statusSequence(scard.StatePresent),
+		connect:         uidConnect,
+	}, WithClock(clock), WithSessionRecorder(&session))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rs := actx.initializeReaderState()
+	if err := actx.waitForStatusChange(context.Background(), rs, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(time.Second)
+
+	c, err := actx.readCardData(rs[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var events []SessionEvent
+	for dec := json.NewDecoder(&session); dec.More(); {
+		var e SessionEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	want := []SessionEvent{
+		{Op: SessionReaders, Readers: actx.Readers()},
+		{Op: SessionStatusChange, States: []SessionReaderState{{Reader: rs[0].Reader, EventState: uint32(scard.StatePresent)}}},
+		{At: time.Second, Op: SessionConnect, Card: 1, Reader: rs[0].Reader},
+		{At: time.Second, Op: SessionTransmit, Card: 1, Reader: rs[0].Reader, Command: "ffca000000", Response: "83fb5824909000"},
+		{At: time.Second, Op: SessionDisconnect, Card: 1, Reader: rs[0].Reader},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+
+	for i := range want {
+		got, _ := json.Marshal(events[i])
+		exp, _ := json.Marshal(want[i])
+		if !bytes.Equal(got, exp) {
+			t.Fatalf("events[%d] = %s, want %s", i, got, exp)
+		}
+	}
+}
+
+func TestSessionEventErr(t *testing.T) {
+	var e SessionEvent
+	recordError(&e, wrapError("connect", scard.ErrNoSmartcard))
+
+	if err := e.Err(); err != scard.ErrNoSmartcard {
+		t.Fatalf("Err() = %v, want %v", err, scard.ErrNoSmartcard)
+	}
+
+	recordError(&e, ErrOperationFailed)
+
+	if err := e.Err(); err != scard.ErrUnknownError {
+		t.Fatalf("Err() = %v, want %v", err, scard.ErrUnknownError)
+	}
+
+	if err := (SessionEvent{}).Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,33 @@
+package acr122u
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// cardEvent is the JSON representation of a card read published to sinks
+type cardEvent struct {
+	Reader string    `json:"reader"`
+	UID    string    `json:"uid"`
+	Time   time.Time `json:"time"`
+}
+
+func newCardEvent(c Card) cardEvent {
+	return cardEvent{
+		Reader: c.Reader(),
+		UID:    hex.EncodeToString(c.UID()),
+		Time:   time.Now(),
+	}
+}
+
+// cardSink receives card events in addition to the handler.
+// publish must not block and reports if the event was dropped,
+// run is started by Serve and returns when ctx is done. Serve waits for run
+// to return, and concurrent Serve calls run the sink concurrently.
+type cardSink interface {
+	publish(e cardEvent) bool
+	run(ctx context.Context, logger zerolog.Logger)
+}
@@ -0,0 +1,83 @@
+package acr122u
+
+import (
+	"fmt"
+	"sync"
+)
+
+// pn532WakeUpHost is the PowerDown WakeUpEnable mask waking the PN532 on
+// activity of any host interface (I2C, GPIO, SPI, HSU), so the next command
+// from the reader's controller wakes it
+const pn532WakeUpHost = 0xF0
+
+// sleepState tracks the readers put to sleep by Sleep
+type sleepState struct {
+	mu      sync.Mutex
+	readers map[string]bool
+}
+
+// set marks the reader as asleep or awake
+func (ss *sleepState) set(reader string, asleep bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if !asleep {
+		delete(ss.readers, reader)
+		return
+	}
+
+	if ss.readers == nil {
+		ss.readers = map[string]bool{}
+	}
+	ss.readers[reader] = true
+}
+
+// asleep reports whether the reader is asleep
+func (ss *sleepState) asleep(reader string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.readers[reader]
+}
+
+// Sleep puts the reader in a low power state for long idle periods, turning
+// off the RF field (RFConfiguration item 0x01) and powering down the PN532
+// (PowerDown command). Cards presented to a sleeping reader are not read by
+// Serve until Wake is called, and cards left on the reader while it was
+// asleep are only read once presented again.
+func (actx *Context) Sleep(reader string) error {
+	actx.sleep.set(reader, true)
+
+	if _, err := actx.pn532(reader, 0x32, 0x01, 0x00); err != nil {
+		actx.sleep.set(reader, false)
+		return wrapError("RF field off", err)
+	}
+
+	resp, err := actx.pn532(reader, 0x16, pn532WakeUpHost)
+	if err == nil && (len(resp) != 1 || resp[0]&0x3F != 0x00) {
+		err = wrapError(fmt.Sprintf("power down response %X", resp), ErrOperationFailed)
+	}
+	if err != nil {
+		// The field is off, keep the reader asleep until woken
+		return wrapError("power down", err)
+	}
+
+	return nil
+}
+
+// Wake wakes a reader put to sleep by Sleep, turning the RF field back on,
+// and resumes reading its cards in Serve.
+//
+// The command wakes the PN532, which takes a few milliseconds to restart its
+// oscillator before answering, so Wake returns once the reader is ready.
+// Cards presented after that are noticed by the next poll of Serve, within
+// about a second.
+func (actx *Context) Wake(reader string) error {
+	if _, err := actx.pn532(reader, 0x32, 0x01, 0x01); err != nil {
+		return wrapError("RF field on", err)
+	}
+
+	actx.sleep.set(reader, false)
+
+	return nil
+}
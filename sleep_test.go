@@ -0,0 +1,146 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextSleepWake(t *testing.T) {
+	var sent [][]byte
+
+	actx, err := newContext(&mockContext{
+		connect: escapeConnect(t, func(in []byte) []byte {
+			sent = append(sent, in)
+			return sleepResponse(in)
+		}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.Sleep("Test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !actx.sleep.asleep("Test") {
+		t.Fatal("reader not asleep after Sleep")
+	}
+
+	if err := actx.Wake("Test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if actx.sleep.asleep("Test") {
+		t.Fatal("reader asleep after Wake")
+	}
+
+	want := [][]byte{
+		{0xFF, 0x00, 0x00, 0x00, 0x04, 0xD4, 0x32, 0x01, 0x00},
+		{0xFF, 0x00, 0x00, 0x00, 0x03, 0xD4, 0x16, 0xF0},
+		{0xFF, 0x00, 0x00, 0x00, 0x04, 0xD4, 0x32, 0x01, 0x01},
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("sent = %X, want %X", sent, want)
+	}
+	for i := range want {
+		if !bytes.Equal(sent[i], want[i]) {
+			t.Fatalf("sent[%d] = %X, want %X", i, sent[i], want[i])
+		}
+	}
+}
+
+func TestContextSleepErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		resp   func(in []byte) []byte
+		asleep bool
+	}{
+		{"RF field", func([]byte) []byte { return []byte{0x63, 0x00} }, false},
+		{"Power down", func(in []byte) []byte {
+			if in[6] == 0x16 {
+				return []byte{0xD5, 0x17, 0x01, 0x90, 0x00}
+			}
+			return sleepResponse(in)
+		}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actx, err := newContext(&mockContext{connect: escapeConnect(t, tc.resp)})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := actx.Sleep("Test"); !errors.Is(err, ErrOperationFailed) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := actx.sleep.asleep("Test"); got != tc.asleep {
+				t.Fatalf("asleep = %v, want %v", got, tc.asleep)
+			}
+		})
+	}
+}
+
+func TestContextServeAsleep(t *testing.T) {
+	var (
+		actx     *Context
+		reads    int
+		handled  int
+		states   = statusSequence(scard.StatePresent, scard.StateEmpty, scard.StatePresent)
+		escape   = escapeConnect(t, sleepResponse)
+		statuses int
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		connect: func(reader string, mode scard.ShareMode, proto scard.Protocol) (PCSCCard, error) {
+			if mode == scard.ShareDirect {
+				return escape(reader, mode, proto)
+			}
+			reads++
+			return uidConnect(reader, mode, proto)
+		},
+		getStatusChange: func(rs []scard.ReaderState, timeout time.Duration) error {
+			// Wake the reader once the card presented while asleep is removed
+			if statuses++; statuses == 3 {
+				if err := actx.Wake("Test"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			return states(rs, timeout)
+		},
+	}, WithInlineDispatch())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.Sleep("Test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := actx.ServeFunc(ctx, func(Card) {
+		handled++
+		cancel()
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reads != 1 || handled != 1 {
+		t.Fatalf("reads = %d, handled = %d, want 1, 1", reads, handled)
+	}
+}
+
+// sleepResponse answers the RFConfiguration and PowerDown commands
+func sleepResponse(in []byte) []byte {
+	if in[6] == 0x16 {
+		return []byte{0xD5, 0x17, 0x00, 0x90, 0x00}
+	}
+
+	return []byte{0xD5, 0x33, 0x90, 0x00}
+}
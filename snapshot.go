@@ -0,0 +1,47 @@
+package acr122u
+
+import (
+	"encoding/hex"
+	"time"
+)
+
+// CardSnapshot contains the data captured when a card was read. Unlike Card
+// it does not communicate with the reader.
+type CardSnapshot struct {
+	Reader       string
+	UID          []byte
+	UIDHex       string
+	UIDLength    int
+	ATR          []byte
+	Type         CardType
+	ReadDuration time.Duration
+	Time         time.Time
+}
+
+// newCardSnapshot captures the card, which must still be connected to read its ATR
+func newCardSnapshot(c Card) CardSnapshot {
+	s := CardSnapshot{
+		Reader:       c.Reader(),
+		UID:          append([]byte{}, c.UID()...),
+		UIDHex:       hex.EncodeToString(c.UID()),
+		UIDLength:    len(c.UID()),
+		ReadDuration: c.ReadDuration(),
+		Time:         c.ReadTime(),
+	}
+
+	if atr, err := c.ATR(); err == nil {
+		s.ATR = append([]byte{}, atr...)
+		s.Type = cardTypeFromATR(atr)
+	}
+
+	return s
+}
+
+// SnapshotHandler is the function signature for handling a snapshot of a
+// card, for handlers that only need the data captured when it was read
+type SnapshotHandler func(CardSnapshot)
+
+// ServeCard makes SnapshotHandler implement the Handler interface
+func (sh SnapshotHandler) ServeCard(c Card) {
+	sh(newCardSnapshot(c))
+}
@@ -0,0 +1,62 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+func TestContextServeSnapshotHandler(t *testing.T) {
+	var got CardSnapshot
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clk := newMockClock()
+
+	actx, err := newContext(&mockContext{
+		connect: func(string, scard.ShareMode, scard.Protocol) (PCSCCard, error) {
+			return &mockCard{transmit: uidTransmit, status: atrStatus(atrMifareUltralight)}, nil
+		},
+		getStatusChange: statusSequence(scard.StatePresent),
+		listReaders: func() ([]string, error) {
+			return []string{"Test"}, nil
+		},
+	}, WithClock(clk))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = actx.Serve(ctx, SnapshotHandler(func(s CardSnapshot) {
+		got = s
+		cancel()
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Reader != "Test" {
+		t.Fatalf("Reader = %q, want %q", got.Reader, "Test")
+	}
+	if !bytes.Equal(got.UID, testUID) || got.UIDLength != len(testUID) {
+		t.Fatalf("UID = %X (%d), want %X (%d)", got.UID, got.UIDLength, testUID, len(testUID))
+	}
+	if want := "83fb582490"; got.UIDHex != want {
+		t.Fatalf("UIDHex = %q, want %q", got.UIDHex, want)
+	}
+	if !bytes.Equal(got.ATR, atrMifareUltralight) {
+		t.Fatalf("ATR = %X, want %X", got.ATR, atrMifareUltralight)
+	}
+	if got.Type != CardTypeMifareUltralight {
+		t.Fatalf("Type = %v, want %v", got.Type, CardTypeMifareUltralight)
+	}
+	if !got.Time.Equal(clk.Now()) {
+		t.Fatalf("Time = %v, want %v", got.Time, clk.Now())
+	}
+	if got.ReadDuration <= 0 || got.ReadDuration > time.Second {
+		t.Fatalf("ReadDuration = %v, want a positive read duration", got.ReadDuration)
+	}
+}
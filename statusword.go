@@ -0,0 +1,83 @@
+package acr122u
+
+import "fmt"
+
+// StatusWord is the status word (SW1 SW2) ending a response APDU
+type StatusWord uint16
+
+// Common status words of the reader and ISO7816-4 cards
+const (
+	SWSuccess                    StatusWord = 0x9000
+	SWEndOfFile                  StatusWord = 0x6282
+	SWOperationFailed            StatusWord = 0x6300
+	SWMemoryUnchanged            StatusWord = 0x6400
+	SWMemoryFailure              StatusWord = 0x6581
+	SWWrongLength                StatusWord = 0x6700
+	SWFunctionInCLANotSupported  StatusWord = 0x6800
+	SWSecurityStatusNotSatisfied StatusWord = 0x6982
+	SWAuthenticationBlocked      StatusWord = 0x6983
+	SWConditionsNotSatisfied     StatusWord = 0x6985
+	SWCommandNotAllowed          StatusWord = 0x6986
+	SWWrongData                  StatusWord = 0x6A80
+	SWFunctionNotSupported       StatusWord = 0x6A81
+	SWFileNotFound               StatusWord = 0x6A82
+	SWRecordNotFound             StatusWord = 0x6A83
+	SWIncorrectP1P2              StatusWord = 0x6A86
+	SWWrongP1P2                  StatusWord = 0x6B00
+	SWINSNotSupported            StatusWord = 0x6D00
+	SWCLANotSupported            StatusWord = 0x6E00
+	SWNoPreciseDiagnosis         StatusWord = 0x6F00
+)
+
+var statusDescriptions = map[StatusWord]string{
+	SWSuccess:                    "success",
+	SWEndOfFile:                  "end of file reached before reading Le bytes",
+	SWOperationFailed:            "operation failed",
+	SWMemoryUnchanged:            "memory unchanged",
+	SWMemoryFailure:              "memory failure",
+	SWWrongLength:                "wrong length",
+	SWFunctionInCLANotSupported:  "function in CLA not supported",
+	SWSecurityStatusNotSatisfied: "security status not satisfied",
+	SWAuthenticationBlocked:      "authentication method blocked",
+	SWConditionsNotSatisfied:     "conditions of use not satisfied",
+	SWCommandNotAllowed:          "command not allowed",
+	SWWrongData:                  "incorrect data",
+	SWFunctionNotSupported:       "function not supported",
+	SWFileNotFound:               "file or application not found",
+	SWRecordNotFound:             "record not found",
+	SWIncorrectP1P2:              "incorrect parameters P1-P2",
+	SWWrongP1P2:                  "wrong parameters P1-P2",
+	SWINSNotSupported:            "instruction not supported",
+	SWCLANotSupported:            "class not supported",
+	SWNoPreciseDiagnosis:         "no precise diagnosis",
+}
+
+// String returns the description of the status word
+func (sw StatusWord) String() string {
+	return DescribeStatus(uint16(sw))
+}
+
+// DescribeStatus returns a human-readable description of the status word
+func DescribeStatus(sw uint16) string {
+	if d, ok := statusDescriptions[StatusWord(sw)]; ok {
+		return d
+	}
+
+	switch sw & 0xFF00 {
+	case 0x6100:
+		return fmt.Sprintf("%d response bytes available", sw&0xFF)
+	case 0x6C00:
+		return fmt.Sprintf("wrong length, %d bytes available", sw&0xFF)
+	}
+
+	if sw&0xFFF0 == 0x63C0 {
+		return fmt.Sprintf("verification failed, %d tries remaining", sw&0x0F)
+	}
+
+	return fmt.Sprintf("unknown status %04X", sw)
+}
+
+// statusText formats the status word with its description for error messages
+func statusText(sw uint16) string {
+	return fmt.Sprintf("status %04X (%s)", sw, DescribeStatus(sw))
+}
@@ -0,0 +1,48 @@
+package acr122u
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribeStatus(t *testing.T) {
+	for _, tc := range []struct {
+		sw   uint16
+		want string
+	}{
+		{0x9000, "success"},
+		{0x6300, "operation failed"},
+		{0x6982, "security status not satisfied"},
+		{0x6A81, "function not supported"},
+		{0x6A82, "file or application not found"},
+		{0x6D00, "instruction not supported"},
+		{0x6110, "16 response bytes available"},
+		{0x6C07, "wrong length, 7 bytes available"},
+		{0x63C2, "verification failed, 2 tries remaining"},
+		{0x6F12, "unknown status 6F12"},
+		{0x9100, "unknown status 9100"},
+	} {
+		if got := DescribeStatus(tc.sw); got != tc.want {
+			t.Fatalf("DescribeStatus(%04X) = %q, want %q", tc.sw, got, tc.want)
+		}
+	}
+
+	if got, want := SWFileNotFound.String(), "file or application not found"; got != want {
+		t.Fatalf("SWFileNotFound.String() = %q, want %q", got, want)
+	}
+}
+
+func TestCardTransmitStatusText(t *testing.T) {
+	c := transmitCard(func(cmd []byte) ([]byte, error) {
+		return []byte{0x6A, 0x82}, nil
+	})
+
+	_, err := c.transmit([]byte{0x00, 0xA4, 0x04, 0x00, 0x00})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if want := "status 6A82 (file or application not found)"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %q, want it to contain %q", err, want)
+	}
+}
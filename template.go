@@ -0,0 +1,75 @@
+package acr122u
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExpandTemplate assembles an APDU from a hex template such as
+// "FF B0 00 {block} 10", replacing each {name} placeholder with the byte
+// vars[name]. Whitespace between bytes is optional. Returns
+// ErrInvalidParameter for malformed hex or unknown placeholders.
+func ExpandTemplate(template string, vars map[string]byte) ([]byte, error) {
+	var apdu []byte
+
+	for i := 0; i < len(template); {
+		switch ch := template[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, wrapError(fmt.Sprintf("unterminated placeholder at offset %d", i), ErrInvalidParameter)
+			}
+			name := template[i+1 : i+end]
+			val, ok := vars[name]
+			if !ok {
+				return nil, wrapError(fmt.Sprintf("unknown placeholder {%s}", name), ErrInvalidParameter)
+			}
+			apdu = append(apdu, val)
+			i += end + 1
+		default:
+			if i+1 >= len(template) {
+				return nil, wrapError(fmt.Sprintf("odd hex digit at offset %d", i), ErrInvalidParameter)
+			}
+			hi, ok1 := hexDigit(template[i])
+			lo, ok2 := hexDigit(template[i+1])
+			if !ok1 || !ok2 {
+				return nil, wrapError(fmt.Sprintf("malformed hex %q at offset %d", template[i:i+2], i), ErrInvalidParameter)
+			}
+			apdu = append(apdu, hi<<4|lo)
+			i += 2
+		}
+	}
+
+	if len(apdu) == 0 {
+		return nil, wrapError("empty template", ErrInvalidParameter)
+	}
+
+	return apdu, nil
+}
+
+// hexDigit returns the value of the hex digit
+func hexDigit(ch byte) (byte, bool) {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return ch - '0', true
+	case ch >= 'a' && ch <= 'f':
+		return ch - 'a' + 10, true
+	case ch >= 'A' && ch <= 'F':
+		return ch - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+// TransmitTemplate assembles the APDU using ExpandTemplate and transmits it,
+// returning the response data
+func (c *card) TransmitTemplate(template string, vars map[string]byte) ([]byte, error) {
+	apdu, err := ExpandTemplate(template, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.transmit(apdu)
+}
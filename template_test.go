@@ -0,0 +1,67 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]byte{"block": 0x04, "len": 0x10}
+
+	for _, tc := range []struct {
+		name     string
+		template string
+		want     []byte
+		err      error
+	}{
+		{"Read block", "FF B0 00 {block} 10", []byte{0xFF, 0xB0, 0x00, 0x04, 0x10}, nil},
+		{"No whitespace", "ffb000{block}{len}", []byte{0xFF, 0xB0, 0x00, 0x04, 0x10}, nil},
+		{"Placeholders only", "{len} {block}", []byte{0x10, 0x04}, nil},
+		{"Unknown placeholder", "FF B0 00 {page} 10", nil, ErrInvalidParameter},
+		{"Unterminated placeholder", "FF B0 00 {block", nil, ErrInvalidParameter},
+		{"Odd hex", "FF B0 0", nil, ErrInvalidParameter},
+		{"Split byte", "FF B 0", nil, ErrInvalidParameter},
+		{"Not hex", "FF BG", nil, ErrInvalidParameter},
+		{"Empty", " ", nil, ErrInvalidParameter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ExpandTemplate(tc.template, vars)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Fatalf("ExpandTemplate(%q) = %X, want %X", tc.template, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCardTransmitTemplate(t *testing.T) {
+	var sent []byte
+
+	c := transmitCard(func(cmd []byte) ([]byte, error) {
+		sent = cmd
+		return append(bytes.Repeat([]byte{0xAB}, 16), rcOperationSuccess...), nil
+	})
+
+	resp, err := c.TransmitTemplate("FF B0 00 {block} 10", map[string]byte{"block": 0x08})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []byte{0xFF, 0xB0, 0x00, 0x08, 0x10}; !bytes.Equal(sent, want) {
+		t.Fatalf("sent %X, want %X", sent, want)
+	}
+	if want := bytes.Repeat([]byte{0xAB}, 16); !bytes.Equal(resp, want) {
+		t.Fatalf("TransmitTemplate() = %X, want %X", resp, want)
+	}
+
+	sent = nil
+	if _, err := c.TransmitTemplate("FF B0 00 {page} 10", nil); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent != nil {
+		t.Fatalf("sent %X for invalid template", sent)
+	}
+}
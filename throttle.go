@@ -0,0 +1,37 @@
+package acr122u
+
+import (
+	"sync"
+	"time"
+)
+
+// readThrottle spaces the card reads of each reader at least interval apart.
+// A nil *readThrottle is valid and never delays reads.
+type readThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newReadThrottle(interval time.Duration) *readThrottle {
+	return &readThrottle{interval: interval, next: map[string]time.Time{}}
+}
+
+// delay returns how long to wait before reading a card on the reader,
+// reserving the read slot so concurrent reads are spaced as well
+func (rt *readThrottle) delay(reader string, now time.Time) time.Duration {
+	if rt == nil {
+		return 0
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	at := now
+	if next, ok := rt.next[reader]; ok && next.After(now) {
+		at = next
+	}
+	rt.next[reader] = at.Add(rt.interval)
+
+	return at.Sub(now)
+}
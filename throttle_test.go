@@ -0,0 +1,37 @@
+package acr122u
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadThrottle(t *testing.T) {
+	clk := newMockClock()
+	rt := newReadThrottle(250 * time.Millisecond)
+
+	for i, tc := range []struct {
+		advance time.Duration
+		reader  string
+		want    time.Duration
+	}{
+		{0, "A", 0},
+		{100 * time.Millisecond, "A", 150 * time.Millisecond},
+		{0, "B", 0},
+		{150 * time.Millisecond, "A", 250 * time.Millisecond},
+		{time.Second, "A", 0},
+	} {
+		clk.Advance(tc.advance)
+
+		if got := rt.delay(tc.reader, clk.Now()); got != tc.want {
+			t.Fatalf("read %d: delay(%q) = %v, want %v", i, tc.reader, got, tc.want)
+		}
+	}
+}
+
+func TestReadThrottleNil(t *testing.T) {
+	var rt *readThrottle
+
+	if got := rt.delay("A", time.Now()); got != 0 {
+		t.Fatalf("delay() = %v, want 0", got)
+	}
+}
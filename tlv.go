@@ -0,0 +1,144 @@
+package acr122u
+
+// TLV is a BER-TLV data object as found in card responses (EMV, FCI, ...)
+type TLV struct {
+	// Tag contains the raw tag bytes, e.g. 0x6F or 0x9F38
+	Tag uint32
+
+	// Value contains the raw value bytes
+	Value []byte
+
+	// Children contains the nested data objects of a constructed TLV
+	Children []TLV
+}
+
+// Constructed reports if the TLV is a constructed data object
+func (t TLV) Constructed() bool {
+	first := t.Tag
+	for first > 0xFF {
+		first >>= 8
+	}
+
+	return first&0x20 != 0
+}
+
+// Find returns the first nested TLV with the given tag, searching depth first
+func (t TLV) Find(tag uint32) (*TLV, bool) {
+	return FindTLV(t.Children, tag)
+}
+
+// FindTLV returns the first TLV with the given tag in tlvs, searching depth first
+func FindTLV(tlvs []TLV, tag uint32) (*TLV, bool) {
+	for i := range tlvs {
+		if tlvs[i].Tag == tag {
+			return &tlvs[i], true
+		}
+
+		if found, ok := tlvs[i].Find(tag); ok {
+			return found, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParseTLV parses BER-TLV encoded data.
+//
+// Tags of up to four bytes and definite lengths of up to four bytes are
+// supported. Constructed data objects are parsed into their Children.
+// Padding bytes (0x00 and 0xFF) between data objects are skipped.
+func ParseTLV(data []byte) ([]TLV, error) {
+	var tlvs []TLV
+
+	for len(data) > 0 {
+		if data[0] == 0x00 || data[0] == 0xFF {
+			data = data[1:]
+			continue
+		}
+
+		tag, n, err := parseTLVTag(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+
+		length, n, err := parseTLVLength(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+
+		if len(data) < length {
+			return nil, wrapError("value exceeds data", ErrInvalidTLV)
+		}
+
+		t := TLV{Tag: tag, Value: data[:length]}
+		data = data[length:]
+
+		if t.Constructed() {
+			if t.Children, err = ParseTLV(t.Value); err != nil {
+				return nil, err
+			}
+		}
+
+		tlvs = append(tlvs, t)
+	}
+
+	return tlvs, nil
+}
+
+// parseTLVTag returns the tag at the start of data and its length in bytes
+func parseTLVTag(data []byte) (uint32, int, error) {
+	tag := uint32(data[0])
+	if data[0]&0x1F != 0x1F {
+		return tag, 1, nil
+	}
+
+	for n := 1; n < len(data); n++ {
+		if n == 4 {
+			return 0, 0, wrapError("tag too long", ErrInvalidTLV)
+		}
+
+		tag = tag<<8 | uint32(data[n])
+		if data[n]&0x80 == 0 {
+			return tag, n + 1, nil
+		}
+	}
+
+	return 0, 0, wrapError("truncated tag", ErrInvalidTLV)
+}
+
+// parseTLVLength returns the length at the start of data and its size in
+// bytes. Lengths exceeding the data following them are rejected.
+func parseTLVLength(data []byte) (int, int, error) {
+	if len(data) == 0 {
+		return 0, 0, wrapError("missing length", ErrInvalidTLV)
+	}
+
+	if data[0] < 0x80 {
+		return int(data[0]), 1, nil
+	}
+
+	n := int(data[0] & 0x7F)
+	switch {
+	case n == 0:
+		return 0, 0, wrapError("indefinite length not supported", ErrInvalidTLV)
+	case n > 4:
+		return 0, 0, wrapError("length too long", ErrInvalidTLV)
+	case len(data) < n+1:
+		return 0, 0, wrapError("truncated length", ErrInvalidTLV)
+	}
+
+	// Accumulate as uint64 and bound by the data left, as a four byte
+	// length overflows int on 32-bit platforms
+	var length uint64
+	for _, b := range data[1 : n+1] {
+		length = length<<8 | uint64(b)
+	}
+
+	if length > uint64(len(data)-n-1) {
+		return 0, 0, wrapError("value exceeds data", ErrInvalidTLV)
+	}
+
+	return int(length), n + 1, nil
+}
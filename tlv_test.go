@@ -0,0 +1,179 @@
+package acr122u
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseTLV(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want []TLV
+	}{
+		{
+			"Empty",
+			nil,
+			nil,
+		},
+		{
+			"Single byte tag",
+			[]byte{0x84, 0x02, 0xA0, 0x00},
+			[]TLV{{Tag: 0x84, Value: []byte{0xA0, 0x00}}},
+		},
+		{
+			"Multi-byte tag",
+			[]byte{0x9F, 0x38, 0x03, 0x9F, 0x66, 0x04},
+			[]TLV{{Tag: 0x9F38, Value: []byte{0x9F, 0x66, 0x04}}},
+		},
+		{
+			"Three byte tag",
+			[]byte{0xDF, 0x81, 0x01, 0x01, 0x42},
+			[]TLV{{Tag: 0xDF8101, Value: []byte{0x42}}},
+		},
+		{
+			"Long form length",
+			append([]byte{0x50, 0x81, 0x80}, make([]byte, 0x80)...),
+			[]TLV{{Tag: 0x50, Value: make([]byte, 0x80)}},
+		},
+		{
+			"Two byte long form length",
+			append([]byte{0x50, 0x82, 0x01, 0x00}, make([]byte, 0x100)...),
+			[]TLV{{Tag: 0x50, Value: make([]byte, 0x100)}},
+		},
+		{
+			"Padding",
+			[]byte{0x00, 0x50, 0x01, 0x41, 0xFF, 0xFF, 0x87, 0x01, 0x01},
+			[]TLV{
+				{Tag: 0x50, Value: []byte{0x41}},
+				{Tag: 0x87, Value: []byte{0x01}},
+			},
+		},
+		{
+			"Nested",
+			[]byte{
+				0x6F, 0x0C,
+				0x84, 0x02, 0xA0, 0x00,
+				0xA5, 0x06,
+				0x50, 0x01, 0x41,
+				0x87, 0x01, 0x01,
+			},
+			[]TLV{
+				{
+					Tag:   0x6F,
+					Value: []byte{0x84, 0x02, 0xA0, 0x00, 0xA5, 0x06, 0x50, 0x01, 0x41, 0x87, 0x01, 0x01},
+					Children: []TLV{
+						{Tag: 0x84, Value: []byte{0xA0, 0x00}},
+						{
+							Tag:   0xA5,
+							Value: []byte{0x50, 0x01, 0x41, 0x87, 0x01, 0x01},
+							Children: []TLV{
+								{Tag: 0x50, Value: []byte{0x41}},
+								{Tag: 0x87, Value: []byte{0x01}},
+							},
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTLV(tc.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tlvsEqual(got, tc.want) {
+				t.Fatalf("ParseTLV(%X) = %#v, want %#v", tc.data, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTLVErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"Truncated tag", []byte{0x9F}},
+		{"Tag too long", []byte{0x9F, 0x81, 0x81, 0x81, 0x01, 0x00}},
+		{"Missing length", []byte{0x50}},
+		{"Indefinite length", []byte{0x50, 0x80, 0x00, 0x00}},
+		{"Length too long", []byte{0x50, 0x85, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}},
+		{"Truncated length", []byte{0x50, 0x82, 0x01}},
+		{"Value exceeds data", []byte{0x50, 0x03, 0x41}},
+		{"Four byte length exceeds data", []byte{0x50, 0x84, 0x00, 0x01, 0x00, 0x00, 0x41}},
+		{"Four byte length overflows int32", []byte{0x50, 0x84, 0xFF, 0xFF, 0xFF, 0xFF, 0x41}},
+		{"Invalid nested", []byte{0x6F, 0x02, 0x84, 0x05}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseTLV(tc.data); !errors.Is(err, ErrInvalidTLV) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTLVFind(t *testing.T) {
+	tlvs, err := ParseTLV([]byte{
+		0x6F, 0x0D,
+		0x84, 0x02, 0xA0, 0x00,
+		0xA5, 0x07,
+		0x50, 0x01, 0x41,
+		0x9F, 0x38, 0x01, 0x42,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fci, ok := FindTLV(tlvs, 0x6F)
+	if !ok {
+		t.Fatalf("tag 6F not found")
+	}
+
+	pdol, ok := fci.Find(0x9F38)
+	if !ok {
+		t.Fatalf("tag 9F38 not found")
+	}
+
+	if got, want := pdol.Value, []byte{0x42}; !bytes.Equal(got, want) {
+		t.Fatalf("pdol.Value = %X, want %X", got, want)
+	}
+
+	if _, ok := fci.Find(0x87); ok {
+		t.Fatalf("tag 87 should not be found")
+	}
+}
+
+func TestTLVConstructed(t *testing.T) {
+	for _, tc := range []struct {
+		tag  uint32
+		want bool
+	}{
+		{0x6F, true},
+		{0x84, false},
+		{0xBF0C, true},
+		{0x9F38, false},
+	} {
+		if got := (TLV{Tag: tc.tag}).Constructed(); got != tc.want {
+			t.Fatalf("TLV{Tag: %X}.Constructed() = %v, want %v", tc.tag, got, tc.want)
+		}
+	}
+}
+
+func tlvsEqual(a, b []TLV) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Tag != b[i].Tag ||
+			!bytes.Equal(a[i].Value, b[i].Value) ||
+			!tlvsEqual(a[i].Children, b[i].Children) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+package acr122u
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+// heartbeatKey is the context key of the heartbeat of a watched read loop
+type heartbeatKey struct{}
+
+// heartbeat records when a read loop last returned from GetStatusChange
+type heartbeat struct {
+	last atomic.Int64
+}
+
+func newHeartbeat() *heartbeat {
+	hb := &heartbeat{}
+	hb.beat()
+	return hb
+}
+
+// beat records that the read loop is alive
+func (hb *heartbeat) beat() {
+	hb.last.Store(time.Now().UnixNano())
+}
+
+// since returns the time since the last beat
+func (hb *heartbeat) since() time.Duration {
+	return time.Duration(time.Now().UnixNano() - hb.last.Load())
+}
+
+// Records a beat of the heartbeat of the read loop running with ctx, if watched
+func beat(ctx context.Context) {
+	if hb, ok := ctx.Value(heartbeatKey{}).(*heartbeat); ok {
+		hb.beat()
+	}
+}
+
+// Runs the read loop, restarting it on a re-established PC/SC context when
+// WithWatchdog detects that GetStatusChange stopped returning. The stalled
+// loop is abandoned, it exits once GetStatusChange returns. Reading stops if
+// the context cannot be re-established, as the stalled loop would not exit.
+func (actx *Context) watchedReadLoop(ctx context.Context, stop func(error), readers []string, results chan<- scard.ReaderState) {
+	if actx.watchdog <= 0 {
+		actx.readLoop(ctx, stop, readers, results)
+		return
+	}
+	for {
+		loopCtx, cancel := context.WithCancel(ctx)
+		hb := newHeartbeat()
+		loopResults := make(chan scard.ReaderState)
+		go func() {
+			defer close(loopResults)
+			actx.readLoop(context.WithValue(loopCtx, heartbeatKey{}, hb), stop, readers, loopResults)
+		}()
+		stalled := actx.forwardResults(hb, loopResults, results)
+		cancel()
+		if !stalled {
+			return
+		}
+		go discardResults(loopResults)
+		if ctx.Err() != nil {
+			return
+		}
+		if err := actx.restartReadLoop(readers); err != nil {
+			stop(err)
+			return
+		}
+	}
+}
+
+// Forwards the results of a read loop until it returns, reporting true if
+// the watchdog timeout passed without a heartbeat first
+func (actx *Context) forwardResults(hb *heartbeat, loopResults <-chan scard.ReaderState, results chan<- scard.ReaderState) bool {
+	ticker := time.NewTicker(actx.watchdog / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case s, ok := <-loopResults:
+			if !ok {
+				return false
+			}
+			results <- s
+			// The loop was waiting for the state to be received
+			hb.beat()
+		case <-ticker.C:
+			if hb.since() > actx.watchdog {
+				return true
+			}
+		}
+	}
+}
+
+// Disconnects the cards read by an abandoned read loop until it exits
+func discardResults(loopResults <-chan scard.ReaderState) {
+	for s := range loopResults {
+		if c, ok := s.UserData.(cardData); ok {
+			_ = c.disconnect()
+		}
+	}
+}
+
+// Reports the stalled read loop and re-establishes the PC/SC context,
+// which interrupts the stalled GetStatusChange call
+func (actx *Context) restartReadLoop(readers []string) error {
+	actx.logger.Error().Strs("Readers", readers).Dur("Timeout", actx.watchdog).Msg("Watchdog restarting stalled read loop")
+	for _, r := range readers {
+		actx.readerError(r, ErrWatchdogTimeout)
+	}
+	if _, err := actx.reestablish(actx.contextGeneration()); err != nil {
+		actx.logger.Error().Err(err).Msg("Problem re-establishing context")
+		return wrapError("watchdog restart", err)
+	}
+	return nil
+}
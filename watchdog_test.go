@@ -0,0 +1,138 @@
+package acr122u
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+// shortPollInterval shortens the poll interval for the duration of the test,
+// allowing watchdog timeouts below a second
+func shortPollInterval(t *testing.T) {
+	t.Helper()
+	old := pollInterval
+	pollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { pollInterval = old })
+}
+
+func TestContextServeWatchdog(t *testing.T) {
+	shortPollInterval(t)
+
+	var (
+		mu          sync.Mutex
+		readerErrs  []error
+		established int
+		hung        = make(chan struct{})
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actx, err := newContext(&mockContext{
+		release: func() error {
+			close(hung)
+			return nil
+		},
+		getStatusChange: func([]scard.ReaderState, time.Duration) error {
+			// Hangs until the context is released
+			<-hung
+			return scard.ErrCancelled
+		},
+	}, WithWatchdog(50*time.Millisecond), WithReaderErrorHandler(func(reader string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		readerErrs = append(readerErrs, err)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actx.establish = func() (PCSCContext, error) {
+		established++
+		return &mockContext{
+			connect:         uidConnect,
+			getStatusChange: statusSequence(scard.StatePresent),
+		}, nil
+	}
+
+	var uid []byte
+	if err := actx.ServeFunc(ctx, func(c Card) {
+		uid = c.UID()
+		cancel()
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if established != 1 || !bytes.Equal(uid, testUID) {
+		t.Fatalf("established = %d, uid = %X, want 1, %X", established, uid, testUID)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(readerErrs) != 1 || !errors.Is(readerErrs[0], ErrWatchdogTimeout) {
+		t.Fatalf("reader errors = %v, want [%v]", readerErrs, ErrWatchdogTimeout)
+	}
+}
+
+func TestContextServeWatchdogNotReestablished(t *testing.T) {
+	shortPollInterval(t)
+
+	var (
+		mu         sync.Mutex
+		readerErrs []error
+		hung       = make(chan struct{})
+	)
+
+	actx, err := newContext(&mockContext{
+		release: func() error {
+			close(hung)
+			return nil
+		},
+		getStatusChange: func([]scard.ReaderState, time.Duration) error {
+			<-hung
+			return scard.ErrCancelled
+		},
+	}, WithWatchdog(50*time.Millisecond), WithReaderErrorHandler(func(reader string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		readerErrs = append(readerErrs, err)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer actx.Release()
+
+	// Contexts from NewContext cannot be re-established, the loop is not restarted
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := actx.ServeFunc(ctx, func(Card) {}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ctx.Err() != nil {
+		t.Fatalf("Serve returned after %v, want on the first timeout", ctx.Err())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(readerErrs) != 1 {
+		t.Fatalf("reader errors = %v, want [%v]", readerErrs, ErrWatchdogTimeout)
+	}
+}
+
+func TestWithWatchdogInvalid(t *testing.T) {
+	for _, timeout := range []time.Duration{-time.Second, time.Millisecond, time.Second} {
+		if _, err := newContext(&mockContext{}, WithWatchdog(timeout)); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("WithWatchdog(%v) err = %v, want %v", timeout, err, ErrInvalidParameter)
+		}
+	}
+
+	if _, err := newContext(&mockContext{}, WithWatchdog(2*time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package acr122u
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// ToWiegand26 returns the 26-bit Wiegand (H10301) credential for the last 3
+// bytes of the UID: an 8-bit facility code (uid[n-3]) and a 16-bit card
+// number (uid[n-2:]), framed by an even parity bit over the leading 12 data
+// bits and an odd parity bit over the trailing 12 data bits. The credential
+// is returned in the low 26 bits, the leading parity bit first.
+func ToWiegand26(uid []byte) (uint32, error) {
+	if len(uid) < 3 {
+		return 0, wrapError(fmt.Sprintf("UID %X too short for Wiegand 26", uid), ErrInvalidParameter)
+	}
+
+	n := len(uid)
+	data := uint64(uid[n-3])<<16 | uint64(uid[n-2])<<8 | uint64(uid[n-1])
+
+	return uint32(wiegand(data, 24)), nil
+}
+
+// ToWiegand34 returns the 34-bit Wiegand credential for the last 4 bytes of
+// the UID: a 16-bit facility code (uid[n-4:n-2]) and a 16-bit card number
+// (uid[n-2:]), framed by an even parity bit over the leading 16 data bits
+// and an odd parity bit over the trailing 16 data bits. The credential is
+// returned in the low 34 bits, the leading parity bit first.
+func ToWiegand34(uid []byte) (uint64, error) {
+	if len(uid) < 4 {
+		return 0, wrapError(fmt.Sprintf("UID %X too short for Wiegand 34", uid), ErrInvalidParameter)
+	}
+
+	n := len(uid)
+	data := uint64(uid[n-4])<<24 | uint64(uid[n-3])<<16 | uint64(uid[n-2])<<8 | uint64(uid[n-1])
+
+	return wiegand(data, 32), nil
+}
+
+// wiegand frames the data bits with an even parity bit over the leading half
+// and an odd parity bit over the trailing half
+func wiegand(data uint64, n int) uint64 {
+	half := n / 2
+	leading := data >> half
+	trailing := data & (1<<half - 1)
+
+	even := uint64(bits.OnesCount64(leading) % 2)
+	odd := uint64(1 - bits.OnesCount64(trailing)%2)
+
+	return even<<(n+1) | data<<1 | odd
+}
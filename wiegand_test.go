@@ -0,0 +1,60 @@
+package acr122u
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToWiegand26(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		uid  []byte
+		want uint32
+		err  error
+	}{
+		// Facility 0, card 0: only the odd parity bit is set
+		{"Zero", []byte{0x00, 0x00, 0x00}, 0x0000001, nil},
+		// Facility 1, card 1: both halves have a single bit set
+		{"One", []byte{0x01, 0x00, 0x01}, 0x2020002, nil},
+		// Facility 255, card 65535: even halves
+		{"All ones", []byte{0xFF, 0xFF, 0xFF}, 0x1FFFFFF, nil},
+		// Facility 18, card 13330 (0x3412), from the last three UID bytes
+		{"Four byte UID", []byte{0x04, 0x12, 0x34, 0x12}, 0x0246824, nil},
+		{"Too short", []byte{0x01, 0x02}, 0, ErrInvalidParameter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ToWiegand26(tc.uid)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("ToWiegand26(%X) = %07X, want %07X", tc.uid, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestToWiegand34(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		uid  []byte
+		want uint64
+		err  error
+	}{
+		{"Zero", []byte{0x00, 0x00, 0x00, 0x00}, 0x000000001, nil},
+		{"One", []byte{0x00, 0x01, 0x00, 0x01}, 0x200020002, nil},
+		{"All ones", []byte{0xFF, 0xFF, 0xFF, 0xFF}, 0x1FFFFFFFF, nil},
+		{"Seven byte UID", []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, 0x06688AACD, nil},
+		{"Too short", []byte{0x01, 0x02, 0x03}, 0, ErrInvalidParameter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ToWiegand34(tc.uid)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("ToWiegand34(%X) = %09X, want %09X", tc.uid, got, tc.want)
+			}
+		})
+	}
+}